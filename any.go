@@ -0,0 +1,113 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tada/catch"
+)
+
+// A NumberMode selects the Go type of the numbers that are returned by ReadAny.
+type NumberMode int
+
+const (
+	// NumberFloat64 represents all numbers as float64, like encoding/json does.
+	NumberFloat64 = NumberMode(iota)
+
+	// NumberJSON represents all numbers as json.Number, i.e. as their literal text.
+	NumberJSON
+
+	// NumberInt64 represents integral numbers that fit an int64 as int64 and all other numbers as float64.
+	NumberInt64
+
+	// NumberBig represents integral numbers as *big.Int and all other numbers as *big.Float with a precision that
+	// keeps all of their digits.
+	NumberBig
+)
+
+// An AnyOption configures the ReadAny method of the Decoder.
+type AnyOption func(*anyOptions)
+
+type anyOptions struct {
+	numbers   NumberMode
+	ordered   bool
+	maxDepth  int
+	allocator Allocator
+
+	// scratch holds the elements of the arrays that are being read
+	scratch []interface{}
+}
+
+// AnyNumbers makes ReadAny represent numbers as given by the mode. The default is the mode of the Numbers option of
+// the decoder, or NumberFloat64.
+func AnyNumbers(mode NumberMode) AnyOption {
+	return func(o *anyOptions) {
+		o.numbers = mode
+	}
+}
+
+// AnyOrdered makes ReadAny return objects as an *OrderedObject instead of a map[string]interface{} so that the order
+// of the keys is kept. The values of an OrderedObject are raw JSON, which can be materialized with UnmarshalAny.
+func AnyOrdered() AnyOption {
+	return func(o *anyOptions) {
+		o.ordered = true
+	}
+}
+
+// AnyMaxDepth makes ReadAny raise a panic with a catch.Error when arrays and objects are nested deeper than the given
+// depth, e.g. to protect against untrusted input that would otherwise use a lot of memory. A depth of zero, which is
+// the default, means no limit.
+func AnyMaxDepth(depth int) AnyOption {
+	return func(o *anyOptions) {
+		o.maxDepth = depth
+	}
+}
+
+func newAnyOptions(numbers NumberMode, options []AnyOption) *anyOptions {
+	o := &anyOptions{numbers: numbers}
+	for _, option := range options {
+		option(o)
+	}
+	return o
+}
+
+// number returns the given number in the representation of the options.
+func (o *anyOptions) number(n json.Number) (interface{}, error) {
+	switch o.numbers {
+	case NumberJSON:
+		return n, nil
+	case NumberInt64:
+		if !strings.ContainsAny(string(n), ".eE") {
+			if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+				return i, nil
+			}
+		}
+	case NumberBig:
+		if !strings.ContainsAny(string(n), ".eE") {
+			if i, ok := new(big.Int).SetString(string(n), 10); ok {
+				return i, nil
+			}
+		}
+		// about 3.3 bits are needed per decimal digit
+		prec := uint(4 * len(n))
+		if prec < 64 {
+			prec = 64
+		}
+		f, _, err := big.ParseFloat(string(n), 10, prec, big.ToNearestEven)
+		return f, err
+	}
+	f, err := parseFloat([]byte(n))
+	return f, err
+}
+
+// UnmarshalAny returns the value of the given JSON as it is returned by the ReadAny method of the Decoder.
+//
+// The function will recover a catch.Error panic and return its cause.
+func UnmarshalAny(bs []byte, options ...AnyOption) (v interface{}, err error) {
+	err = catch.Do(func() {
+		v = NewBytesDecoder(bs).ReadAny(options...)
+	})
+	return
+}
@@ -0,0 +1,110 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestReadAny(t *testing.T) {
+	input := `{"a": [1, 2.5, "x", true, null, {}], "b": {"n": 12345678901234567890}}`
+	var ex interface{}
+	if err := json.Unmarshal([]byte(input), &ex); err != nil {
+		t.Fatal(err)
+	}
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var v interface{}
+		if err := catch.Do(func() { v = js.ReadAny() }); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, ex) {
+			t.Errorf("expected %v, got %v", ex, v)
+		}
+	}
+}
+
+func TestReadAny_numbers(t *testing.T) {
+	input := []byte(`[1, -2, 2.5, 1e3, 12345678901234567890]`)
+	tests := []struct {
+		mode NumberMode
+		ex   string
+	}{
+		{NumberFloat64, `[float64(1) float64(-2) float64(2.5) float64(1000) float64(1.2345678901234567e+19)]`},
+		{NumberJSON, `[json.Number(1) json.Number(-2) json.Number(2.5) json.Number(1e3) ` +
+			`json.Number(12345678901234567890)]`},
+		{NumberInt64, `[int64(1) int64(-2) float64(2.5) float64(1000) float64(1.2345678901234567e+19)]`},
+	}
+	for _, tt := range tests {
+		v, err := UnmarshalAny(input, AnyNumbers(tt.mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var a []string
+		for _, e := range v.([]interface{}) {
+			a = append(a, fmt.Sprintf("%T(%v)", e, e))
+		}
+		if s := fmt.Sprint(a); s != tt.ex {
+			t.Errorf("mode %d: expected %s, got %s", tt.mode, tt.ex, s)
+		}
+	}
+}
+
+func TestReadAny_ordered(t *testing.T) {
+	v, err := UnmarshalAny([]byte(`[{"z": 1, "a": {"y": 2, "b": 3}}]`), AnyOrdered())
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := v.([]interface{})[0].(*OrderedObject)
+	if bs, _ := o.MarshalJSON(); string(bs) != `{"z":1,"a":{"y": 2, "b": 3}}` {
+		t.Errorf("unexpected object %s", bs)
+	}
+}
+
+func TestReadAny_maxDepth(t *testing.T) {
+	if _, err := UnmarshalAny([]byte(`[[{"a": 1}]]`), AnyMaxDepth(3)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := UnmarshalAny([]byte(`[[{"a": []}]]`), AnyMaxDepth(3))
+	if err == nil || err.Error() != `maximum depth 3 exceeded` {
+		t.Errorf("expected depth error, got %v", err)
+	}
+	if _, err = UnmarshalAny([]byte(`]`)); err == nil {
+		t.Error("expected delimiter error")
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	input := `[1, 2.5, 123456789012345678901234567890, 0.1234567890123456789012345]`
+	for _, js := range []Decoder{
+		NewDecoder(strings.NewReader(input), Numbers(NumberBig)),
+		NewBytesDecoder([]byte(input), Numbers(NumberBig)),
+	} {
+		var v interface{}
+		if err := catch.Do(func() { v = js.ReadAny() }); err != nil {
+			t.Fatal(err)
+		}
+		var a []string
+		for _, e := range v.([]interface{}) {
+			a = append(a, fmt.Sprintf("%T(%v)", e, e))
+		}
+		ex := `[*big.Int(1) *big.Float(2.5) *big.Int(123456789012345678901234567890) ` +
+			`*big.Float(0.1234567890123456789012345)]`
+		if s := fmt.Sprint(a); s != ex {
+			t.Errorf("expected %s, got %s", ex, s)
+		}
+	}
+	v, err := UnmarshalAny([]byte(`[1]`), AnyNumbers(NumberJSON))
+	if err != nil || fmt.Sprintf("%T", v.([]interface{})[0]) != "json.Number" {
+		t.Errorf("expected AnyNumbers to apply, got %v, %v", v, err)
+	}
+	var n interface{}
+	if err = catch.Do(func() {
+		n = NewBytesDecoder([]byte(`7`), Numbers(NumberJSON)).ReadAny(AnyNumbers(NumberInt64))
+	}); err != nil || n != int64(7) {
+		t.Errorf("expected AnyNumbers to override Numbers, got %v, %v", n, err)
+	}
+}
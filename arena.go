@@ -0,0 +1,94 @@
+package jsonstream
+
+// An Allocator allocates the slices and maps of decoded values. It is used by ReadAny and is available to consumers
+// through the Allocator method of the Decoder, so that batch jobs can allocate the object graphs of their documents
+// from an Arena and release them wholesale between documents.
+type Allocator interface {
+	// Slice returns an empty slice with a capacity of at least n
+	Slice(n int) []interface{}
+
+	// Map returns an empty map with room for at least n entries
+	Map(n int) map[string]interface{}
+}
+
+type heapAllocator struct{}
+
+func (heapAllocator) Slice(n int) []interface{} {
+	return make([]interface{}, 0, n)
+}
+
+func (heapAllocator) Map(n int) map[string]interface{} {
+	return make(map[string]interface{}, n)
+}
+
+// An Arena is an Allocator that allocates slices from chunks and keeps the maps that it has allocated. Reset releases
+// everything that has been allocated at once, so that the next document reuses the chunks and maps of the previous one
+// rather than causing new allocations. A slice or map that was allocated before a call to Reset must not be used after
+// it, since its contents will be overwritten.
+//
+// An Arena is not safe for concurrent use.
+type Arena struct {
+	size   int
+	chunks [][]interface{}
+
+	// chunk is the index of the chunk that slices are allocated from and off is the offset of its free part
+	chunk int
+	off   int
+
+	// maps holds all maps allocated, of which the first nMaps are in use
+	maps  []map[string]interface{}
+	nMaps int
+}
+
+// NewArena creates a new Arena that allocates chunks with room for the given number of slice elements. Slices with a
+// capacity larger than an eighth of the chunk size are allocated as usual.
+func NewArena(chunkSize int) *Arena {
+	return &Arena{size: chunkSize}
+}
+
+// Slice returns an empty slice with a capacity of n. A slice that is appended beyond its capacity is moved to the heap
+// as usual.
+func (a *Arena) Slice(n int) []interface{} {
+	if n > a.size/8 {
+		return make([]interface{}, 0, n)
+	}
+	if a.chunk < len(a.chunks) && a.off+n > a.size {
+		a.chunk++
+		a.off = 0
+	}
+	if a.chunk == len(a.chunks) {
+		a.chunks = append(a.chunks, make([]interface{}, a.size))
+	}
+	s := a.chunks[a.chunk][a.off : a.off : a.off+n]
+	a.off += n
+	return s
+}
+
+// Map returns an empty map. Maps that were released by Reset are reused.
+func (a *Arena) Map(n int) map[string]interface{} {
+	if a.nMaps == len(a.maps) {
+		a.maps = append(a.maps, make(map[string]interface{}, n))
+	}
+	m := a.maps[a.nMaps]
+	a.nMaps++
+	return m
+}
+
+// Reset releases all slices and maps allocated from the arena. The elements of the slices and the entries of the maps
+// are cleared so that the values that they refer to can be garbage collected.
+func (a *Arena) Reset() {
+	for i := 0; i < len(a.chunks) && i <= a.chunk; i++ {
+		c := a.chunks[i]
+		for j := range c {
+			c[j] = nil
+		}
+	}
+	for _, m := range a.maps[:a.nMaps] {
+		for k := range m {
+			delete(m, k)
+		}
+	}
+	a.chunk = 0
+	a.off = 0
+	a.nMaps = 0
+}
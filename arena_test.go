@@ -0,0 +1,80 @@
+package jsonstream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArena_Slice(t *testing.T) {
+	a := NewArena(16)
+	x := append(a.Slice(2), 1, 2)
+	y := append(a.Slice(2), 3, 4)
+	if &y[0] != &a.chunks[0][2] || &x[0] != &a.chunks[0][0] {
+		t.Fatal("expected slices to share a chunk")
+	}
+	if z := append(x, 5); &z[0] == &x[0] {
+		t.Fatal("expected a slice appended beyond its capacity to be moved")
+	}
+	if b := a.Slice(3); cap(b) != 3 || len(a.chunks) != 1 {
+		t.Fatalf("expected a large slice to be allocated as usual, got capacity %d", cap(b))
+	}
+	for i := 0; i < 7; i++ {
+		a.Slice(2)
+	}
+	if len(a.chunks) != 2 || a.chunk != 1 {
+		t.Fatalf("expected a second chunk, got %d", len(a.chunks))
+	}
+	a.Reset()
+	if x[0] != nil || y[1] != nil {
+		t.Fatal("expected Reset to clear the slices")
+	}
+	if s := a.Slice(2); cap(s) != 2 || &s[:1][0] != &x[0] {
+		t.Fatal("expected Reset to reuse the chunks")
+	}
+}
+
+func TestArena_Map(t *testing.T) {
+	a := NewArena(16)
+	m := a.Map(1)
+	m["a"] = 1
+	if n := a.Map(0); len(n) != 0 || len(a.maps) != 2 {
+		t.Fatal("expected a new map")
+	}
+	a.Reset()
+	if n := a.Map(0); len(n) != 0 || reflect.ValueOf(n).Pointer() != reflect.ValueOf(m).Pointer() {
+		t.Fatal("expected Reset to clear and reuse the maps")
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	a := NewArena(64)
+	js := NewBytesDecoder([]byte(`{"a": [1, [2, 3], []], "b": {}} [true]`), Allocate(a), Numbers(NumberInt64))
+	if js.Allocator() != a {
+		t.Fatal("expected the decoder to return the arena")
+	}
+	v := js.ReadAny()
+	ex := map[string]interface{}{
+		"a": []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{}},
+		"b": map[string]interface{}{},
+	}
+	if !reflect.DeepEqual(v, ex) {
+		t.Fatalf("expected %v, got %v", ex, v)
+	}
+	if len(a.maps) != 2 || a.off != 5 {
+		t.Fatalf("expected 2 maps and 5 slice elements from the arena, got %d and %d", len(a.maps), a.off)
+	}
+	a.Reset()
+	if w := js.ReadAny(); !reflect.DeepEqual(w, []interface{}{true}) || len(v.(map[string]interface{})) != 0 {
+		t.Fatalf("expected the arena to be reused, got %v and %v", w, v)
+	}
+}
+
+func TestDecoder_Allocator(t *testing.T) {
+	al := NewBytesDecoder([]byte(`[]`)).Allocator()
+	if s := al.Slice(3); !(len(s) == 0 && cap(s) == 3) {
+		t.Fatalf("expected an empty slice with capacity 3, got %d and %d", len(s), cap(s))
+	}
+	if m := al.Map(3); !(m != nil && len(m) == 0) {
+		t.Fatal("expected an empty map")
+	}
+}
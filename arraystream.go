@@ -0,0 +1,121 @@
+package jsonstream
+
+import (
+	"io"
+
+	"github.com/tada/catch"
+)
+
+// An ArrayStreamWriter writes a JSON object with an array of elements that are appended one at a time, followed by a
+// trailer field with the number of elements, e.g. {"items":[...],"count":2}. It is the typical shape of an export
+// whose size isn't known when the output starts.
+//
+// The output is delivered according to the FlushEvery and FlushHook options in the same way as the output of
+// MarshalWriter, except that the number of values given to FlushEvery counts the appended elements only, and not the
+// values nested within them. An element that fails to be written leaves the output incomplete, so the first error
+// that occurs is returned by every later call.
+//
+// The exported fields are optional and must be set before the first call to Append.
+type ArrayStreamWriter struct {
+	// CountKey is the key of the trailer field that holds the number of elements. NewArrayStreamWriter sets it to
+	// "count". The trailer field is omitted when CountKey is empty.
+	CountKey string
+
+	w      io.Writer
+	ow     io.Writer
+	key    string
+	object *ObjectWriter
+	array  *ArrayWriter
+	count  int64
+	every  int64
+	closed bool
+	err    error
+}
+
+// NewArrayStreamWriter creates a new ArrayStreamWriter that writes an object with the array under the given key to the
+// given io.Writer. The given options, such as FlushEvery, are applied to the whole object. Nothing is written before
+// the first call to Append or Close.
+func NewArrayStreamWriter(w io.Writer, key string, options ...MarshalOption) *ArrayStreamWriter {
+	aw := &ArrayStreamWriter{CountKey: "count", w: w, key: key}
+
+	// the elements are counted here rather than by the flusher, which would count the nested values too
+	options = append(options[:len(options):len(options)], func(o *marshalOptions) {
+		aw.every = int64(o.flushValues)
+		o.flushValues = 0
+	})
+	aw.ow = newOptionsWriter(w, options)
+	return aw
+}
+
+// Append writes the value that is streamed by the given Streamer, or null if the Streamer is nil, as the next element
+// of the array. An error is returned if the ArrayStreamWriter has been closed.
+func (aw *ArrayStreamWriter) Append(s Streamer) error {
+	if aw.closed && aw.err == nil {
+		return catch.Error("append after close")
+	}
+	if err := aw.do(func() {
+		aw.begin()
+		aw.array.Streamer(s)
+		aw.count++
+	}); err != nil {
+		return err
+	}
+	if aw.every > 0 && aw.count%aw.every == 0 {
+		return aw.Flush()
+	}
+	return nil
+}
+
+// Close writes the end of the array, the trailer field, and the end of the object, and then flushes the writer.
+// Calling Close again has no effect.
+func (aw *ArrayStreamWriter) Close() error {
+	if aw.closed {
+		return aw.err
+	}
+	aw.closed = true
+	if err := aw.do(func() {
+		aw.begin()
+		aw.array.Close()
+		if aw.CountKey != "" {
+			aw.object.Int(aw.CountKey, aw.count)
+		}
+		aw.object.Close()
+	}); err != nil {
+		return err
+	}
+	return aw.Flush()
+}
+
+// Flush delivers the output that has been written so far in the same way as the Flush function.
+func (aw *ArrayStreamWriter) Flush() error {
+	return aw.do(func() {
+		if optionsOf(aw.ow).flusher != nil {
+			Flush(aw.ow)
+		} else {
+			Flush(aw.w)
+		}
+	})
+}
+
+// Count returns the number of elements appended so far.
+func (aw *ArrayStreamWriter) Count() int64 {
+	return aw.count
+}
+
+// do calls the given function unless an earlier call failed, and returns the error of the earlier call or of the
+// function.
+func (aw *ArrayStreamWriter) do(f func()) error {
+	if aw.err == nil {
+		aw.err = catch.Do(f)
+	}
+	return aw.err
+}
+
+// begin writes the start of the object and of the array unless that has been done already.
+func (aw *ArrayStreamWriter) begin() {
+	if aw.array == nil {
+		aw.object = NewObjectWriter(aw.ow)
+		aw.object.Field(aw.key)
+		aw.array = NewArrayWriter(aw.ow)
+	}
+}
@@ -0,0 +1,68 @@
+package jsonstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestArrayStreamWriter(t *testing.T) {
+	r := flushRecorder{}
+	aw := NewArrayStreamWriter(&r, "items", FlushEvery(0, 2))
+	for i := int64(1); i <= 3; i++ {
+		if err := aw.Append(&intList{i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ex := `{"items":[[1],[2],[3]],"count":3}`
+	if a := r.String(); a != ex {
+		t.Errorf("expected %s, got %s", ex, a)
+	}
+	if a := fmt.Sprint(r.flushes, aw.Count()); a != `[17 33] 3` {
+		t.Errorf("unexpected flushes %s", a)
+	}
+	if err := aw.Close(); err != nil || len(r.flushes) != 2 {
+		t.Errorf("expected a second Close to have no effect, got %v", err)
+	}
+	if err := aw.Append(&intList{4}); err == nil || err.Error() != "append after close" || aw.Count() != 3 {
+		t.Errorf("expected append after close error, got %v", err)
+	}
+	if a := r.String(); a != ex {
+		t.Errorf("expected %s, got %s", ex, a)
+	}
+}
+
+func TestArrayStreamWriter_empty(t *testing.T) {
+	r := flushRecorder{}
+	aw := NewArrayStreamWriter(&r, "items", Indent("", " "))
+	aw.CountKey = ""
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ex := "{\n \"items\": []\n}"; r.String() != ex {
+		t.Errorf("expected %q, got %q", ex, r.String())
+	}
+}
+
+func TestArrayStreamWriter_errors(t *testing.T) {
+	aw := NewArrayStreamWriter(&flushRecorder{}, "items")
+	err := aw.Append(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		return errors.New("failed")
+	})))
+	if err == nil || err.Error() != "failed" || aw.Count() != 0 {
+		t.Errorf("expected failed error, got %v", err)
+	}
+	if err = aw.Append(&intList{1}); err == nil || err.Error() != "failed" || aw.Count() != 0 {
+		t.Errorf("expected failed error from Append after failure, got %v", err)
+	}
+	if err = aw.Close(); err == nil || err.Error() != "failed" {
+		t.Errorf("expected failed error from Close after failure, got %v", err)
+	}
+	if err = NewArrayStreamWriter(failingWriter{}, "items").Close(); err == nil || err.Error() != "write failed" {
+		t.Errorf("expected write failed error, got %v", err)
+	}
+}
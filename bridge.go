@@ -0,0 +1,36 @@
+package jsonstream
+
+// A StreamConsumer is both a Streamer and a Consumer.
+type StreamConsumer interface {
+	Streamer
+	Consumer
+}
+
+// JSONBridge implements json.Marshaler and json.Unmarshaler by delegating to the Streamer and Consumer methods of C,
+// which saves a type from writing the MarshalJSON and UnmarshalJSON methods that call Marshal and Unmarshal. A
+// value can be passed to encoding/json wrapped in a JSONBridge, or a type can embed a JSONBridge and point C at
+// itself when it is created:
+//
+//	type Point struct {
+//		jsonstream.JSONBridge
+//		X, Y int64
+//	}
+//
+//	func NewPoint() *Point {
+//		p := &Point{}
+//		p.C = p
+//		return p
+//	}
+type JSONBridge struct {
+	C StreamConsumer
+}
+
+// MarshalJSON streams C using Marshal.
+func (b JSONBridge) MarshalJSON() ([]byte, error) {
+	return Marshal(b.C)
+}
+
+// UnmarshalJSON consumes the given bytes into C using Unmarshal.
+func (b JSONBridge) UnmarshalJSON(bs []byte) error {
+	return Unmarshal(b.C, bs)
+}
@@ -0,0 +1,51 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+type bridgedPoint struct {
+	JSONBridge
+	x, y int64
+}
+
+func newBridgedPoint() *bridgedPoint {
+	p := &bridgedPoint{}
+	p.C = p
+	return p
+}
+
+func (p *bridgedPoint) MarshalToJSON(w io.Writer) {
+	a := NewArrayWriter(w)
+	a.Int(p.x)
+	a.Int(p.y)
+	a.Close()
+}
+
+func (p *bridgedPoint) UnmarshalFromJSON(js Decoder, firstToken json.Token) {
+	AssertDelim(firstToken, '[')
+	p.x = js.ReadInt()
+	p.y = js.ReadInt()
+	js.ReadDelim(']')
+}
+
+func TestJSONBridge(t *testing.T) {
+	p := newBridgedPoint()
+	p.x, p.y = 1, 2
+	bs, err := json.Marshal(map[string]interface{}{"p": p})
+	if err != nil || string(bs) != `{"p":[1,2]}` {
+		t.Fatalf("expected {\"p\":[1,2]}, got %s, %v", bs, err)
+	}
+	q := newBridgedPoint()
+	if err = json.Unmarshal([]byte(`[3, 4]`), q); err != nil || q.x != 3 || q.y != 4 {
+		t.Fatalf("expected 3, 4, got %d, %d, %v", q.x, q.y, err)
+	}
+
+	v := &ts{}
+	if err = json.Unmarshal([]byte(`{"v":7}`), &JSONBridge{C: v}); err != nil || v.v != 7*time.Millisecond {
+		t.Fatalf("expected 7ms, got %v, %v", v.v, err)
+	}
+}
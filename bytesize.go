@@ -0,0 +1,76 @@
+package jsonstream
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A byteUnit is a unit suffix of byte sizes with its multiplier.
+type byteUnit struct {
+	suffix string
+	n      int64
+}
+
+// byteUnits returns the units of byte sizes, with the binary units before the decimal units and the larger units
+// before the smaller units of each kind.
+func byteUnits() [13]byteUnit {
+	return [...]byteUnit{
+		{"EiB", 1 << 60}, {"PiB", 1 << 50}, {"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+		{"EB", 1e18}, {"PB", 1e15}, {"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+		{"B", 1},
+	}
+}
+
+// parseByteSize parses a byte size that consists of a non-negative number, which may have a fraction, optionally
+// followed by whitespace and a unit suffix, e.g. "10MB", "1.5 GiB", or "512". The suffixes KB, MB, GB, TB, PB, and
+// EB denote powers of 1000, and KiB, MiB, GiB, TiB, PiB, and EiB denote powers of 1024. Suffixes are case-insensitive
+// and a number without a suffix is a number of bytes.
+func parseByteSize(s string) (int64, error) {
+	num := strings.TrimRight(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+	suffix := s[len(num):]
+	num = strings.TrimSpace(num)
+	mul := int64(0)
+	if suffix == "" {
+		mul = 1
+	} else {
+		for _, u := range byteUnits() {
+			if strings.EqualFold(suffix, u.suffix) {
+				mul = u.n
+				break
+			}
+		}
+	}
+	if mul != 0 && num != "" && num[0] != '-' && num[0] != '+' {
+		if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+			if i <= math.MaxInt64/mul {
+				return i * mul, nil
+			}
+		} else if f, err := strconv.ParseFloat(num, 64); err == nil && f*float64(mul) < math.MaxInt64 {
+			return int64(math.Round(f * float64(mul))), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid byte size %q", s)
+}
+
+// formatByteSize returns the given number of bytes with the largest unit suffix that it is a whole multiple of, e.g.
+// "10MiB" for 10485760 and "10MB" for 10000000, so that parseByteSize returns the same number of bytes.
+func formatByteSize(n int64) string {
+	for _, u := range byteUnits() {
+		if n != 0 && n%u.n == 0 {
+			return strconv.FormatInt(n/u.n, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
+
+// WriteByteSize writes the given number of bytes on the writer as a string with the largest unit suffix that the
+// number is a whole multiple of, e.g. "10MiB" or "1500KB". The size is read back using the ReadByteSize method of the
+// Decoder.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteByteSize(w io.Writer, n int64) {
+	WriteString(w, formatByteSize(n))
+}
@@ -0,0 +1,71 @@
+package jsonstream
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestReadByteSize(t *testing.T) {
+	input := `["10MB", "1.5 GiB", "512", "4kb", "8EiB", 2048, null, "1B"]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var a []int64
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 7; i++ {
+				a = append(a, js.ReadByteSize())
+			}
+		})
+		if err == nil || err.Error() != `invalid byte size "8EiB"` {
+			t.Errorf("expected invalid byte size error, got %v", err)
+		}
+		if s := fmt.Sprint(a); s != `[10000000 1610612736 512 4000]` {
+			t.Errorf("unexpected sizes %s", s)
+		}
+		err = catch.Do(func() {
+			a = append(a[:0], js.ReadByteSize(), js.ReadByteSize(), js.ReadByteSize())
+		})
+		if err != nil || fmt.Sprint(a) != `[2048 0 1]` {
+			t.Errorf("unexpected sizes %v, %v", a, err)
+		}
+	}
+	for _, input := range []string{`"-1MB"`, `"10XB"`, `"MB"`, `1.5`, `-1`, `true`} {
+		err := catch.Do(func() {
+			NewBytesDecoder([]byte(input)).ReadByteSize()
+		})
+		if err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func TestWriteByteSize(t *testing.T) {
+	sizes := []int64{0, 1536, 10 << 20, 10e6, 1500e3, 1 << 62}
+	bs, err := Marshal(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		a := NewArrayWriter(w)
+		for _, n := range sizes {
+			a.Element()
+			WriteByteSize(w, n)
+		}
+		a.Close()
+		return nil
+	})))
+	ex := `["0B","1536B","10MiB","10MB","1500KB","4EiB"]`
+	if err != nil || string(bs) != ex {
+		t.Fatalf("expected %s, got %s, %v", ex, bs, err)
+	}
+	js := NewBytesDecoder(bs)
+	err = catch.Do(func() {
+		js.ReadDelim('[')
+		for _, n := range sizes {
+			if a := js.ReadByteSize(); a != n {
+				t.Errorf("expected %d, got %d", n, a)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
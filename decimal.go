@@ -0,0 +1,41 @@
+package jsonstream
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tada/catch"
+	"github.com/tada/catch/pio"
+)
+
+// A Decimal is an arbitrary-precision decimal number, such as a monetary amount, that is read using the ReadDecimal
+// method of the Decoder and written using the WriteDecimal function. The digits of the JSON number are passed as is, so
+// nothing is lost to a float64 on the way. Types of libraries like shopspring/decimal or cockroachdb/apd typically
+// need a small adapter, e.g.
+//
+//	type Money struct{ decimal.Decimal }
+//
+//	func (m *Money) SetString(s string) (err error) {
+//		m.Decimal, err = decimal.NewFromString(s)
+//		return err
+//	}
+type Decimal interface {
+	// SetString assigns the number in the given string, which is in the format of a JSON number, to the receiver.
+	SetString(s string) error
+
+	// String returns the number in a format that is a valid JSON number.
+	String() string
+}
+
+// WriteDecimal writes the string returned by the String method of the given value as a number on the writer. The
+// number is read back using the ReadDecimal method of the Decoder.
+//
+// If an error occurs or if the string isn't a valid JSON number, the method panics with a catch.Error with the Cause
+// set to that error
+func WriteDecimal(w io.Writer, v Decimal) {
+	s := v.String()
+	if !isNumber(s) {
+		panic(catch.Error(fmt.Errorf("decimal %q is not a valid JSON number", s)))
+	}
+	pio.WriteString(w, s)
+}
@@ -0,0 +1,87 @@
+package jsonstream
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+// amount is a Decimal with two fraction digits.
+type amount struct {
+	big.Rat
+}
+
+func (a *amount) SetString(s string) error {
+	if _, ok := a.Rat.SetString(s); !ok {
+		return fmt.Errorf("invalid amount %q", s)
+	}
+	return nil
+}
+
+func (a *amount) String() string {
+	return a.FloatString(2)
+}
+
+// rawDecimal is a Decimal that keeps the string as is.
+type rawDecimal string
+
+func (r *rawDecimal) SetString(s string) error {
+	*r = rawDecimal(s)
+	return nil
+}
+
+func (r rawDecimal) String() string {
+	return string(r)
+}
+
+func TestReadDecimal(t *testing.T) {
+	input := `[12345678901234567.89, "0.10", null, true, "x"]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var as [3]amount
+		as[2].SetInt64(7)
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := range as {
+				js.ReadDecimal(&as[i])
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprintf("%s %s %s", &as[0], &as[1], &as[2]); a != `12345678901234567.89 0.10 7.00` {
+			t.Fatalf("unexpected amounts %s", a)
+		}
+		err = catch.Do(func() {
+			js.ReadDecimal(&as[0])
+		})
+		if err == nil || err.Error() != `expected a decimal number, got bool true` {
+			t.Fatalf("expected type error, got %v", err)
+		}
+		err = catch.Do(func() {
+			js.ReadDecimal(&as[0])
+		})
+		if err == nil || err.Error() != `invalid amount "x"` {
+			t.Fatalf("expected SetString error, got %v", err)
+		}
+	}
+}
+
+func TestWriteDecimal(t *testing.T) {
+	a := &amount{}
+	a.SetFrac64(-1234, 100)
+	b := bytes.Buffer{}
+	WriteDecimal(&b, a)
+	if s := b.String(); s != `-12.34` {
+		t.Fatalf("unexpected output %s", s)
+	}
+	err := catch.Do(func() {
+		r := rawDecimal("1/3")
+		WriteDecimal(&b, &r)
+	})
+	if err == nil || err.Error() != `decimal "1/3" is not a valid JSON number` {
+		t.Fatalf("expected invalid number error, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package jsonstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"math"
+
+	"github.com/tada/catch"
+)
+
+// A DedupOption configures DedupBy.
+type DedupOption func(*deduper)
+
+// DedupBloom makes DedupBy remember the keys that it has seen in a bloom filter that is sized for n keys with the
+// given false positive rate p, instead of keeping all keys in memory. The memory used is then bounded regardless of
+// the number of elements, at the cost of dropping a unique element with the probability p.
+func DedupBloom(n int, p float64) DedupOption {
+	return func(d *deduper) {
+		m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+		k := int(math.Round(m / float64(n) * math.Ln2))
+		if k < 1 {
+			k = 1
+		}
+		d.bloom = &bloomFilter{bits: make([]uint64, (int(m)+63)/64), k: k}
+	}
+}
+
+type deduper struct {
+	key   string
+	seen  map[string]bool
+	bloom *bloomFilter
+}
+
+// add returns true if the given key hasn't been added before.
+func (d *deduper) add(key []byte) bool {
+	if d.bloom != nil {
+		return d.bloom.add(key)
+	}
+	if d.seen[string(key)] {
+		return false
+	}
+	d.seen[string(key)] = true
+	return true
+}
+
+// bloomFilter is a bloom filter with k hash functions that are derived from the two halves of a 64-bit FNV-1a hash.
+type bloomFilter struct {
+	bits []uint64
+	k    int
+}
+
+// add adds the given key and returns true if the key wasn't found before it was added.
+func (b *bloomFilter) add(key []byte) bool {
+	h := fnv.New64a()
+	_, _ = h.Write(key)
+	sum := h.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)
+	m := uint32(len(b.bits) * 64)
+	added := false
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint32(i)*h2) % m
+		w, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[w]&mask == 0 {
+			b.bits[w] |= mask
+			added = true
+		}
+	}
+	return added
+}
+
+// DedupBy copies the JSON values from src to dst, with insignificant whitespace removed, and drops the elements of
+// each array found at the given JSON Pointer whose value at the JSON Pointer key, relative to the element, e.g. "/id",
+// has been seen before. An empty pointer denotes a top level array. Keys are compared by their compact JSON, and the
+// keys seen are shared between all arrays, so overlapping export shards that are concatenated in src are merged into
+// one set of elements. Elements that don't have the key are always kept.
+//
+// The input is processed token by token, and only one element at a time is held in memory, but all distinct keys are
+// kept unless the DedupBloom option is given.
+//
+// The function will recover a catch.Error panic and return its cause.
+func DedupBy(dst io.Writer, src io.Reader, pointer, key string, options ...DedupOption) error {
+	d := &deduper{key: key}
+	for _, option := range options {
+		option(d)
+	}
+	if d.bloom == nil {
+		d.seen = make(map[string]bool)
+	}
+	bw := bufio.NewWriter(dst)
+	return catch.Do(func() {
+		c := newTokenCopier(bw, bw, src)
+		target := -1
+		for {
+			isKey := c.d.atKey
+			depth := len(c.d.stack)
+			t, ok := c.next()
+			if !ok {
+				break
+			}
+			if depth == target {
+				if isDelim(t, ']') {
+					target = -1
+				} else {
+					raw := compactRest(c, t, depth)
+					if d.keep(raw) {
+						c.writeRaw(raw)
+					}
+					continue
+				}
+			}
+			c.write(t, isKey)
+			if target < 0 && isDelim(t, '[') && c.d.CurrentPath() == pointer {
+				target = depth + 1
+			}
+		}
+		c.flush()
+	})
+}
+
+// compactRest returns the compact JSON of the value whose first token, which was read at the given depth, is t.
+func compactRest(c *tokenCopier, t json.Token, depth int) []byte {
+	raw := appendToken(nil, 0, t, false)
+	for len(c.d.stack) > depth {
+		isKey := c.d.atKey
+		t, _ = c.next()
+		raw = appendToken(raw, raw[len(raw)-1], t, isKey)
+	}
+	return raw
+}
+
+// keep returns true if the given element doesn't have the key or if its key hasn't been seen before.
+func (d *deduper) keep(raw []byte) bool {
+	var v Value
+	if err := Extract(NewBytesDecoder(raw), map[string]Consumer{d.key: &v}); err != nil {
+		panic(catch.Error(err))
+	}
+	return !v.Exists() || d.add(v.Raw())
+}
@@ -0,0 +1,58 @@
+package jsonstream
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDedupBy(t *testing.T) {
+	input := `{"items": [{"id": 1, "v": "a"}, {"v": "b", "id": 2}, {"id": 1, "v": "c"}, {"v": "d"}, 3,` +
+		` {"id": {"x": [1]}}, {"id": {"x": [ 1 ]}}], "n": 1}` + "\n" + `{"items": [{"id": 2}, {"id": 3}]}`
+	ex := `{"items":[{"id":1,"v":"a"},{"v":"b","id":2},{"v":"d"},3,{"id":{"x":[1]}}],"n":1}` + "\n" +
+		`{"items":[{"id":3}]}`
+	for _, options := range [][]DedupOption{nil, {DedupBloom(100, 0.001)}} {
+		b := bytes.Buffer{}
+		if err := DedupBy(&b, strings.NewReader(input), "/items", "/id", options...); err != nil {
+			t.Fatal(err)
+		}
+		if a := b.String(); a != ex {
+			t.Errorf("expected %s, got %s", ex, a)
+		}
+	}
+	if err := DedupBy(&bytes.Buffer{}, strings.NewReader(`[{"id": 1}, {"id": }]`), "", "/id"); err == nil {
+		t.Error("expected syntax error")
+	}
+}
+
+func TestDedupBy_controlCharacters(t *testing.T) {
+	input := `[{"id": "a\nb", "v": "x\u0001"}, {"id": "a\nb"}, {"id": "a\tb"}]`
+	b := bytes.Buffer{}
+	if err := DedupBy(&b, strings.NewReader(input), "", "/id"); err != nil {
+		t.Fatal(err)
+	}
+	if ex := `[{"id":"a\nb","v":"x\u0001"},{"id":"a\tb"}]`; b.String() != ex {
+		t.Fatalf("expected %s, got %q", ex, b.String())
+	}
+}
+
+func TestDedupBloom(t *testing.T) {
+	d := &deduper{}
+	DedupBloom(1000, 0.01)(d)
+	if len(d.bloom.bits) != 150 || d.bloom.k != 7 {
+		t.Errorf("unexpected size %d bits with %d hashes", len(d.bloom.bits)*64, d.bloom.k)
+	}
+	dropped := 0
+	for i := 0; i < 1000; i++ {
+		if !d.add([]byte(fmt.Sprint(i))) {
+			dropped++
+		}
+	}
+	if dropped > 30 {
+		t.Errorf("too many false positives: %d", dropped)
+	}
+	if d.add([]byte("7")) {
+		t.Error("expected key to be seen")
+	}
+}
@@ -0,0 +1,230 @@
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/tada/catch"
+)
+
+// A DiffKind tells how a value differs between the two documents compared by Diff.
+type DiffKind int
+
+const (
+	// DiffChanged means that the value is present in both documents but differs.
+	DiffChanged DiffKind = iota
+
+	// DiffAdded means that the value is only present in the second document.
+	DiffAdded
+
+	// DiffRemoved means that the value is only present in the first document.
+	DiffRemoved
+)
+
+// String returns "changed", "added", or "removed".
+func (k DiffKind) String() string {
+	switch k {
+	case DiffChanged:
+		return "changed"
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	}
+	return "DiffKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A DiffOption configures Diff.
+type DiffOption func(*differ)
+
+// DiffSortedKeys makes Diff compare the fields of objects by key regardless of their order. Each pair of objects
+// that is compared is then read into memory. Without this option, the fields are compared in the order they appear
+// and a key that differs is reported as the removal of one field and the addition of another.
+func DiffSortedKeys() DiffOption {
+	return func(d *differ) {
+		d.sortedKeys = true
+	}
+}
+
+type differ struct {
+	report     func(path string, kind DiffKind, av, bv []byte)
+	sortedKeys bool
+	ra         []byte
+	rb         []byte
+}
+
+// Diff reads one JSON value from each of the given readers and calls report for each difference between them. The
+// path is a JSON Pointer to the value that differs. The raw JSON of the values is passed in av and bv, either of
+// which is nil when the value is only present in one document. The raw bytes are only valid during the call. Arrays
+// and objects are compared element by element as they are read, so neither document is loaded into memory unless
+// the DiffSortedKeys option is used.
+//
+// Strings are compared by their unquoted value and all other scalars by their literal JSON, so 1 and 1.0 differ.
+//
+// The function will recover a catch.Error panic and return its cause.
+func Diff(a, b io.Reader, report func(path string, kind DiffKind, av, bv []byte), options ...DiffOption) error {
+	d := &differ{report: report}
+	for _, option := range options {
+		option(d)
+	}
+	return catch.Do(func() {
+		d.diff("", NewDecoder(a), NewDecoder(b))
+	})
+}
+
+// diff compares the next value of da with the next value of db.
+func (d *differ) diff(path string, da, db Decoder) {
+	ta, tb := peekToken(da), peekToken(db)
+	switch {
+	case isDelim(ta, '[') && isDelim(tb, '['):
+		d.diffArrays(path, da, db)
+	case isDelim(ta, '{') && isDelim(tb, '{'):
+		if d.sortedKeys {
+			d.diffSortedObjects(path, da, db)
+		} else {
+			d.diffObjects(path, da, db)
+		}
+	default:
+		d.ra = da.ReadRawAppend(d.ra[:0])
+		d.rb = db.ReadRawAppend(d.rb[:0])
+		if !equalScalars(d.ra, d.rb) {
+			d.report(path, DiffChanged, d.ra, d.rb)
+		}
+	}
+}
+
+func (d *differ) diffArrays(path string, da, db Decoder) {
+	da.ReadDelim('[')
+	db.ReadDelim('[')
+	for i := 0; ; i++ {
+		endA, endB := isDelim(peekToken(da), ']'), isDelim(peekToken(db), ']')
+		switch {
+		case endA && endB:
+			da.ReadDelim(']')
+			db.ReadDelim(']')
+			return
+		case endA:
+			d.rb = db.ReadRawAppend(d.rb[:0])
+			d.report(path+"/"+strconv.Itoa(i), DiffAdded, nil, d.rb)
+		case endB:
+			d.ra = da.ReadRawAppend(d.ra[:0])
+			d.report(path+"/"+strconv.Itoa(i), DiffRemoved, d.ra, nil)
+		default:
+			d.diff(path+"/"+strconv.Itoa(i), da, db)
+		}
+	}
+}
+
+func (d *differ) diffObjects(path string, da, db Decoder) {
+	da.ReadDelim('{')
+	db.ReadDelim('{')
+	for {
+		ka, okA := da.ReadStringOrEnd('}')
+		kb, okB := db.ReadStringOrEnd('}')
+		switch {
+		case !okA && !okB:
+			return
+		case okA && okB && ka == kb:
+			d.diff(path+"/"+escapePointerKey(ka), da, db)
+			continue
+		}
+		if okA {
+			d.ra = da.ReadRawAppend(d.ra[:0])
+			d.report(path+"/"+escapePointerKey(ka), DiffRemoved, d.ra, nil)
+		}
+		if okB {
+			d.rb = db.ReadRawAppend(d.rb[:0])
+			d.report(path+"/"+escapePointerKey(kb), DiffAdded, nil, d.rb)
+		}
+		if !okA {
+			d.reportRemaining(path, db, DiffAdded)
+			return
+		}
+		if !okB {
+			d.reportRemaining(path, da, DiffRemoved)
+			return
+		}
+	}
+}
+
+// reportRemaining reports the remaining fields of the object that js is reading as added or removed.
+func (d *differ) reportRemaining(path string, js Decoder, kind DiffKind) {
+	for {
+		k, ok := js.ReadStringOrEnd('}')
+		if !ok {
+			return
+		}
+		raw := js.ReadRawAppend(nil)
+		if kind == DiffAdded {
+			d.report(path+"/"+escapePointerKey(k), kind, nil, raw)
+		} else {
+			d.report(path+"/"+escapePointerKey(k), kind, raw, nil)
+		}
+	}
+}
+
+func (d *differ) diffSortedObjects(path string, da, db Decoder) {
+	fa, fb := readFields(da), readFields(db)
+	keys := make([]string, 0, len(fa)+len(fb))
+	for k := range fa {
+		keys = append(keys, k)
+	}
+	for k := range fb {
+		if _, ok := fa[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p := path + "/" + escapePointerKey(k)
+		av, okA := fa[k]
+		bv, okB := fb[k]
+		switch {
+		case !okA:
+			d.report(p, DiffAdded, nil, bv)
+		case !okB:
+			d.report(p, DiffRemoved, av, nil)
+		default:
+			d.diff(p, NewBytesDecoder(av), NewBytesDecoder(bv))
+		}
+	}
+}
+
+// readFields reads an object and returns the raw values of its fields.
+func readFields(js Decoder) map[string][]byte {
+	js.ReadDelim('{')
+	fields := make(map[string][]byte)
+	for {
+		k, ok := js.ReadStringOrEnd('}')
+		if !ok {
+			return fields
+		}
+		fields[k] = js.ReadRawAppend(nil)
+	}
+}
+
+// peekToken returns the next token of the decoder without consuming it, or nil at the end of the input.
+func peekToken(js Decoder) json.Token {
+	if ts := js.PeekN(1); len(ts) > 0 {
+		return ts[0]
+	}
+	return nil
+}
+
+// equalScalars returns true if the given raw JSON values are equal. Strings are compared by their unquoted value.
+func equalScalars(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	if len(a) == 0 || len(b) == 0 || a[0] != '"' || b[0] != '"' {
+		return false
+	}
+	var sa, sb string
+	return catch.Do(func() {
+		sa = NewBytesDecoder(a).ReadString()
+		sb = NewBytesDecoder(b).ReadString()
+	}) == nil && sa == sb
+}
@@ -0,0 +1,78 @@
+package jsonstream
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func diffReport(t *testing.T, a, b string, options ...DiffOption) []string {
+	t.Helper()
+	var diffs []string
+	err := Diff(strings.NewReader(a), strings.NewReader(b), func(path string, kind DiffKind, av, bv []byte) {
+		diffs = append(diffs, fmt.Sprintf("%s %s %s %s", kind, path, av, bv))
+	}, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return diffs
+}
+
+func TestDiff(t *testing.T) {
+	a := `{"a":[1, 2, 3], "b":{"c":"x", "d":true}, "e/f":"A", "g":null, "h":1}`
+	b := `{"a":[1, 5], "b":{"c":"y", "d":true, "i":[]}, "e/f":"A", "g":{}, "j":2}`
+	ex := `["changed /a/1 2 5" "removed /a/2 3 " "changed /b/c \"x\" \"y\"" "added /b/i  []" "changed /g null {}"` +
+		` "removed /h 1 " "added /j  2"]`
+	if a := fmt.Sprintf("%q", diffReport(t, a, b)); a != ex {
+		t.Fatalf("expected %s, got %s", ex, a)
+	}
+	if diffs := diffReport(t, a, a); len(diffs) != 0 {
+		t.Fatalf("expected no differences, got %q", diffs)
+	}
+}
+
+func TestDiff_sortedKeys(t *testing.T) {
+	a := `{"a":1, "b":{"x":[1], "y":2}, "c":3}`
+	b := `{"c":3, "b":{"y":2, "x":[2]}, "d":4}`
+	ex := `["removed /a 1 " "added /c  3" "removed /b/x [1] " "added /b/y  2" "removed /b/y 2 " "added /b/x  [2]"` +
+		` "removed /c 3 " "added /d  4"]`
+	if a := fmt.Sprintf("%q", diffReport(t, a, b)); a != ex {
+		t.Fatalf("expected %s, got %s", ex, a)
+	}
+	ex = `["removed /a 1 " "changed /b/x/0 1 2" "added /d  4"]`
+	if a := fmt.Sprintf("%q", diffReport(t, a, b, DiffSortedKeys())); a != ex {
+		t.Fatalf("expected %s, got %s", ex, a)
+	}
+}
+
+func TestDiff_controlCharacters(t *testing.T) {
+	a := `{"a":["x\ny"], "b":{"c":"\u0001"}}`
+	b := `{"a":["x\nz"], "b":{"c":"\u0002", "d":"\t"}}`
+	for _, options := range [][]DiffOption{nil, {DiffSortedKeys()}} {
+		var values [][]byte
+		err := Diff(strings.NewReader(a), strings.NewReader(b), func(path string, kind DiffKind, av, bv []byte) {
+			for _, v := range [][]byte{av, bv} {
+				if v != nil {
+					values = append(values, append([]byte(nil), v...))
+				}
+			}
+		}, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex := `["\"x\\ny\"" "\"x\\nz\"" "\"\\u0001\"" "\"\\u0002\"" "\"\\t\""]`
+		if a := fmt.Sprintf("%q", values); a != ex {
+			t.Fatalf("expected %s, got %s", ex, a)
+		}
+	}
+}
+
+func TestDiff_error(t *testing.T) {
+	err := Diff(strings.NewReader(`[1,`), strings.NewReader(`[1, 2]`), func(string, DiffKind, []byte, []byte) {})
+	if err == nil {
+		t.Fatal("expected an error for truncated input")
+	}
+	if s := DiffAdded.String() + " " + DiffKind(7).String(); s != "added DiffKind(7)" {
+		t.Fatalf("unexpected kind strings %s", s)
+	}
+}
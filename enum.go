@@ -0,0 +1,61 @@
+package jsonstream
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tada/catch"
+)
+
+// An EnumCodec maps the strings that represent the values of an enumeration in JSON to integer codes and back. It
+// is used with the ReadEnumCode method of the Decoder and the WriteEnumCode function so that the values are kept as
+// compact codes in memory while the JSON remains readable. An EnumCodec is safe for concurrent use once all values
+// have been registered.
+type EnumCodec struct {
+	codes map[string]int64
+	names map[int64]string
+}
+
+// NewEnumCodec creates a new EnumCodec without any values.
+func NewEnumCodec() *EnumCodec {
+	return &EnumCodec{codes: make(map[string]int64), names: make(map[int64]string)}
+}
+
+// Register adds a value with the given name and code to the codec and returns the codec. A panic is raised if the
+// name or the code is already registered.
+func (c *EnumCodec) Register(name string, code int64) *EnumCodec {
+	if _, ok := c.codes[name]; ok {
+		panic(fmt.Sprintf("enum name %q is already registered", name))
+	}
+	if _, ok := c.names[code]; ok {
+		panic(fmt.Sprintf("enum code %d is already registered", code))
+	}
+	c.codes[name] = code
+	c.names[code] = name
+	return c
+}
+
+// Code returns the code of the value with the given name and true, or 0 and false if no such value is registered.
+func (c *EnumCodec) Code(name string) (int64, bool) {
+	code, ok := c.codes[name]
+	return code, ok
+}
+
+// Name returns the name of the value with the given code and true, or an empty string and false if no such value is
+// registered.
+func (c *EnumCodec) Name(code int64) (string, bool) {
+	name, ok := c.names[code]
+	return name, ok
+}
+
+// WriteEnumCode writes the name that the given codec registered for the given code as a string on the writer.
+//
+// If an error occurs or if the code isn't registered, the method panics with a catch.Error with the Cause set to that
+// error
+func WriteEnumCode(w io.Writer, c *EnumCodec, code int64) {
+	name, ok := c.names[code]
+	if !ok {
+		panic(catch.Error(fmt.Errorf("enum code %d is not registered", code)))
+	}
+	WriteString(w, name)
+}
@@ -0,0 +1,99 @@
+package jsonstream
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestEnumCodec(t *testing.T) {
+	c := NewEnumCodec().Register("pending", 1).Register("active", 2)
+	input := `["active", null, "pending", "gone"]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var codes []int64
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 3; i++ {
+				codes = append(codes, js.ReadEnumCode(c))
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(codes); a != `[2 0 1]` {
+			t.Fatalf("unexpected codes %s", a)
+		}
+		err = catch.Do(func() {
+			js.ReadEnumCode(c)
+		})
+		if err == nil || err.Error() != `enum name "gone" is not registered` {
+			t.Fatalf("expected unregistered name error, got %v", err)
+		}
+	}
+
+	b := bytes.Buffer{}
+	WriteEnumCode(&b, c, 2)
+	if a := b.String(); a != `"active"` {
+		t.Fatalf("unexpected output %s", a)
+	}
+	err := catch.Do(func() {
+		WriteEnumCode(&b, c, 3)
+	})
+	if err == nil || err.Error() != `enum code 3 is not registered` {
+		t.Fatalf("expected unregistered code error, got %v", err)
+	}
+}
+
+func TestEnumCodec_Register(t *testing.T) {
+	c := NewEnumCodec().Register("a", 1)
+	if code, ok := c.Code("a"); !(ok && code == 1) {
+		t.Errorf("expected code 1, got %d", code)
+	}
+	if name, ok := c.Name(1); !(ok && name == "a") {
+		t.Errorf("expected name a, got %q", name)
+	}
+	for _, f := range []func(){func() { c.Register("a", 2) }, func() { c.Register("b", 1) }} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic on duplicate registration")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+type testStatus string
+
+const (
+	statusActive   testStatus = "active"
+	statusDisabled testStatus = "disabled"
+)
+
+func TestReadStringEnum(t *testing.T) {
+	input := `["disabled", null, "gone"]`
+	allowed := []string{string(statusActive), string(statusDisabled)}
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var a, b testStatus
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			a = testStatus(js.ReadStringEnum(allowed...))
+			b = testStatus(js.ReadStringEnum(allowed...))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a != statusDisabled || b != "" || !js.WasNull() {
+			t.Fatalf("unexpected values %q, %q", a, b)
+		}
+		err = catch.Do(func() {
+			js.ReadStringEnum(allowed...)
+		})
+		if err == nil || err.Error() != `"gone" is not one of the allowed values ["active" "disabled"]` {
+			t.Fatalf("expected not allowed error, got %v", err)
+		}
+	}
+}
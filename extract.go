@@ -0,0 +1,98 @@
+package jsonstream
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tada/catch"
+)
+
+// Extract reads the next value from the decoder and passes each subtree whose JSON Pointer is a key of the given
+// targets to the Consumer of that key, e.g. {"/user/name": c1, "/items/0": c2}. The value is read once and all
+// subtrees that neither are targets nor contain targets are skipped, so several values can be pulled out of a large
+// document at the cost of one pass. Targets that aren't found are ignored. The empty pointer denotes the whole value.
+//
+// The function will recover a catch.Error panic and return its cause.
+func Extract(d Decoder, targets map[string]Consumer) error {
+	return catch.Do(func() {
+		extractValue(d, "", targets)
+	})
+}
+
+func extractValue(js Decoder, path string, targets map[string]Consumer) {
+	if c, ok := targets[path]; ok {
+		js.ReadConsumer(c)
+		return
+	}
+	if !containsTarget(path, targets) {
+		js.Skip()
+		return
+	}
+	t := peekToken(js)
+	switch {
+	case isDelim(t, '['):
+		js.ReadDelim('[')
+		for i := 0; !isDelim(peekToken(js), ']'); i++ {
+			extractValue(js, path+"/"+strconv.Itoa(i), targets)
+		}
+		js.ReadDelim(']')
+	case isDelim(t, '{'):
+		js.ReadDelim('{')
+		for {
+			key, ok := js.ReadStringOrEnd('}')
+			if !ok {
+				break
+			}
+			extractValue(js, path+"/"+escapePointerKey(key), targets)
+		}
+	default:
+		js.Skip()
+	}
+}
+
+// containsTarget returns true if the value at the given path contains one of the targets.
+func containsTarget(path string, targets map[string]Consumer) bool {
+	prefix := path + "/"
+	for target := range targets {
+		if strings.HasPrefix(target, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindKey reads the next value from the decoder up to the value of the member that is found by following the given
+// keys through nested objects, e.g. FindKey(d, "response", "data", "items"), and returns true. The value is then the
+// next value of the decoder, so it can be read by ReadConsumer or any other method, after which the rest of each
+// enclosing object can be skipped with SkipRemaining if more values follow. When a key isn't found, or a value on the
+// way isn't an object, the whole value is consumed and false is returned. A panic with a catch.Error is raised if an
+// error occurred.
+func FindKey(d Decoder, path ...string) bool {
+	for i, key := range path {
+		if !isDelim(peekToken(d), '{') {
+			d.Skip()
+			skipEnclosing(d, i)
+			return false
+		}
+		d.ReadDelim('{')
+		for {
+			k, ok := d.ReadStringOrEnd('}')
+			if !ok {
+				skipEnclosing(d, i)
+				return false
+			}
+			if k == key {
+				break
+			}
+			d.Skip()
+		}
+	}
+	return true
+}
+
+// skipEnclosing skips the remaining members of the given number of enclosing objects.
+func skipEnclosing(d Decoder, n int) {
+	for ; n > 0; n-- {
+		d.SkipRemaining()
+	}
+}
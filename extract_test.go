@@ -0,0 +1,66 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestExtract(t *testing.T) {
+	input := `{"user":{"name":"x","id":7},"items":[[1,2],[3]],"a/b":[4],"skip":{"deep":[{}]},"last":true}`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var name json.Token
+		first, slash, missing := intList{}, intList{}, intList{}
+		err := Extract(js, map[string]Consumer{
+			"/user/name": consumerFunc(func(_ Decoder, t json.Token) { name = t }),
+			"/items/0":   &first,
+			"/a~1b":      &slash,
+			"/none/0":    &missing,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprintf("%v %v %v %v", name, first, slash, missing); a != `x [1 2] [4] []` {
+			t.Fatalf("unexpected result %s", a)
+		}
+		if len(js.PeekN(1)) != 0 {
+			t.Fatal("expected the whole value to be read")
+		}
+	}
+
+	var all intList
+	if err := Extract(NewBytesDecoder([]byte(`[1,2]`)), map[string]Consumer{"": &all}); err != nil || len(all) != 2 {
+		t.Fatalf("expected the whole value, got %v, %v", all, err)
+	}
+	if err := Extract(NewBytesDecoder([]byte(`{"a":[1,}`)), map[string]Consumer{"/a/0": &all}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestFindKey(t *testing.T) {
+	input := `{"status":"ok","response":{"meta":{"n":[1]},"data":{"items":[1,2,3],"more":false}},"tail":1}` +
+		` {"response":[]} {"response":{"data":{}}} 42`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var items intList
+		var found []bool
+		err := catch.Do(func() {
+			found = append(found, FindKey(js, "response", "data", "items"))
+			js.ReadConsumer(&items)
+			skipEnclosing(js, 3)
+			for i := 0; i < 3; i++ {
+				found = append(found, FindKey(js, "response", "data", "items"))
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(found, items); a != `[true false false false] [1 2 3]` {
+			t.Errorf("unexpected result %s", a)
+		}
+		if len(js.PeekN(1)) != 0 {
+			t.Error("expected all input to be consumed")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package jsonstream
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tada/catch"
+	"github.com/tada/catch/pio"
+)
+
+// A FlagCodec maps the names of flags to the bits of a bitmask and back. It is used with the ReadFlags method of the
+// Decoder and the WriteFlags function so that a set of flags is kept as a bitmask in memory while the JSON is an array
+// of readable names, e.g. ["read","write"] for 0b011. A FlagCodec is safe for concurrent use once all flags have been
+// registered.
+type FlagCodec struct {
+	bits  map[string]uint64
+	names []string
+	masks []uint64
+}
+
+// NewFlagCodec creates a new FlagCodec without any flags.
+func NewFlagCodec() *FlagCodec {
+	return &FlagCodec{bits: make(map[string]uint64)}
+}
+
+// Register adds a flag with the given name and bit to the codec and returns the codec. The bit is normally a single
+// bit, but a mask of several bits is allowed for a name that stands for a combination. A panic is raised if the name
+// or the bit is already registered or if the bit is zero.
+func (c *FlagCodec) Register(name string, bit uint64) *FlagCodec {
+	if bit == 0 {
+		panic(fmt.Sprintf("flag %q has no bits", name))
+	}
+	if _, ok := c.bits[name]; ok {
+		panic(fmt.Sprintf("flag name %q is already registered", name))
+	}
+	for _, m := range c.masks {
+		if m == bit {
+			panic(fmt.Sprintf("flag bit %#x is already registered", bit))
+		}
+	}
+	c.bits[name] = bit
+	c.names = append(c.names, name)
+	c.masks = append(c.masks, bit)
+	return c
+}
+
+// Bit returns the bit of the flag with the given name and true, or 0 and false if no such flag is registered.
+func (c *FlagCodec) Bit(name string) (uint64, bool) {
+	bit, ok := c.bits[name]
+	return bit, ok
+}
+
+// Names returns the names of the flags that are set in the given bitmask in the order that they were registered. A
+// flag is included when all of its bits are set. The second return value is the bits that aren't covered by any
+// registered flag.
+func (c *FlagCodec) Names(flags uint64) ([]string, uint64) {
+	var names []string
+	rest := flags
+	for i, m := range c.masks {
+		if flags&m == m {
+			names = append(names, c.names[i])
+			rest &^= m
+		}
+	}
+	return names, rest
+}
+
+// WriteFlags writes the names of the flags that are set in the given bitmask as an array of strings on the writer. The
+// names are written in the order that they were registered with the given codec.
+//
+// If an error occurs or if the bitmask has bits that aren't covered by a registered flag, the method panics with a
+// catch.Error with the Cause set to that error
+func WriteFlags(w io.Writer, c *FlagCodec, flags uint64) {
+	names, rest := c.Names(flags)
+	if rest != 0 {
+		panic(catch.Error(fmt.Errorf("flag bits %#x are not registered", rest)))
+	}
+	pio.WriteByte(w, '[')
+	for i, name := range names {
+		if i > 0 {
+			pio.WriteByte(w, ',')
+		}
+		WriteString(w, name)
+	}
+	pio.WriteByte(w, ']')
+}
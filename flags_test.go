@@ -0,0 +1,71 @@
+package jsonstream
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestFlagCodec(t *testing.T) {
+	c := NewFlagCodec().Register("read", 1).Register("write", 2).Register("exec", 4)
+	input := `[["read", "write"], null, [], ["exec", "read"], ["delete"]]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var flags []uint64
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 4; i++ {
+				flags = append(flags, js.ReadFlags(c))
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(len(flags) == 4 && flags[0] == 3 && flags[1] == 0 && flags[2] == 0 && flags[3] == 5) {
+			t.Fatalf("unexpected flags %v", flags)
+		}
+		err = catch.Do(func() {
+			js.ReadFlags(c)
+		})
+		if err == nil || err.Error() != `flag name "delete" is not registered` {
+			t.Fatalf("expected unregistered name error, got %v", err)
+		}
+	}
+
+	b := bytes.Buffer{}
+	WriteFlags(&b, c, 5)
+	WriteFlags(&b, c, 0)
+	if a := b.String(); a != `["read","exec"][]` {
+		t.Fatalf("unexpected output %s", a)
+	}
+	err := catch.Do(func() {
+		WriteFlags(&b, c, 9)
+	})
+	if err == nil || err.Error() != `flag bits 0x8 are not registered` {
+		t.Fatalf("expected unregistered bits error, got %v", err)
+	}
+}
+
+func TestFlagCodec_Register(t *testing.T) {
+	c := NewFlagCodec().Register("read", 1).Register("write", 2).Register("rw", 3)
+	if bit, ok := c.Bit("rw"); !(ok && bit == 3) {
+		t.Errorf("expected bit 3, got %d", bit)
+	}
+	if names, rest := c.Names(7); !(len(names) == 3 && names[2] == "rw" && rest == 4) {
+		t.Errorf("unexpected names %v and rest %d", names, rest)
+	}
+	for _, f := range []func(){
+		func() { c.Register("read", 4) },
+		func() { c.Register("other", 2) },
+		func() { c.Register("none", 0) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic on invalid registration")
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,89 @@
+package jsonstream
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tada/catch"
+)
+
+// Flatten reads the JSON values from the given reader and calls f with the path and the raw JSON of each scalar that
+// they contain, in the order they appear. Empty arrays and objects are passed as [] and {}. The path is written in
+// JSONPath notation, e.g. $.a.b[0], where keys that aren't identifiers are written in brackets, e.g. $['a b']. The
+// raw bytes are only valid during the call. Values are read as they are flattened, so the input can be of any size.
+//
+// The function will recover a catch.Error panic and return its cause.
+func Flatten(r io.Reader, f func(path string, raw []byte)) error {
+	return catch.Do(func() {
+		js := NewDecoder(r)
+		fl := flattener{f: f}
+		for len(js.PeekN(1)) > 0 {
+			fl.value(js, "$")
+		}
+	})
+}
+
+type flattener struct {
+	f   func(path string, raw []byte)
+	raw []byte
+}
+
+func (fl *flattener) value(js Decoder, path string) {
+	t := peekToken(js)
+	switch {
+	case isDelim(t, '['):
+		js.ReadDelim('[')
+		i := 0
+		for ; !isDelim(peekToken(js), ']'); i++ {
+			fl.value(js, path+"["+strconv.Itoa(i)+"]")
+		}
+		js.ReadDelim(']')
+		if i == 0 {
+			fl.f(path, []byte("[]"))
+		}
+	case isDelim(t, '{'):
+		js.ReadDelim('{')
+		empty := true
+		for {
+			key, ok := js.ReadStringOrEnd('}')
+			if !ok {
+				break
+			}
+			empty = false
+			fl.value(js, path+jsonPathKey(key))
+		}
+		if empty {
+			fl.f(path, []byte("{}"))
+		}
+	default:
+		fl.raw = js.ReadRawAppend(fl.raw[:0])
+		fl.f(path, fl.raw)
+	}
+}
+
+// jsonPathKey returns the JSONPath notation of the given key, i.e. .key for an identifier and ['key'] otherwise.
+func jsonPathKey(key string) string {
+	ident := key != ""
+	for i, c := range key {
+		if !(c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			ident = false
+			break
+		}
+	}
+	if ident {
+		return "." + key
+	}
+	// a backslash and a quote have a special meaning in a quoted key
+	b := strings.Builder{}
+	b.WriteString("['")
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '\\' || c == '\'' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteString("']")
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	input := `{"a":{"b":[1, "x"]}, "c d":null, "e'f":[], "g":{}, "_h2":[[true]]} 7`
+	var pairs []string
+	err := Flatten(strings.NewReader(input), func(path string, raw []byte) {
+		pairs = append(pairs, path+"="+string(raw))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := `["$.a.b[0]=1" "$.a.b[1]=\"x\"" "$['c d']=null" "$['e\\'f']=[]" "$.g={}" "$._h2[0][0]=true" "$=7"]`
+	if a := fmt.Sprintf("%q", pairs); a != ex {
+		t.Fatalf("expected %s, got %s", ex, a)
+	}
+	if err = Flatten(strings.NewReader(`{"a":`), func(string, []byte) {}); err == nil {
+		t.Fatal("expected an error for truncated input")
+	}
+}
+
+func TestFlatten_controlCharacters(t *testing.T) {
+	var raws []string
+	err := Flatten(strings.NewReader(`{"k":"a\nb"} ["\u0001"]`), func(path string, raw []byte) {
+		raws = append(raws, string(raw))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprintf("%q", raws); a != `["\"a\\nb\"" "\"\\u0001\""]` {
+		t.Fatalf("unexpected raw values %s", a)
+	}
+	for _, raw := range raws {
+		if !json.Valid([]byte(raw)) {
+			t.Errorf("expected valid JSON, got %q", raw)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package jsonstream
+
+import (
+	"io"
+
+	"github.com/tada/catch"
+)
+
+// flusher is the io.Writer that delivers the output written to it when the thresholds of the FlushEvery option are
+// reached.
+type flusher struct {
+	w      io.Writer
+	hook   func() error
+	bytes  int
+	values int
+
+	// number of bytes and values written since the last flush
+	nb int
+	nv int
+}
+
+// newFlusher returns a flusher that writes on the given writer and delivers the output according to the FlushEvery
+// and FlushHook options in the given options.
+func newFlusher(w io.Writer, o *marshalOptions) *flusher {
+	return &flusher{w: w, hook: o.flushHook, bytes: o.flushBytes, values: o.flushValues}
+}
+
+func (f *flusher) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.nb += n
+	if err == nil && f.bytes > 0 && f.nb >= f.bytes {
+		err = f.flush()
+	}
+	return n, err
+}
+
+// valueWritten is called when an element of an array or a field of an object has been written.
+func (f *flusher) valueWritten() {
+	if err := f.countValue(); err != nil {
+		panic(catch.Error(err))
+	}
+}
+
+// countValue counts a value that has been written and flushes when the threshold of values is reached.
+func (f *flusher) countValue() error {
+	f.nv++
+	if f.values > 0 && f.nv >= f.values {
+		return f.flush()
+	}
+	return nil
+}
+
+func (f *flusher) flush() error {
+	f.nb = 0
+	f.nv = 0
+	if f.hook != nil {
+		return f.hook()
+	}
+	return flushWriter(f.w)
+}
+
+// flushWriter calls the Flush method of the given writer, if it has one. Both the Flush() error of a bufio.Writer and
+// the Flush() of an http.Flusher are recognized.
+func flushWriter(w io.Writer) error {
+	switch fw := w.(type) {
+	case interface{ Flush() error }:
+		return fw.Flush()
+	case interface{ Flush() }:
+		fw.Flush()
+	}
+	return nil
+}
+
+// valueWritten notifies the flusher of the given writer, if any, that a value has been written.
+func valueWritten(w io.Writer) {
+	if f := optionsOf(w).flusher; f != nil {
+		f.valueWritten()
+	}
+}
+
+// Flush delivers the output that has been written on the given writer so far. It calls the hook of the FlushHook
+// option, when given, and otherwise the Flush method of the writer that was passed to MarshalWriter, e.g. a
+// bufio.Writer or an http.ResponseWriter that implements http.Flusher. A Streamer calls Flush to make partial results
+// available to the reader at a point of its choosing, e.g. after each event of a server-sent event stream. Nothing
+// happens when there is no Flush method.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func Flush(w io.Writer) {
+	var err error
+	if f := optionsOf(w).flusher; f != nil {
+		err = f.flush()
+	} else {
+		err = flushWriter(w)
+	}
+	if err != nil {
+		panic(catch.Error(err))
+	}
+}
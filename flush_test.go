@@ -0,0 +1,109 @@
+package jsonstream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// flushRecorder records the length of its buffer at each flush.
+type flushRecorder struct {
+	bytes.Buffer
+	flushes []int
+}
+
+func (r *flushRecorder) Flush() error {
+	r.flushes = append(r.flushes, r.Len())
+	return nil
+}
+
+// httpFlushRecorder has the Flush method of an http.Flusher.
+type httpFlushRecorder struct {
+	flushRecorder
+}
+
+func (r *httpFlushRecorder) Flush() {
+	_ = r.flushRecorder.Flush()
+}
+
+func intArray(n int) Streamer {
+	return AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		a := NewArrayWriter(w)
+		for i := 1; i <= n; i++ {
+			a.Int(int64(i))
+		}
+		a.Close()
+		return nil
+	}))
+}
+
+func TestFlushEvery(t *testing.T) {
+	tests := []struct {
+		bytes, values int
+		flushes       string
+	}{
+		{0, 2, `[4 8]`},
+		{0, 1, `[2 4 6 8 10]`},
+		{4, 0, `[4 8]`},
+		{0, 0, `[]`},
+	}
+	for _, tt := range tests {
+		r := flushRecorder{}
+		if err := MarshalWriter(intArray(5), &r, FlushEvery(tt.bytes, tt.values)); err != nil {
+			t.Fatal(err)
+		}
+		if r.String() != `[1,2,3,4,5]` {
+			t.Fatalf("unexpected output %s", r.String())
+		}
+		if a := fmt.Sprint(r.flushes); a != tt.flushes {
+			t.Errorf("FlushEvery(%d, %d): expected flushes at %s, got %s", tt.bytes, tt.values, tt.flushes, a)
+		}
+	}
+
+	h := httpFlushRecorder{}
+	if err := MarshalWriter(intArray(3), &h, FlushEvery(0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprint(h.flushes); a != `[2 4 6]` {
+		t.Errorf("unexpected flushes %s", a)
+	}
+}
+
+func TestFlushHook(t *testing.T) {
+	b := bytes.Buffer{}
+	var flushes []string
+	hook := FlushHook(func() error {
+		flushes = append(flushes, b.String())
+		return nil
+	})
+	s := AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		WriteString(w, "a")
+		Flush(w)
+		WriteString(w, "b")
+		return nil
+	}))
+	if err := MarshalWriter(s, &b, hook); err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprint(flushes); a != `["a"]` {
+		t.Errorf("unexpected flushes %s", a)
+	}
+
+	hook = FlushHook(func() error {
+		return errors.New("flush failed")
+	})
+	if err := MarshalWriter(intArray(3), &b, hook, FlushEvery(0, 1)); err == nil || err.Error() != "flush failed" {
+		t.Errorf("expected flush error, got %v", err)
+	}
+}
+
+func TestFlush_noOptions(t *testing.T) {
+	r := flushRecorder{}
+	Flush(&r)
+	Flush(&bytes.Buffer{})
+	if len(r.flushes) != 1 {
+		t.Errorf("expected one flush, got %d", len(r.flushes))
+	}
+}
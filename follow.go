@@ -0,0 +1,178 @@
+package jsonstream
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tada/catch"
+)
+
+// A FileWatcher waits for a file to change. It lets a FollowReader be woken by file system notifications, e.g. from
+// fsnotify, instead of by polling.
+type FileWatcher interface {
+	// Wait blocks until the file may have changed or the context is done, in which case the error of the context is
+	// returned.
+	Wait(ctx context.Context) error
+}
+
+type pollWatcher time.Duration
+
+// PollWatcher returns a FileWatcher that waits for the given interval before it reports that the file may have
+// changed.
+func PollWatcher(interval time.Duration) FileWatcher {
+	return pollWatcher(interval)
+}
+
+func (p pollWatcher) Wait(ctx context.Context) error {
+	t := time.NewTimer(time.Duration(p))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// A FollowReader reads JSON Lines (NDJSON) from a file that other processes append to, in the same way as tail -f
+// follows a file. The file is read from the start. At its end, the reader waits for more lines using a FileWatcher.
+// When the file is truncated, it is read again from the start, and when it is replaced by a new file, e.g. by log
+// rotation, the new file is read from the start. A line that hasn't been completed by a newline is not read until it
+// is.
+type FollowReader struct {
+	path    string
+	watcher FileWatcher
+	options []DecoderOption
+	f       *os.File
+	r       *bufio.Reader
+
+	// offset is the number of bytes read from the file, and line holds the bytes of the incomplete line
+	offset int64
+	line   []byte
+}
+
+// NewFollowReader creates a new FollowReader that reads the file with the given path and waits for changes using the
+// given watcher. The given options are applied to the decoder of each line.
+func NewFollowReader(path string, watcher FileWatcher, options ...DecoderOption) *FollowReader {
+	return &FollowReader{path: path, watcher: watcher, options: options}
+}
+
+// Read waits for the next complete line and passes the document on it to the UnmarshalFromJSON method of the given
+// consumer. Empty lines are skipped. The file doesn't need to exist before its first line is read.
+//
+// The function returns the error of the context when the context is done while it waits.
+func (fr *FollowReader) Read(ctx context.Context, c Consumer) error {
+	for {
+		if fr.f == nil {
+			if err := fr.open(); err != nil {
+				if !os.IsNotExist(err) {
+					return err
+				}
+				if err = fr.watcher.Wait(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		chunk, err := fr.r.ReadSlice('\n')
+		fr.line = append(fr.line, chunk...)
+		fr.offset += int64(len(chunk))
+		switch err {
+		case nil:
+			line := fr.line
+			fr.line = fr.line[:0]
+			if !isBlank(line) {
+				return catch.Do(func() {
+					NewBytesDecoder(line, fr.options...).ReadConsumer(c)
+				})
+			}
+		case bufio.ErrBufferFull:
+		case io.EOF:
+			changed, err := fr.reopen()
+			if err != nil {
+				return err
+			}
+			if !changed {
+				if err = fr.watcher.Wait(ctx); err != nil {
+					return err
+				}
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// Close closes the file that is read.
+func (fr *FollowReader) Close() error {
+	if fr.f == nil {
+		return nil
+	}
+	err := fr.f.Close()
+	fr.f = nil
+	return err
+}
+
+func (fr *FollowReader) open() error {
+	f, err := os.Open(fr.path)
+	if err != nil {
+		return err
+	}
+	fr.f = f
+	fr.r = bufio.NewReader(f)
+	fr.offset = 0
+	fr.line = fr.line[:0]
+	return nil
+}
+
+// reopen is called at the end of the file. It reads the file again from the start when it has been truncated or
+// replaced, and returns true if it did or if there is more to read.
+func (fr *FollowReader) reopen() (bool, error) {
+	fi, err := os.Stat(fr.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// the file is being rotated
+			return false, nil
+		}
+		return false, err
+	}
+	ofi, err := fr.f.Stat()
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case !os.SameFile(fi, ofi):
+		if ofi.Size() > fr.offset {
+			// read what was appended to the old file before it was replaced
+			return true, nil
+		}
+		if err = fr.Close(); err == nil {
+			err = fr.open()
+		}
+		return true, err
+	case fi.Size() < fr.offset:
+		if _, err = fr.f.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		fr.r.Reset(fr.f)
+		fr.offset = 0
+		fr.line = fr.line[:0]
+		return true, nil
+	}
+	return false, nil
+}
+
+// isBlank returns true if the given bytes only contain whitespace.
+func isBlank(b []byte) bool {
+	for _, c := range b {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package jsonstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// watcherFunc is a FileWatcher that calls a function.
+type watcherFunc func(ctx context.Context) error
+
+func (f watcherFunc) Wait(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestFollowReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "follow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.ndjson")
+	appendFile := func(s string) {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.WriteString(s)
+		_ = f.Close()
+	}
+
+	// each wait makes the next change to the file
+	changes := []func(){
+		func() { appendFile("{\"n\": 1}\n\n{\"n\"") },
+		func() { appendFile(": 2}\n") },
+		func() { _ = ioutil.WriteFile(path, []byte("{\"n\": 3}\n"), 0644) },
+		func() {
+			appendFile("{\"n\": 4}\n")
+			_ = os.Rename(path, path+".1")
+			appendFile("{\"n\": 5}\n")
+		},
+	}
+	waits := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	fr := NewFollowReader(path, watcherFunc(func(ctx context.Context) error {
+		if waits == len(changes) {
+			cancel()
+			return ctx.Err()
+		}
+		changes[waits]()
+		waits++
+		return nil
+	}))
+	defer fr.Close()
+	var ns []interface{}
+	c := consumerFunc(func(js Decoder, firstToken json.Token) {
+		js.ReadStringOrEnd('}')
+		ns = append(ns, js.ReadInt())
+		js.ReadDelim('}')
+	})
+	for err == nil {
+		err = fr.Read(ctx, c)
+	}
+	if err != context.Canceled {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+	if a := fmt.Sprint(ns, waits); a != `[1 2 3 4 5] 4` {
+		t.Errorf("unexpected values and waits %s", a)
+	}
+}
+
+func TestPollWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := PollWatcher(0).Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := PollWatcher(1e12).Wait(ctx); err != context.Canceled {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+}
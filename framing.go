@@ -0,0 +1,104 @@
+package jsonstream
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/tada/catch"
+)
+
+const contentLengthHeader = "Content-Length"
+
+// A FrameReader reads JSON documents that are framed using a "Content-Length: N\r\n\r\n" header, i.e. the framing
+// used by the Language Server Protocol and the Debug Adapter Protocol.
+type FrameReader struct {
+	r *bufio.Reader
+}
+
+// A FrameWriter writes JSON documents that are framed using a "Content-Length: N\r\n\r\n" header, i.e. the framing
+// used by the Language Server Protocol and the Debug Adapter Protocol.
+type FrameWriter struct {
+	w io.Writer
+}
+
+// NewFrameReader creates a new FrameReader that reads frames from the given io.Reader.
+func NewFrameReader(r io.Reader) *FrameReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &FrameReader{r: br}
+}
+
+// NewFrameWriter creates a new FrameWriter that writes frames to the given io.Writer.
+func NewFrameWriter(w io.Writer) *FrameWriter {
+	return &FrameWriter{w: w}
+}
+
+// Read reads the header of the next frame and then passes the frame body to the UnmarshalFromJSON method of the given
+// consumer using a Decoder that is limited to the body. Headers other than Content-Length are ignored. Any part of
+// the body that isn't read by the consumer is discarded, also when the consumer fails, so that the next call reads
+// the next frame.
+//
+// The function returns io.EOF if the stream ends before a new frame starts.
+func (fr *FrameReader) Read(c Consumer) error {
+	n, err := fr.readHeader()
+	if err != nil {
+		return err
+	}
+	lr := &io.LimitedReader{R: fr.r, N: n}
+	err = catch.Do(func() {
+		NewDecoder(lr).ReadConsumer(c)
+	})
+	if _, derr := io.Copy(ioutil.Discard, lr); err == nil {
+		err = derr
+	}
+	return err
+}
+
+// readHeader reads the header lines of a frame up to and including the empty line that separates the header from the
+// body and returns the value of the Content-Length header.
+func (fr *FrameReader) readHeader() (int64, error) {
+	n := int64(-1)
+	for first := true; ; first = false {
+		line, err := fr.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && !(first && line == "") {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return 0, fmt.Errorf("malformed frame header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(line[:i]), contentLengthHeader) {
+			if n, err = strconv.ParseInt(strings.TrimSpace(line[i+1:]), 10, 64); err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid %s header %q", contentLengthHeader, line)
+			}
+		}
+	}
+	if n < 0 {
+		return 0, errors.New("frame header has no " + contentLengthHeader)
+	}
+	return n, nil
+}
+
+// Write marshals the given streamer and writes the result as a frame body preceded by a Content-Length header.
+func (fw *FrameWriter) Write(s Streamer) error {
+	bs, err := Marshal(s)
+	if err == nil {
+		h := fmt.Sprintf("%s: %d\r\n\r\n", contentLengthHeader, len(bs))
+		_, err = fw.w.Write(append([]byte(h), bs...))
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package jsonstream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type consumerFunc func(js Decoder, firstToken json.Token)
+
+func (f consumerFunc) UnmarshalFromJSON(js Decoder, firstToken json.Token) {
+	f(js, firstToken)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestFrameReader(t *testing.T) {
+	fr := NewFrameReader(strings.NewReader(
+		"Content-Length: 8\r\n\r\n{\"v\":23}" +
+			"Content-Type: application/json\r\ncontent-length: 10\r\n\r\n{\"v\":42}  "))
+	tv := ts{}
+	if err := fr.Read(&tv); err != nil {
+		t.Fatal(err)
+	}
+	if tv.v != 23*time.Millisecond {
+		t.Fatalf("expected 23ms, got %s", tv.v)
+	}
+	if err := fr.Read(&tv); err != nil {
+		t.Fatal(err)
+	}
+	if tv.v != 42*time.Millisecond {
+		t.Fatalf("expected 42ms, got %s", tv.v)
+	}
+	if err := fr.Read(&tv); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestFrameReader_discardsUnread(t *testing.T) {
+	fr := NewFrameReader(bufio.NewReader(strings.NewReader(
+		"Content-Length: 11\r\n\r\n\"a\" \"b\" [] Content-Length: 3\r\n\r\n\"c\"")))
+	var s []json.Token
+	c := consumerFunc(func(js Decoder, firstToken json.Token) {
+		s = append(s, firstToken)
+	})
+	if err := fr.Read(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := fr.Read(c); err != nil {
+		t.Fatal(err)
+	}
+	if !(len(s) == 2 && s[0] == "a" && s[1] == "c") {
+		t.Fatalf(`expected ["a" "c"], got %v`, s)
+	}
+}
+
+func TestFrameReader_afterError(t *testing.T) {
+	// the body is larger than what the decoder reads ahead before it fails
+	body := `{"v":"x","w":"` + strings.Repeat("w", 8192) + `"}`
+	fr := NewFrameReader(strings.NewReader(
+		"Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body + "Content-Length: 8\r\n\r\n{\"v\":42}"))
+	tv := ts{}
+	if err := fr.Read(&tv); err == nil || err.Error() != "expected an integer, got string x" {
+		t.Fatalf("expected integer error, got %v", err)
+	}
+	if err := fr.Read(&tv); err != nil || tv.v != 42*time.Millisecond {
+		t.Fatalf("expected 42ms after failed frame, got %s, %v", tv.v, err)
+	}
+}
+
+func TestFrameReader_errors(t *testing.T) {
+	tests := map[string]string{
+		"Content-Length: 8\r\n":                  "unexpected EOF",
+		"Content-Length 8\r\n\r\n{}":             `malformed frame header "Content-Length 8"`,
+		"Content-Length: x\r\n\r\n{}":            `invalid Content-Length header "Content-Length: x"`,
+		"Content-Length: -1\r\n\r\n{}":           `invalid Content-Length header "Content-Length: -1"`,
+		"Content-Type: text/plain\r\n\r\n{}":     "frame header has no Content-Length",
+		"Content-Length: 8\r\n\r\n{\"v\":\"x\"}": "expected an integer, got string x",
+		"Content-Length: 4\r\n\r\n{\"v\":23}":    "unexpected EOF",
+	}
+	for input, expected := range tests {
+		err := NewFrameReader(bytes.NewBufferString(input)).Read(&ts{})
+		if err == nil || err.Error() != expected {
+			t.Errorf("%q: expected error %q, got %v", input, expected, err)
+		}
+	}
+}
+
+func TestFrameWriter(t *testing.T) {
+	b := bytes.Buffer{}
+	fw := NewFrameWriter(&b)
+	if err := fw.Write(&ts{v: 23 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Write(&ts{v: 4 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	ex := "Content-Length: 8\r\n\r\n{\"v\":23}Content-Length: 7\r\n\r\n{\"v\":4}"
+	if a := b.String(); a != ex {
+		t.Fatalf("expected %q, got %q", ex, a)
+	}
+
+	tv := ts{}
+	fr := NewFrameReader(&b)
+	if err := fr.Read(&tv); err != nil || tv.v != 23*time.Millisecond {
+		t.Fatalf("unexpected round trip result %s, %v", tv.v, err)
+	}
+}
+
+func TestFrameWriter_error(t *testing.T) {
+	if err := NewFrameWriter(failingWriter{}).Write(&ts{}); err == nil || err.Error() != "write failed" {
+		t.Fatalf("expected write error, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build go1.18
+// +build go1.18
+
+package jsonstream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func FuzzDecoder(f *testing.F) {
+	for _, seed := range []string{
+		` { "a" : 1 , "b" : [ true , false , null ] , "c" : { } } `,
+		`[1, -1, 0, -0, 1.5, -0.25e10, 1E+2, 3e-4, 12345678901234567890]`,
+		`"esc\"aped\\\/\b\f\n\r\t"`,
+		`"😀 Aå"`,
+		`[[[["deep"]]]]`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, bs []byte) {
+		ac, acErr := scannerTokens(string(bs))
+		if acErr != nil {
+			// the scanner is stricter than the json.Decoder, e.g. when the input ends inside of a container
+			return
+		}
+		ex, exErr := jsonTokens(t, string(bs))
+		if exErr != nil {
+			t.Fatalf("%q: json.Decoder failed: %v", bs, exErr)
+		}
+		if !reflect.DeepEqual(ex, ac) {
+			t.Fatalf("%q: expected %v, got %v", bs, ex, ac)
+		}
+	})
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, seed := range []string{`{"v":23}`, `{"v":-1,"x":"y"}`, `{}`, `null`} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, bs []byte) {
+		tv := ts{}
+		if Unmarshal(&tv, bs) != nil {
+			return
+		}
+		first, err := Marshal(&tv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tv2 := ts{}
+		if err = Unmarshal(&tv2, first); err != nil {
+			t.Fatalf("%q: unmarshal of %q failed: %v", bs, first, err)
+		}
+		if tv != tv2 {
+			t.Fatalf("%q: round trip changed %v to %v", bs, tv, tv2)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// A DecodeHook converts a scalar value before ReadInto assigns it to a pointer to the given target type. The value is
+// one of the tokens string, json.Number, bool, or nil, or the result of the previous hook of the chain that the hook
+// is part of (see the DecodeHooks option). A hook that doesn't handle the value returns it unchanged.
+type DecodeHook func(v interface{}, target reflect.Type) (interface{}, error)
+
+// TimeHook returns a DecodeHook that parses a string into a time.Time using the first of the given layouts that
+// matches it, or the layouts returned by DefaultTimeLayouts when no layouts are given.
+func TimeHook(layouts ...string) DecodeHook {
+	timeType := reflect.TypeOf(time.Time{})
+	return func(v interface{}, target reflect.Type) (interface{}, error) {
+		if s, ok := v.(string); ok && target == timeType {
+			return parseTimeLayouts(s, layouts, nil)
+		}
+		return v, nil
+	}
+}
+
+// WeakTypingHook returns a DecodeHook that converts between strings, numbers, and booleans as needed by the kind of
+// the target, e.g. "42" to a number, 1 to true, and true to "true". A string is converted to a boolean using
+// strconv.ParseBool. A number is converted to the boolean false when it is zero and to true otherwise. Unlike the
+// CoerceScalars option, the conversions may lose information.
+func WeakTypingHook() DecodeHook {
+	return func(v interface{}, target reflect.Type) (interface{}, error) {
+		switch target.Kind() {
+		case reflect.Bool:
+			switch t := v.(type) {
+			case string:
+				return strconv.ParseBool(t)
+			case json.Number:
+				f, err := t.Float64()
+				return f != 0, err
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+			reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			switch t := v.(type) {
+			case string:
+				if !isNumber(t) {
+					return nil, fmt.Errorf("cannot convert %q to %s", t, target)
+				}
+				return json.Number(t), nil
+			case bool:
+				if t {
+					return json.Number("1"), nil
+				}
+				return json.Number("0"), nil
+			}
+		case reflect.String:
+			switch t := v.(type) {
+			case json.Number:
+				return string(t), nil
+			case bool:
+				return strconv.FormatBool(t), nil
+			}
+		}
+		return v, nil
+	}
+}
+
+// readHooked reads the next value and passes it through the decode hooks of the decoder before it is assigned to the
+// given pointer. It returns false, without reading anything, if the next value isn't a scalar or if ptr isn't a
+// pointer.
+func (d *decoder) readHooked(ptr interface{}) bool {
+	pv := reflect.ValueOf(ptr)
+	if pv.Kind() != reflect.Ptr || pv.IsNil() {
+		return false
+	}
+	switch d.PeekKind() {
+	case KindArray, KindObject, KindInvalid:
+		return false
+	}
+	t, err := d.Token()
+	if err != nil {
+		panic(d.unexpectedError(err))
+	}
+	target := pv.Type().Elem()
+	v := interface{}(t)
+	for _, h := range d.hooks {
+		if v, err = h(v, target); err != nil {
+			panic(d.unexpectedError(err))
+		}
+	}
+	if v != nil && reflect.TypeOf(v).AssignableTo(target) {
+		pv.Elem().Set(reflect.ValueOf(v))
+		return true
+	}
+
+	// let the hooked value be read in the regular way
+	o := d.decoderOptions
+	o.hooks = nil
+	(&decoder{TokenSource: &sliceSource{v}, decoderOptions: o}).ReadInto(ptr)
+	return true
+}
+
+// sliceSource is a TokenSource that returns the tokens of a slice.
+type sliceSource []json.Token
+
+func (s *sliceSource) Token() (json.Token, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	t := (*s)[0]
+	*s = (*s)[1:]
+	return t, nil
+}
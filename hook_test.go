@@ -0,0 +1,73 @@
+package jsonstream
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tada/catch"
+)
+
+func TestDecodeHooks(t *testing.T) {
+	input := `["2024-01-02 15:04", "42", 1, 0, "t", 7, true, "127.0.0.1", null, 2.5]`
+	upper := func(v interface{}, target reflect.Type) (interface{}, error) {
+		if s, ok := v.(string); ok && target.Kind() == reflect.String {
+			return strings.ToUpper(s), nil
+		}
+		return v, nil
+	}
+	options := []DecoderOption{DecodeHooks(TimeHook("2006-01-02 15:04")), DecodeHooks(WeakTypingHook(), upper)}
+	decoders := []Decoder{NewDecoder(strings.NewReader(input), options...), NewBytesDecoder([]byte(input), options...)}
+	for _, js := range decoders {
+		var (
+			tm     time.Time
+			i      int32
+			b1, b2 bool
+			s1, s2 string
+			ip     net.IP
+			u      uint8
+			f      float64
+		)
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for _, p := range []interface{}{&tm, &i, &b1, &b2, &b2, &s1, &s2, &ip, &u, &f} {
+				js.ReadInto(p)
+			}
+			js.ReadDelim(']')
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex := `2024-01-02 15:04:00 +0000 UTC 42 true true 7 TRUE 127.0.0.1 0 2.5`
+		if a := fmt.Sprint(tm, " ", i, " ", b1, b2, " ", s1, " ", s2, " ", ip, " ", u, " ", f); a != ex {
+			t.Errorf("expected %s, got %s", ex, a)
+		}
+	}
+
+	for input, ex := range map[string]string{
+		`"x"`:    `cannot convert "x" to int`,
+		`"1000"`: `number 1000 at "" overflows int8`,
+		`"x1"`:   `time "x1" does not match any of the layouts`,
+	} {
+		err := catch.Do(func() {
+			js := NewBytesDecoder([]byte(input), DecodeHooks(WeakTypingHook()))
+			switch input {
+			case `"x"`:
+				var i int
+				js.ReadInto(&i)
+			case `"1000"`:
+				var i int8
+				js.ReadInto(&i)
+			default:
+				var tm time.Time
+				js.ReadInto(&tm)
+			}
+		})
+		if err == nil || !strings.Contains(err.Error(), ex) {
+			t.Errorf("%s: expected error containing %q, got %v", input, ex, err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package jsonstream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+)
+
+// An HTTPOption configures the middleware that is returned by CheckBody.
+type HTTPOption func(*httpOptions)
+
+type httpOptions struct {
+	maxBytes     int64
+	contentTypes []string
+	validate     bool
+	valid        []ValidOption
+	check        func(body io.Reader) error
+}
+
+// HTTPMaxBody makes CheckBody reject a request body that is larger than n bytes. A body with a larger Content-Length is
+// rejected before it is read, and the body that the handler reads fails with an error once more than n bytes have been
+// read.
+func HTTPMaxBody(n int64) HTTPOption {
+	return func(o *httpOptions) {
+		o.maxBytes = n
+	}
+}
+
+// HTTPContentTypes makes CheckBody accept request bodies with the given media types instead of only with
+// application/json. Parameters of the Content-Type, such as the charset, are ignored.
+func HTTPContentTypes(types ...string) HTTPOption {
+	return func(o *httpOptions) {
+		o.contentTypes = types
+	}
+}
+
+// HTTPValidate makes CheckBody check the request body with Valid and the given options before the handler runs.
+func HTTPValidate(options ...ValidOption) HTTPOption {
+	return func(o *httpOptions) {
+		o.validate = true
+		o.valid = options
+	}
+}
+
+// HTTPCheck makes CheckBody pass the request body to the given function before the handler runs, e.g. to check it
+// against a schema. A body that the function returns an error for is rejected as unprocessable. The function is called
+// after the check of the HTTPValidate option, if given.
+func HTTPCheck(check func(body io.Reader) error) HTTPOption {
+	return func(o *httpOptions) {
+		o.check = check
+	}
+}
+
+// A Problem is a problem details object as defined by RFC 7807. CheckBody writes it with the media type
+// application/problem+json when it rejects a request.
+type Problem struct {
+	// Type is a URI that identifies the type of the problem. It is omitted when empty, which means "about:blank"
+	Type string
+
+	// Title is a short summary of the type of the problem
+	Title string
+
+	// Status is the HTTP status code
+	Status int
+
+	// Detail explains this occurrence of the problem
+	Detail string
+}
+
+// MarshalToJSON writes the problem as a JSON object with the members type, title, status, and detail. Empty members
+// are omitted.
+func (p *Problem) MarshalToJSON(w io.Writer) {
+	o := NewObjectWriter(w)
+	if p.Type != "" {
+		o.String("type", p.Type)
+	}
+	if p.Title != "" {
+		o.String("title", p.Title)
+	}
+	if p.Status != 0 {
+		o.Int("status", int64(p.Status))
+	}
+	if p.Detail != "" {
+		o.String("detail", p.Detail)
+	}
+	o.Close()
+}
+
+// CheckBody returns a middleware handler that checks the request body before it calls the given handler, and writes a
+// Problem response instead when the check fails. A body is only checked when the request has one. The Content-Type
+// must be application/json, or one of the types of the HTTPContentTypes option, and the body must not be larger than
+// the limit of the HTTPMaxBody option.
+//
+// The body is passed on as a stream unless the HTTPValidate or HTTPCheck option is given. The body is then read in
+// full before the handler runs, and held in memory so that the handler can read it again. The HTTPMaxBody option
+// should be given to bound that memory.
+func CheckBody(next http.Handler, options ...HTTPOption) http.Handler {
+	o := &httpOptions{contentTypes: []string{"application/json"}}
+	for _, option := range options {
+		option(o)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); !o.acceptsType(ct) {
+			writeProblem(w, http.StatusUnsupportedMediaType, fmt.Sprintf("content type %q is not supported", ct))
+			return
+		}
+		if o.maxBytes > 0 {
+			if r.ContentLength > o.maxBytes {
+				writeProblem(w, http.StatusRequestEntityTooLarge, o.tooLarge().Error())
+				return
+			}
+			r.Body = &limitedBody{ReadCloser: r.Body, n: o.maxBytes, err: &limitError{o.tooLarge()}}
+		}
+		if o.validate || o.check != nil {
+			body, status, err := o.checkBody(r.Body)
+			if err != nil {
+				writeProblem(w, status, err.Error())
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acceptsType returns true if the media type of the given Content-Type is one of the accepted types.
+func (o *httpOptions) acceptsType(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	for _, t := range o.contentTypes {
+		if mt == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *httpOptions) tooLarge() error {
+	return fmt.Errorf("request body exceeds the maximum of %d bytes", o.maxBytes)
+}
+
+// checkBody reads the given body and checks it according to the options. It returns the body, or the HTTP status
+// code and the error of a failed check.
+func (o *httpOptions) checkBody(r io.Reader) ([]byte, int, error) {
+	var buf bytes.Buffer
+	var err error
+	if o.validate {
+		err = Valid(io.TeeReader(r, &buf), o.valid...)
+	} else {
+		_, err = buf.ReadFrom(r)
+	}
+	if err != nil {
+		switch err.(type) {
+		case *limitError:
+			return nil, http.StatusRequestEntityTooLarge, err
+		case *ValidationError:
+			return nil, http.StatusBadRequest, err
+		}
+		return nil, http.StatusBadRequest, fmt.Errorf("reading request body: %s", err)
+	}
+	if o.check != nil {
+		if err = o.check(bytes.NewReader(buf.Bytes())); err != nil {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+	}
+	return buf.Bytes(), 0, nil
+}
+
+// limitError is the error returned by a limitedBody when the limit is exceeded.
+type limitError struct {
+	error
+}
+
+// limitedBody is a request body that fails with a limitError when more than n bytes are read from it.
+type limitedBody struct {
+	io.ReadCloser
+	n   int64
+	err *limitError
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.n < 0 {
+		return 0, b.err
+	}
+	if int64(len(p)) > b.n+1 {
+		p = p[:b.n+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.n {
+		n, b.n = int(b.n), -1
+		return n, b.err
+	}
+	b.n -= int64(n)
+	return n, err
+}
+
+// writeProblem writes a Problem response with the given status code and detail.
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = MarshalWriter(&Problem{Title: http.StatusText(status), Status: status, Detail: detail}, w)
+}
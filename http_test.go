@@ -0,0 +1,111 @@
+package jsonstream
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckBody(t *testing.T) {
+	var got string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			got = "error: " + err.Error()
+			return
+		}
+		got = string(bs)
+	})
+	h := CheckBody(echo, HTTPMaxBody(16), HTTPValidate(ValidMaxDepth(2)),
+		HTTPCheck(func(body io.Reader) error {
+			bs, _ := ioutil.ReadAll(body)
+			if strings.Contains(string(bs), "bad") {
+				return errors.New("bad is not allowed")
+			}
+			return nil
+		}))
+	tests := []struct {
+		contentType string
+		body        io.Reader
+		status      int
+		response    string
+	}{
+		{"application/json; charset=utf-8", strings.NewReader(`{"a": [1]}`), 200, ``},
+		{"text/plain", strings.NewReader(`{}`), 415,
+			`{"title":"Unsupported Media Type","status":415,"detail":"content type \"text/plain\" is not supported"}`},
+		{"application/json", strings.NewReader(`[1, 2, 3, 4, 5, 6, 7, 8, 9]`), 413,
+			`{"title":"Request Entity Too Large","status":413,` +
+				`"detail":"request body exceeds the maximum of 16 bytes"}`},
+		{"application/json", ioutil.NopCloser(strings.NewReader(`[1, 2, 3, 4, 5, 6, 7, 8, 9]`)), 413,
+			`{"title":"Request Entity Too Large","status":413,` +
+				`"detail":"request body exceeds the maximum of 16 bytes"}`},
+		{"application/json", strings.NewReader(`[[[1]]]`), 400,
+			`{"title":"Bad Request","status":400,"detail":"maximum depth 2 exceeded at offset 3"}`},
+		{"application/json", strings.NewReader(`["bad"]`), 422,
+			`{"title":"Unprocessable Entity","status":422,"detail":"bad is not allowed"}`},
+	}
+	for i, tc := range tests {
+		got = ""
+		r := httptest.NewRequest("POST", "/", tc.body)
+		r.Header.Set("Content-Type", tc.contentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tc.status || w.Body.String() != tc.response {
+			t.Errorf("%d: expected %d %s, got %d %s", i, tc.status, tc.response, w.Code, w.Body.String())
+		}
+		if tc.status == 200 && got != `{"a": [1]}` {
+			t.Errorf("unexpected body %q passed to the handler", got)
+		}
+		if tc.status != 200 && (got != "" || w.Header().Get("Content-Type") != "application/problem+json") {
+			t.Errorf("expected problem response, got %q and content type %s", got, w.Header().Get("Content-Type"))
+		}
+	}
+
+	// the body is streamed to the handler when it isn't checked
+	r := httptest.NewRequest("POST", "/", ioutil.NopCloser(strings.NewReader(`"0123456789abcdef"`)))
+	r.Header.Set("Content-Type", "application/json")
+	CheckBody(echo, HTTPMaxBody(16)).ServeHTTP(httptest.NewRecorder(), r)
+	if got != "error: request body exceeds the maximum of 16 bytes" {
+		t.Errorf("expected limit error in the handler, got %q", got)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{"a": 1, "a": 2}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	CheckBody(echo, HTTPValidate(ValidSyntax(Strict()))).ServeHTTP(w, r)
+	if ex := `{"title":"Bad Request","status":400,"detail":"duplicate key \"a\" in object at offset 9"}`; w.Body.String() != ex {
+		t.Errorf("expected %s, got %s", ex, w.Body.String())
+	}
+	got = "none"
+	CheckBody(echo).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if got != "" {
+		t.Errorf("expected request without body to pass, got %q", got)
+	}
+}
+
+func TestHTTPContentTypes(t *testing.T) {
+	passed := 0
+	h := CheckBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { passed++ }),
+		HTTPContentTypes("application/json", "application/merge-patch+json"))
+	for ct, status := range map[string]int{
+		"application/merge-patch+json":             200,
+		"Application/JSON; charset=utf-8":          200,
+		"application/ld+json":                      415,
+		"application/json; charset=\"unterminated": 415,
+		"": 415,
+	} {
+		r := httptest.NewRequest("PATCH", "/", strings.NewReader(`{"a": null}`))
+		r.Header.Set("Content-Type", ct)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != status {
+			t.Errorf("%q: expected %d, got %d %s", ct, status, w.Code, w.Body.String())
+		}
+	}
+	if passed != 2 {
+		t.Errorf("expected 2 requests to pass, got %d", passed)
+	}
+}
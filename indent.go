@@ -0,0 +1,283 @@
+package jsonstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/tada/catch"
+)
+
+// indentWriter is an io.Writer that indents the JSON that is written to it before it is passed on to the underlying
+// writer. Whitespace outside of strings is replaced with the indentation.
+type indentWriter struct {
+	w      io.Writer
+	prefix string
+	indent string
+	buf    []byte
+	depth  int
+
+	// opened is true when the last written delimiter opened a container. The line break that follows is deferred
+	// so that empty containers can be written as [] and {}
+	opened   bool
+	inString bool
+	escaped  bool
+
+	// inline is the maximum length of a container that is written on one line, or 0
+	inline int
+
+	// pending holds the one line form of a container that is written on one line unless it grows longer than inline.
+	// It is nil when no such container is open. pendingDepth is the nesting depth within that container
+	pending      []byte
+	pendingDepth int
+
+	// noInline is true when the next container must be indented since its one line form is too long
+	noInline bool
+
+	// compact is the number of WriteCompact calls in progress. Their values are written without whitespace
+	compact int
+}
+
+func (iw *indentWriter) Write(p []byte) (int, error) {
+	b := iw.buf[:0]
+	for _, c := range p {
+		b = iw.put(b, c)
+	}
+	iw.buf = b
+	if _, err := iw.w.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// put appends the indented form of the given byte to b.
+func (iw *indentWriter) put(b []byte, c byte) []byte {
+	if iw.pending != nil {
+		return iw.putPending(b, c)
+	}
+	if iw.inString {
+		iw.stringByte(c)
+		return append(b, c)
+	}
+	switch c {
+	case ' ', '\t', '\r', '\n':
+		return b
+	}
+	if iw.opened {
+		iw.opened = false
+		if c == ']' || c == '}' {
+			iw.depth--
+			return append(b, c)
+		}
+		b = iw.newline(b)
+	}
+	if iw.compact > 0 {
+		iw.inString = c == '"'
+		return append(b, c)
+	}
+	switch c {
+	case '[', '{':
+		if iw.inline > 0 && !iw.noInline {
+			iw.pending = append(iw.pending, c)
+			iw.pendingDepth = 1
+			return b
+		}
+		iw.noInline = false
+		iw.depth++
+		iw.opened = true
+		b = append(b, c)
+	case ']', '}':
+		iw.depth--
+		b = append(iw.newline(b), c)
+	case ',':
+		b = iw.newline(append(b, c))
+	case ':':
+		b = append(b, ':', ' ')
+	case '"':
+		iw.inString = true
+		b = append(b, c)
+	default:
+		b = append(b, c)
+	}
+	return b
+}
+
+// putPending adds the given byte to the one line form of the pending container. The container is appended to b when
+// it is complete. When it grows too long, its bytes are instead written again as an indented container.
+func (iw *indentWriter) putPending(b []byte, c byte) []byte {
+	if iw.inString {
+		iw.stringByte(c)
+		iw.pending = append(iw.pending, c)
+	} else {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			return b
+		case '[', '{':
+			iw.pendingDepth++
+			iw.pending = append(iw.pending, c)
+		case ']', '}':
+			iw.pendingDepth--
+			iw.pending = append(iw.pending, c)
+		case ',', ':':
+			iw.pending = append(iw.pending, c, ' ')
+		case '"':
+			iw.inString = true
+			iw.pending = append(iw.pending, c)
+		default:
+			iw.pending = append(iw.pending, c)
+		}
+	}
+	if iw.pendingDepth == 0 {
+		b = append(b, iw.pending...)
+		iw.pending = nil
+		return b
+	}
+	if len(iw.pending) > iw.inline {
+		p := iw.pending
+		iw.pending = nil
+		iw.inString = false
+		iw.escaped = false
+		iw.noInline = true
+		for _, c := range p {
+			b = iw.put(b, c)
+		}
+	}
+	return b
+}
+
+// stringByte tracks the end of the string that the given byte is part of.
+func (iw *indentWriter) stringByte(c byte) {
+	switch {
+	case iw.escaped:
+		iw.escaped = false
+	case c == '\\':
+		iw.escaped = true
+	case c == '"':
+		iw.inString = false
+	}
+}
+
+func (iw *indentWriter) newline(b []byte) []byte {
+	b = append(b, '\n')
+	b = append(b, iw.prefix...)
+	for i := 0; i < iw.depth; i++ {
+		b = append(b, iw.indent...)
+	}
+	return b
+}
+
+// WriteCompact writes the value that is streamed by the given Streamer, or null if the Streamer is nil, without
+// insignificant whitespace even when the Indent option is in effect, e.g. to keep a large matrix that is embedded in an
+// otherwise indented document on one line. The value starts on a line of its own like any other indented value.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteCompact(w io.Writer, s Streamer) {
+	if ow, ok := w.(*optionsWriter); ok {
+		if iw, ok := ow.Writer.(*indentWriter); ok {
+			iw.compact++
+			defer func() { iw.compact-- }()
+		}
+	}
+	WriteStreamer(w, s)
+}
+
+// CompactStream reads JSON from src and writes it to dst with all insignificant whitespace removed. The input is
+// processed token by token so its size is not limited by the available memory. Consecutive top level values are
+// separated by a newline.
+//
+// The function will recover a catch.Error panic and return its cause.
+func CompactStream(dst io.Writer, src io.Reader) error {
+	bw := bufio.NewWriter(dst)
+	return copyTokens(bw, bw, src)
+}
+
+// IndentStream reads JSON from src and writes it to dst indented in the same way as json.Indent does it, except that
+// whitespace before and after top level values isn't retained. The input is processed token by token so its size is
+// not limited by the available memory. Consecutive top level values are separated by a newline.
+//
+// The function will recover a catch.Error panic and return its cause.
+func IndentStream(dst io.Writer, src io.Reader, prefix, indent string) error {
+	bw := bufio.NewWriter(dst)
+	return copyTokens(&indentWriter{w: bw, prefix: prefix, indent: indent}, bw, src)
+}
+
+// copyTokens reads the tokens from src and writes their compact form to w. The newlines that separate top level
+// values are written to bw, which is flushed at the end.
+func copyTokens(w io.Writer, bw *bufio.Writer, src io.Reader) error {
+	return catch.Do(func() {
+		c := newTokenCopier(w, bw, src)
+		for {
+			key := c.d.atKey
+			t, ok := c.next()
+			if !ok {
+				break
+			}
+			c.write(t, key)
+		}
+		c.flush()
+	})
+}
+
+// tokenCopier writes the compact form of tokens that are read by a decoder.
+type tokenCopier struct {
+	d    *decoder
+	w    io.Writer
+	bw   *bufio.Writer
+	b    []byte
+	last byte
+}
+
+// newTokenCopier returns a tokenCopier that reads from src and writes to w. The newlines that separate top level
+// values are written to bw.
+func newTokenCopier(w io.Writer, bw *bufio.Writer, src io.Reader) *tokenCopier {
+	return &tokenCopier{d: NewDecoder(src).(*decoder), w: w, bw: bw}
+}
+
+// next returns the next token and true, or nil and false at the end of the input.
+func (c *tokenCopier) next() (json.Token, bool) {
+	t, err := c.d.Token()
+	if err == io.EOF && len(c.d.stack) == 0 {
+		return nil, false
+	}
+	if err != nil {
+		panic(c.d.unexpectedError(err))
+	}
+	return t, true
+}
+
+// write writes the compact form of the given token, preceded by the separator that it needs.
+func (c *tokenCopier) write(t json.Token, key bool) {
+	if c.last == 0 && len(c.b) > 0 {
+		c.bw.WriteByte('\n')
+	}
+	c.b = appendToken(c.b[:0], c.last, t, key)
+	c.last = c.b[len(c.b)-1]
+	if len(c.d.stack) == 0 {
+		c.last = 0
+	}
+	if _, err := c.w.Write(c.b); err != nil {
+		panic(catch.Error(err))
+	}
+}
+
+// writeRaw writes the given compact value, which must not be a top level value, preceded by the separator that it
+// needs.
+func (c *tokenCopier) writeRaw(raw []byte) {
+	c.b = c.b[:0]
+	switch c.last {
+	case '[', '{', ':':
+	default:
+		c.b = append(c.b, ',')
+	}
+	c.b = append(c.b, raw...)
+	c.last = raw[len(raw)-1]
+	if _, err := c.w.Write(c.b); err != nil {
+		panic(catch.Error(err))
+	}
+}
+
+func (c *tokenCopier) flush() {
+	if err := c.bw.Flush(); err != nil {
+		panic(catch.Error(err))
+	}
+}
@@ -0,0 +1,168 @@
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIndentWriter(t *testing.T) {
+	inputs := []string{
+		`{}`,
+		`[]`,
+		`{"a":[1,2,{"b":[]}],"c":{},"d":"x, y: {z}\" ["}`,
+		` [ 1 , [ ] , { "a" : null } ] `,
+		`"top"`,
+	}
+	for _, input := range inputs {
+		ex := bytes.Buffer{}
+		if err := json.Indent(&ex, []byte(input), ">", "  "); err != nil {
+			t.Fatal(err)
+		}
+		// write one byte at a time to verify that the state is kept between writes
+		ac := bytes.Buffer{}
+		iw := &indentWriter{w: &ac, prefix: ">", indent: "  "}
+		for i := 0; i < len(input); i++ {
+			if _, err := iw.Write([]byte{input[i]}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// json.Indent retains leading and trailing whitespace
+		if e := string(bytes.TrimSpace(ex.Bytes())); ac.String() != e {
+			t.Errorf("%q: expected %q, got %q", input, e, ac.String())
+		}
+	}
+}
+
+func TestIndentWriter_inline(t *testing.T) {
+	input := `{"a":[1,2,{"b":[]}],"c":{"d":"x, y: [z]\" {","e":[[1,2],[3,4]]},"f":[]}`
+	ex := `{
+  "a": [1, 2, {"b": []}],
+  "c": {
+    "d": "x, y: [z]\" {",
+    "e": [[1, 2], [3, 4]]
+  },
+  "f": []
+}`
+	for _, chunk := range []int{1, len(input)} {
+		ac := bytes.Buffer{}
+		iw := &indentWriter{w: &ac, indent: "  ", inline: 24}
+		for i := 0; i < len(input); i += chunk {
+			if _, err := iw.Write([]byte(input[i : i+chunk])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if ac.String() != ex {
+			t.Errorf("expected %s, got %s", ex, ac.String())
+		}
+	}
+	bs, err := Marshal(&intList{1, 2}, Indent("", "\t"), InlineSmall(10))
+	if err != nil || string(bs) != `[1, 2]` {
+		t.Fatalf("expected [1, 2], got %s, %v", bs, err)
+	}
+}
+
+func TestWriteCompact(t *testing.T) {
+	matrix := AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		a := NewArrayWriter(w)
+		a.Streamer(&intList{1, 2})
+		a.Streamer(&intList{3, 4})
+		a.Close()
+		return nil
+	}))
+	doc := AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		o := NewObjectWriter(w)
+		o.Field("m")
+		WriteCompact(w, matrix)
+		o.Field("s")
+		WriteCompact(w, AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+			WriteString(w, "a, [b]")
+			return nil
+		})))
+		o.Streamer("n", &intList{5})
+		o.Close()
+		return nil
+	}))
+	bs, err := Marshal(doc, Indent("", "  "))
+	ex := "{\n  \"m\": [[1,2],[3,4]],\n  \"s\": \"a, [b]\",\n  \"n\": [\n    5\n  ]\n}"
+	if err != nil || string(bs) != ex {
+		t.Fatalf("expected %s, got %s, %v", ex, bs, err)
+	}
+	if bs, err = Marshal(doc); err != nil || string(bs) != `{"m":[[1,2],[3,4]],"s":"a, [b]","n":[5]}` {
+		t.Fatalf("unexpected compact output %s, %v", bs, err)
+	}
+}
+
+func TestIndentWriter_error(t *testing.T) {
+	iw := &indentWriter{w: failingWriter{}, indent: " "}
+	if _, err := iw.Write([]byte(`[]`)); err == nil || err.Error() != "write failed" {
+		t.Fatalf("expected write error, got %v", err)
+	}
+}
+
+func TestIndentStream(t *testing.T) {
+	input := ` {"a" : [1, 2, {"b":[]}], "c":{}, "d":"x, y: <z>\" ["} [true, null]`
+	ac := bytes.Buffer{}
+	if err := IndentStream(&ac, strings.NewReader(input), ">", "  "); err != nil {
+		t.Fatal(err)
+	}
+	ex := "{\n>  \"a\": [\n>    1,\n>    2,\n>    {\n>      \"b\": []\n>    }\n>  ],\n>  \"c\": {},\n" +
+		">  \"d\": \"x, y: <z>\\\" [\"\n>}\n[\n>  true,\n>  null\n>]"
+	if ac.String() != ex {
+		t.Fatalf("expected %q, got %q", ex, ac.String())
+	}
+	ac.Reset()
+	if err := CompactStream(&ac, strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	ex = "{\"a\":[1,2,{\"b\":[]}],\"c\":{},\"d\":\"x, y: <z>\\\" [\"}\n[true,null]"
+	if ac.String() != ex {
+		t.Fatalf("expected %q, got %q", ex, ac.String())
+	}
+}
+
+func TestCompactStream_controlCharacters(t *testing.T) {
+	input := `["a\nb\u0001", "\t\r\b\f\"\\ é"]`
+	ac := bytes.Buffer{}
+	if err := CompactStream(&ac, strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	ex := `["a\nb\u0001","\t\r\b\f\"\\ é"]`
+	if ac.String() != ex {
+		t.Fatalf("expected %s, got %q", ex, ac.String())
+	}
+	var a, b []string
+	if err := json.Unmarshal(ac.Bytes(), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(input), &b); err != nil || !reflect.DeepEqual(a, b) {
+		t.Fatalf("expected %q, got %q", b, a)
+	}
+}
+
+func TestIndentStream_controlCharacters(t *testing.T) {
+	input := `{"k\n\u0001": "v\n\u0001"}`
+	ac := bytes.Buffer{}
+	if err := IndentStream(&ac, strings.NewReader(input), "", " "); err != nil {
+		t.Fatal(err)
+	}
+	ex := "{\n \"k\\n\\u0001\": \"v\\n\\u0001\"\n}"
+	if ac.String() != ex {
+		t.Fatalf("expected %q, got %q", ex, ac.String())
+	}
+	if !json.Valid(ac.Bytes()) {
+		t.Fatal("expected valid JSON")
+	}
+}
+
+func TestCompactStream_errors(t *testing.T) {
+	if err := CompactStream(&bytes.Buffer{}, strings.NewReader(`[1,`)); err == nil || err.Error() != "unexpected EOF" {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+	if err := CompactStream(failingWriter{}, strings.NewReader(`[1]`)); err == nil || err.Error() != "write failed" {
+		t.Fatalf("expected write error, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package jsonstream
+
+import "sync"
+
+// An InternTable holds a bounded set of strings. It is used by decoders that are created with the InternKeys option
+// so that decoding a large number of objects that share the same keys doesn't allocate the same small strings over
+// and over again. An InternTable is safe for concurrent use.
+type InternTable struct {
+	lock    sync.RWMutex
+	strings map[string]string
+	limit   int
+}
+
+// NewInternTable creates a new InternTable that holds at most limit strings. Strings that are interned when the
+// table is full are allocated as usual.
+func NewInternTable(limit int) *InternTable {
+	return &InternTable{strings: make(map[string]string), limit: limit}
+}
+
+// Len returns the number of strings that are held by the table.
+func (t *InternTable) Len() int {
+	t.lock.RLock()
+	n := len(t.strings)
+	t.lock.RUnlock()
+	return n
+}
+
+// Intern returns a string that is equal to the given bytes. The returned string is shared with other callers that
+// intern the same bytes.
+func (t *InternTable) Intern(bs []byte) string {
+	t.lock.RLock()
+	s, ok := t.strings[string(bs)]
+	t.lock.RUnlock()
+	if ok {
+		return s
+	}
+	s = string(bs)
+	t.lock.Lock()
+	if len(t.strings) < t.limit {
+		t.strings[s] = s
+	}
+	t.lock.Unlock()
+	return s
+}
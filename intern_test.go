@@ -0,0 +1,59 @@
+package jsonstream
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/tada/catch"
+)
+
+func stringData(s string) uintptr {
+	return (*[2]uintptr)(unsafe.Pointer(&s))[0]
+}
+
+func TestInternTable_Intern(t *testing.T) {
+	it := NewInternTable(1)
+	a := it.Intern([]byte("key"))
+	b := it.Intern([]byte("key"))
+	if stringData(a) != stringData(b) {
+		t.Fatal("expected interned strings to share data")
+	}
+	if c := it.Intern([]byte("other")); c != "other" {
+		t.Fatalf(`expected "other", got %q`, c)
+	}
+	if it.Len() != 1 {
+		t.Fatalf("expected table to be limited to 1 string, got %d", it.Len())
+	}
+}
+
+func TestInternKeys(t *testing.T) {
+	input := `[{"m":"m"},{"m":"i"}]`
+	for _, newDecoder := range []func(...DecoderOption) Decoder{
+		func(options ...DecoderOption) Decoder { return NewDecoder(strings.NewReader(input), options...) },
+		func(options ...DecoderOption) Decoder { return NewBytesDecoder([]byte(input), options...) },
+	} {
+		it := NewInternTable(10)
+		var keys []string
+		err := catch.Do(func() {
+			js := newDecoder(InternKeys(it))
+			js.ReadDelim('[')
+			for i := 0; i < 2; i++ {
+				js.ReadDelim('{')
+				keys = append(keys, js.ReadString())
+				js.ReadString()
+				js.ReadDelim('}')
+			}
+			js.ReadDelim(']')
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(len(keys) == 2 && stringData(keys[0]) == stringData(keys[1])) {
+			t.Fatal("expected keys to be interned")
+		}
+		if it.Len() != 1 {
+			t.Fatalf("expected only keys to be interned, got %d strings", it.Len())
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package jsonstream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tada/catch"
+	"github.com/tada/catch/pio"
+)
+
+// A JSONCNode is a value of a JSONC document, i.e. JSON with comments, together with the comments and blank lines that
+// precede it. A tree of nodes is created by ParseJSONC and written back by MarshalJSONC, so that a configuration file
+// that is maintained by humans can be edited programmatically without losing its annotations.
+//
+// A JSONCNode is also a Streamer that writes the value as plain JSON, without the comments.
+type JSONCNode struct {
+	// Comments holds the comments that precede the value, or the key of an object member, with their delimiters, e.g.
+	// "// the port". A blank line is held as an empty string.
+	Comments []string
+
+	// Kind is the kind of the value.
+	Kind Kind
+
+	// Raw is the JSON of a scalar value.
+	Raw []byte
+
+	// Elements holds the elements of an array.
+	Elements []*JSONCNode
+
+	// Members holds the members of an object in order.
+	Members []JSONCMember
+
+	// Trailing holds the comments that follow the last element or member of an array or object.
+	Trailing []string
+
+	// After holds the comments that follow the value at the end of the document. It is only used for the root node.
+	After []string
+}
+
+// A JSONCMember is a member of an object of a JSONC document.
+type JSONCMember struct {
+	Key   string
+	Value *JSONCNode
+}
+
+// ParseJSONC parses the given JSONC document into a tree of nodes. Each comment, and each blank line between comments
+// and values, is attached to the value that follows it. Comments that follow a value on the same line are thus moved
+// to the line before the next value when the tree is written back.
+func ParseJSONC(bs []byte) (n *JSONCNode, err error) {
+	err = catch.Do(func() {
+		s := &scanner{buf: bs, allowComments: true, keepComments: true}
+		n = parseJSONCNode(s, nextKind(s), s.takeComments())
+		if _, err := s.next(); err != io.EOF {
+			if err == nil {
+				err = fmt.Errorf("unexpected %s after the value", s.buf[s.start:s.end])
+			}
+			panic(catch.Error(err))
+		}
+		n.After = s.takeComments()
+	})
+	return
+}
+
+// nextKind scans the next token and returns its kind or raises a panic with a catch.Error.
+func nextKind(s *scanner) byte {
+	k, err := s.next()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		panic(catch.Error(err))
+	}
+	return k
+}
+
+func parseJSONCNode(s *scanner, k byte, comments []string) *JSONCNode {
+	n := &JSONCNode{Comments: comments}
+	switch k {
+	case '[':
+		n.Kind = KindArray
+		for {
+			k = nextKind(s)
+			comments = s.takeComments()
+			if k == ']' {
+				n.Trailing = comments
+				return n
+			}
+			n.Elements = append(n.Elements, parseJSONCNode(s, k, comments))
+		}
+	case '{':
+		n.Kind = KindObject
+		for {
+			k = nextKind(s)
+			comments = s.takeComments()
+			if k == '}' {
+				n.Trailing = comments
+				return n
+			}
+			key := s.stringValue()
+			k = nextKind(s)
+			comments = append(comments, s.takeComments()...)
+			n.Members = append(n.Members, JSONCMember{Key: key, Value: parseJSONCNode(s, k, comments)})
+		}
+	default:
+		n.Raw = append([]byte(nil), s.buf[s.start:s.end]...)
+		n.Kind = kindOfRaw(n.Raw)
+	}
+	return n
+}
+
+// Get returns the value of the member of an object with the given key, or nil if there is no such member.
+func (n *JSONCNode) Get(key string) *JSONCNode {
+	for _, m := range n.Members {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+	return nil
+}
+
+// Set assigns the value of the member of an object with the given key. A member that is already present keeps its
+// position, and also its comments unless the given value has comments of its own. Other members are added last.
+func (n *JSONCNode) Set(key string, v *JSONCNode) {
+	for i, m := range n.Members {
+		if m.Key == key {
+			if v.Comments == nil {
+				v.Comments = m.Value.Comments
+			}
+			n.Members[i].Value = v
+			return
+		}
+	}
+	n.Members = append(n.Members, JSONCMember{Key: key, Value: v})
+}
+
+// Delete removes the member of an object with the given key, together with its comments, and returns true, or returns
+// false if there is no such member.
+func (n *JSONCNode) Delete(key string) bool {
+	for i, m := range n.Members {
+		if m.Key == key {
+			n.Members = append(n.Members[:i], n.Members[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalToJSON writes the value as plain JSON, without the comments.
+func (n *JSONCNode) MarshalToJSON(w io.Writer) {
+	switch n.Kind {
+	case KindArray:
+		a := NewArrayWriter(w)
+		for _, e := range n.Elements {
+			a.Streamer(e)
+		}
+		a.Close()
+	case KindObject:
+		o := NewObjectWriter(w)
+		for _, m := range n.Members {
+			o.Streamer(m.Key, m.Value)
+		}
+		o.Close()
+	default:
+		pio.Write(w, n.Raw)
+	}
+}
+
+// MarshalJSONC returns the JSONC document of the given tree of nodes. Each element of an array and each member of an
+// object is written on a line of its own that is indented with one copy of the given indent per nesting level, and
+// each comment is written on the line, or lines, before the value that it is attached to.
+//
+// The function will recover a catch.Error panic and return its cause.
+func MarshalJSONC(n *JSONCNode, indent string) (result []byte, err error) {
+	err = catch.Do(func() {
+		b := strings.Builder{}
+		writeJSONCComments(&b, n.Comments, "")
+		writeJSONCNode(&b, n, indent, "")
+		if len(n.After) > 0 {
+			b.WriteByte('\n')
+			writeJSONCComments(&b, n.After, "")
+		}
+		result = []byte(b.String())
+	})
+	return
+}
+
+func writeJSONCNode(w io.Writer, n *JSONCNode, indent, prefix string) {
+	var open, close byte
+	var size int
+	switch n.Kind {
+	case KindArray:
+		open, close, size = '[', ']', len(n.Elements)
+	case KindObject:
+		open, close, size = '{', '}', len(n.Members)
+	default:
+		pio.Write(w, n.Raw)
+		return
+	}
+	pio.WriteByte(w, open)
+	if size > 0 || len(n.Trailing) > 0 {
+		inner := prefix + indent
+		for i := 0; i < size; i++ {
+			if i > 0 {
+				pio.WriteByte(w, ',')
+			}
+			pio.WriteByte(w, '\n')
+			var v *JSONCNode
+			if n.Kind == KindArray {
+				v = n.Elements[i]
+				writeJSONCComments(w, v.Comments, inner)
+				pio.WriteString(w, inner)
+			} else {
+				m := n.Members[i]
+				v = m.Value
+				writeJSONCComments(w, v.Comments, inner)
+				pio.WriteString(w, inner)
+				WriteString(w, m.Key)
+				pio.WriteString(w, ": ")
+			}
+			writeJSONCNode(w, v, indent, inner)
+		}
+		pio.WriteByte(w, '\n')
+		writeJSONCComments(w, n.Trailing, inner)
+		pio.WriteString(w, prefix)
+	}
+	pio.WriteByte(w, close)
+}
+
+// writeJSONCComments writes each comment on a line of its own that starts with the given prefix, and each blank line
+// as an empty line.
+func writeJSONCComments(w io.Writer, comments []string, prefix string) {
+	for _, c := range comments {
+		if c != "" {
+			pio.WriteString(w, prefix)
+			pio.WriteString(w, c)
+		}
+		pio.WriteByte(w, '\n')
+	}
+}
@@ -0,0 +1,113 @@
+package jsonstream
+
+import (
+	"testing"
+)
+
+const jsoncDocument = `// Editor settings
+{
+	// the font
+	"font": {
+		"size": 12,
+		/* a list of
+		   fallbacks */
+		"family": [
+			"Mono",
+			"Sans"
+		]
+	},
+
+	// lines
+	"wrap": true,
+	"rulers": [
+		80,
+		// the hard limit
+		120
+	],
+	"empty": {},
+	"only": [
+		// nothing yet
+	]
+}
+// end of settings
+`
+
+func TestParseJSONC(t *testing.T) {
+	n, err := ParseJSONC([]byte(jsoncDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := MarshalJSONC(n, "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := string(bs); a != jsoncDocument {
+		t.Fatalf("unexpected round trip:\n%s", a)
+	}
+	if c := n.Get("wrap").Comments; len(c) != 2 || c[0] != "" || c[1] != "// lines" {
+		t.Errorf("unexpected comments %q", c)
+	}
+
+	n.Set("wrap", &JSONCNode{Kind: KindBool, Raw: []byte("false")})
+	n.Get("font").Delete("family")
+	n.Set("tabs", &JSONCNode{Comments: []string{"// added"}, Kind: KindNumber, Raw: []byte("4")})
+	bs, _ = MarshalJSONC(n, "  ")
+	ex := `// Editor settings
+{
+  // the font
+  "font": {
+    "size": 12
+  },
+
+  // lines
+  "wrap": false,
+  "rulers": [
+    80,
+    // the hard limit
+    120
+  ],
+  "empty": {},
+  "only": [
+    // nothing yet
+  ],
+  // added
+  "tabs": 4
+}
+// end of settings
+`
+	if a := string(bs); a != ex {
+		t.Fatalf("unexpected edited document:\n%s", a)
+	}
+
+	bs, err = Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := string(bs); a != `{"font":{"size":12},"wrap":false,"rulers":[80,120],"empty":{},"only":[],"tabs":4}` {
+		t.Errorf("unexpected JSON %s", a)
+	}
+}
+
+func TestParseJSONC_errors(t *testing.T) {
+	for _, bad := range []string{`{"a": 1 /* open`, `[1] 2`, `// only a comment`, `{"a": / 1}`} {
+		if _, err := ParseJSONC([]byte(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestAllowComments(t *testing.T) {
+	input := []byte("/* head */ [1, // one\n 2 /* two */]")
+	var l intList
+	if err := Unmarshal(&l, input); err == nil {
+		t.Error("expected comments to be rejected by default")
+	}
+	l = nil
+	js := NewBytesDecoder(input, AllowComments())
+	if err := Extract(js, map[string]Consumer{"": &l}); err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 2 || l[1] != 2 {
+		t.Errorf("unexpected list %v", l)
+	}
+}
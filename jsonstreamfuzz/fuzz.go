@@ -0,0 +1,72 @@
+//go:build go1.18
+// +build go1.18
+
+// Package jsonstreamfuzz contains helpers that make it easy to fuzz implementations of the jsonstream.Consumer and
+// jsonstream.Streamer interfaces using native Go fuzzing, and to check their round trip using testing/quick.
+package jsonstreamfuzz
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tada/catch"
+	"github.com/tada/jsonstream"
+)
+
+// A Value is both a jsonstream.Consumer and a jsonstream.Streamer.
+type Value interface {
+	jsonstream.Consumer
+	jsonstream.Streamer
+}
+
+// Consumer fuzzes the UnmarshalFromJSON method of the consumers produced by newConsumer. The given seeds are added
+// to the corpus. Each input is decoded using both jsonstream.NewDecoder and jsonstream.NewBytesDecoder. The fuzzing
+// fails if a consumer panics with anything but a catch.Error or if an input that is accepted by the stricter bytes
+// decoder is rejected by the other decoder.
+func Consumer(f *testing.F, newConsumer func() jsonstream.Consumer, seeds ...string) {
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, bs []byte) {
+		bErr := decode(jsonstream.NewBytesDecoder(bs), newConsumer())
+		if err := decode(jsonstream.NewDecoder(bytes.NewReader(bs)), newConsumer()); err != nil && bErr == nil {
+			t.Fatalf("NewDecoder failed on %q: %v", bs, err)
+		}
+	})
+}
+
+// RoundTrip fuzzes the round trip of the values produced by newValue. The given seeds are added to the corpus. Each
+// input that can be decoded into a value is marshaled, decoded into a new value, and marshaled again. The fuzzing
+// fails if the second decode fails or if the two marshaled results differ.
+func RoundTrip(f *testing.F, newValue func() Value, seeds ...string) {
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, bs []byte) {
+		v := newValue()
+		if decode(jsonstream.NewBytesDecoder(bs), v) != nil {
+			return
+		}
+		first, err := jsonstream.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal of value decoded from %q failed: %v", bs, err)
+		}
+		v = newValue()
+		if err = decode(jsonstream.NewBytesDecoder(first), v); err != nil {
+			t.Fatalf("decode of marshaled %q failed: %v", first, err)
+		}
+		second, err := jsonstream.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal of value decoded from %q failed: %v", first, err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Fatalf("round trip of %q changed %q to %q", bs, first, second)
+		}
+	})
+}
+
+func decode(js jsonstream.Decoder, c jsonstream.Consumer) error {
+	return catch.Do(func() {
+		js.ReadConsumer(c)
+	})
+}
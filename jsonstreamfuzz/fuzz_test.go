@@ -0,0 +1,51 @@
+//go:build go1.18
+// +build go1.18
+
+package jsonstreamfuzz
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/tada/catch/pio"
+	"github.com/tada/jsonstream"
+)
+
+type point struct {
+	x, y int64
+}
+
+func (p *point) MarshalToJSON(w io.Writer) {
+	pio.WriteString(w, `{"x":`)
+	pio.WriteInt(w, p.x)
+	pio.WriteString(w, `,"y":`)
+	pio.WriteInt(w, p.y)
+	pio.WriteByte(w, '}')
+}
+
+func (p *point) UnmarshalFromJSON(js jsonstream.Decoder, firstToken json.Token) {
+	jsonstream.AssertDelim(firstToken, '{')
+	for {
+		s, ok := js.ReadStringOrEnd('}')
+		if !ok {
+			break
+		}
+		switch s {
+		case "x":
+			p.x = js.ReadInt()
+		case "y":
+			p.y = js.ReadInt()
+		default:
+			js.ReadRawAppend(nil)
+		}
+	}
+}
+
+func FuzzConsumer(f *testing.F) {
+	Consumer(f, func() jsonstream.Consumer { return &point{} }, `{"x":1,"y":2}`, `{"z":[1,{}]}`)
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	RoundTrip(f, func() Value { return &point{} }, `{"x":1,"y":2}`, `{"y":-3}`)
+}
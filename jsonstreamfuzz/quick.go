@@ -0,0 +1,190 @@
+//go:build go1.18
+// +build go1.18
+
+package jsonstreamfuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/tada/jsonstream"
+)
+
+// CheckRoundTrip checks the round trip of random values in the style of testing/quick. Each value produced by
+// generate is marshaled, decoded into a value produced by newValue, and marshaled again. The check fails if the
+// decode fails, if the two marshaled results differ, or if the decoded value isn't deeply equal to the generated
+// value. On failure, the marshaled JSON is shrunk by removing array elements for as long as the result still fails to
+// round trip, and the smallest failing JSON that was found is reported along with the cause. Object members are never
+// removed since many values always marshal all of their fields.
+//
+// The config is passed to quick.Check and may be nil.
+func CheckRoundTrip(t testing.TB, generate func(r *rand.Rand) Value, newValue func() Value, config *quick.Config) {
+	t.Helper()
+	var failing []byte
+	var cause error
+	err := quick.Check(func(seed int64) bool {
+		failing, cause = roundTrip(generate(rand.New(rand.NewSource(seed))), newValue)
+		return cause == nil
+	}, config)
+	if err == nil {
+		return
+	}
+	if cause == nil {
+		t.Fatal(err)
+		return
+	}
+	if failing != nil {
+		if minimal := shrink(failing, newValue); !bytes.Equal(minimal, failing) {
+			t.Fatalf("%v\nminimal failing JSON: %s", cause, minimal)
+			return
+		}
+	}
+	t.Fatal(cause)
+}
+
+// roundTrip marshals the given value, decodes the result into a new value, and marshals that value. It returns the
+// first marshaled JSON and an error describing why the round trip failed, or nil if it succeeded.
+func roundTrip(v Value, newValue func() Value) ([]byte, error) {
+	first, err := jsonstream.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal failed: %v", err)
+	}
+	dv := newValue()
+	if err = decode(jsonstream.NewBytesDecoder(first), dv); err != nil {
+		return first, fmt.Errorf("decode of marshaled %s failed: %v", first, err)
+	}
+	second, err := jsonstream.Marshal(dv)
+	if err != nil {
+		return first, fmt.Errorf("marshal of value decoded from %s failed: %v", first, err)
+	}
+	if !bytes.Equal(first, second) {
+		return first, fmt.Errorf("round trip changed %s to %s", first, second)
+	}
+	if !reflect.DeepEqual(v, dv) {
+		return first, fmt.Errorf("value decoded from %s is not equal to the marshaled value", first)
+	}
+	return first, nil
+}
+
+// shrink returns the smallest JSON, obtained by removing array elements from bs, that can be decoded into a value that
+// then either doesn't marshal to the same JSON or fails to round trip. The given JSON is returned unchanged when it
+// doesn't fail.
+func shrink(bs []byte, newValue func() Value) []byte {
+	fails := func(bs []byte) bool {
+		v := newValue()
+		if decode(jsonstream.NewBytesDecoder(bs), v) != nil {
+			return false
+		}
+		first, err := roundTrip(v, newValue)
+		return err != nil || !bytes.Equal(first, bs)
+	}
+	root, err := parseNode(json.NewDecoder(bytes.NewReader(bs)))
+	if err != nil || !fails(bs) {
+		return bs
+	}
+	for changed := true; changed; {
+		changed = false
+		root.walk(func(n *jsonNode) {
+			for i := 0; n.delim == '[' && i < len(n.children); {
+				child := n.children[i]
+				n.children = append(n.children[:i], n.children[i+1:]...)
+				if fails(root.appendTo(nil)) {
+					changed = true
+					continue
+				}
+				n.children = append(n.children[:i], append([]*jsonNode{child}, n.children[i:]...)...)
+				i++
+			}
+		})
+	}
+	return root.appendTo(nil)
+}
+
+// A jsonNode is a parsed JSON value that keeps the order of object members.
+type jsonNode struct {
+	// delim is '[' or '{' for a container and 0 for a scalar
+	delim byte
+
+	// raw is the JSON of a scalar
+	raw []byte
+
+	// keys and children are the keys and values of an object, or the elements of an array, in which case keys is nil
+	keys     []string
+	children []*jsonNode
+}
+
+func parseNode(jd *json.Decoder) (*jsonNode, error) {
+	jd.UseNumber()
+	t, err := jd.Token()
+	if err != nil {
+		return nil, err
+	}
+	return parseValue(jd, t)
+}
+
+func parseValue(jd *json.Decoder, t json.Token) (*jsonNode, error) {
+	d, ok := t.(json.Delim)
+	if !ok {
+		raw, err := json.Marshal(t)
+		return &jsonNode{raw: raw}, err
+	}
+	n := &jsonNode{delim: byte(d)}
+	for {
+		t, err := jd.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if t == json.Delim(']') || t == json.Delim('}') {
+			return n, nil
+		}
+		if n.delim == '{' {
+			n.keys = append(n.keys, t.(string))
+			if t, err = jd.Token(); err != nil {
+				return nil, err
+			}
+		}
+		c, err := parseValue(jd, t)
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, c)
+	}
+}
+
+// walk calls f for each container in the tree, parents before their children.
+func (n *jsonNode) walk(f func(*jsonNode)) {
+	if n.delim == 0 {
+		return
+	}
+	f(n)
+	for _, c := range n.children {
+		c.walk(f)
+	}
+}
+
+func (n *jsonNode) appendTo(b []byte) []byte {
+	if n.delim == 0 {
+		return append(b, n.raw...)
+	}
+	b = append(b, n.delim)
+	for i, c := range n.children {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if n.delim == '{' {
+			k, _ := json.Marshal(n.keys[i])
+			b = append(append(b, k...), ':')
+		}
+		b = c.appendTo(b)
+	}
+	return append(b, n.delim+2)
+}
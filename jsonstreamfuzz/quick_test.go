@@ -0,0 +1,83 @@
+//go:build go1.18
+// +build go1.18
+
+package jsonstreamfuzz
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/tada/jsonstream"
+)
+
+// absList is a list of integers that has a bug; it decodes the absolute values.
+type absList []int64
+
+func (l *absList) MarshalToJSON(w io.Writer) {
+	a := jsonstream.NewArrayWriter(w)
+	for _, i := range *l {
+		a.Int(i)
+	}
+	a.Close()
+}
+
+func (l *absList) UnmarshalFromJSON(js jsonstream.Decoder, firstToken json.Token) {
+	jsonstream.AssertDelim(firstToken, '[')
+	*l = absList{}
+	for {
+		i, ok := js.ReadIntOrEnd(']')
+		if !ok {
+			break
+		}
+		if i < 0 {
+			i = -i
+		}
+		*l = append(*l, i)
+	}
+}
+
+// fatalRecorder records the message passed to Fatal or Fatalf instead of failing the test.
+type fatalRecorder struct {
+	testing.TB
+	msg string
+}
+
+func (r *fatalRecorder) Fatal(args ...interface{}) {
+	r.msg = fmt.Sprint(args...)
+}
+
+func (r *fatalRecorder) Fatalf(format string, args ...interface{}) {
+	r.msg = fmt.Sprintf(format, args...)
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	CheckRoundTrip(t, func(r *rand.Rand) Value {
+		return &point{x: r.Int63(), y: -r.Int63()}
+	}, func() Value { return &point{} }, nil)
+}
+
+func TestCheckRoundTrip_shrink(t *testing.T) {
+	r := &fatalRecorder{TB: t}
+	CheckRoundTrip(r, func(r *rand.Rand) Value {
+		l := absList{1, 2, -3, 4}
+		return &l
+	}, func() Value { return &absList{} }, &quick.Config{MaxCount: 1})
+	if !strings.HasSuffix(r.msg, "\nminimal failing JSON: [-3]") {
+		t.Fatalf("unexpected failure %q", r.msg)
+	}
+}
+
+func TestShrink(t *testing.T) {
+	newValue := func() Value { return &absList{} }
+	if s := string(shrink([]byte(`[1,2,3]`), newValue)); s != `[1,2,3]` {
+		t.Errorf("expected passing JSON to be unchanged, got %s", s)
+	}
+	if s := string(shrink([]byte(`[1,-2,3]`), newValue)); s != `[-2]` {
+		t.Errorf("expected [-2], got %s", s)
+	}
+}
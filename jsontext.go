@@ -0,0 +1,43 @@
+//go:build goexperiment.jsonv2
+// +build goexperiment.jsonv2
+
+package jsonstream
+
+import (
+	"encoding/json"
+	"encoding/json/jsontext"
+)
+
+// jsontextSource is a TokenSource that reads its tokens from a jsontext.Decoder.
+type jsontextSource struct {
+	*jsontext.Decoder
+}
+
+// Token returns the next token converted to the token of a json.Decoder that has UseNumber enabled.
+func (s jsontextSource) Token() (json.Token, error) {
+	t, err := s.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+	switch k := t.Kind(); k {
+	case 'n':
+		return nil, nil
+	case 't', 'f':
+		return t.Bool(), nil
+	case '"':
+		return t.String(), nil
+	case '0':
+		return json.Number(t.String()), nil
+	default:
+		return json.Delim(k), nil
+	}
+}
+
+// NewJSONTextDecoder creates a new Decoder that reads its tokens from the given jsontext.Decoder, so that the
+// strictness and performance options of the jsontext package apply. Options that are only effective for decoders
+// created with NewDecoder or NewBytesDecoder are ignored.
+//
+// The function is only available when building with GOEXPERIMENT=jsonv2.
+func NewJSONTextDecoder(jd *jsontext.Decoder, options ...DecoderOption) Decoder {
+	return NewTokenSourceDecoder(jsontextSource{jd}, options...)
+}
@@ -0,0 +1,47 @@
+//go:build goexperiment.jsonv2
+// +build goexperiment.jsonv2
+
+package jsonstream
+
+import (
+	"encoding/json/jsontext"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestNewJSONTextDecoder(t *testing.T) {
+	js := NewJSONTextDecoder(jsontext.NewDecoder(strings.NewReader(`{"a":[1, 2.5, "x", true, null], "b":{"c":[]}}`)))
+	var vs []interface{}
+	var raw []byte
+	err := catch.Do(func() {
+		js.ReadDelim('{')
+		js.ReadObjectFields(map[string]func(Decoder){
+			"a": func(js Decoder) {
+				js.ReadDelim('[')
+				vs = append(vs, js.ReadInt(), js.ReadFloat(), js.ReadString(), js.ReadBool())
+				js.ReadConsumerOrEnd(nil, ']')
+				_, ok := js.ReadIntOrEnd(']')
+				vs = append(vs, ok, js.CurrentPath())
+			},
+			"b": func(js Decoder) { raw = js.ReadRawAppend(nil) },
+		}, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprint(vs, string(raw)); a != `[1 2.5 x true false /a]{"c":[]}` {
+		t.Fatalf("unexpected values %s", a)
+	}
+
+	js = NewJSONTextDecoder(jsontext.NewDecoder(strings.NewReader(`{"a":1, "a":2}`)))
+	err = catch.Do(func() {
+		js.ReadDelim('{')
+		js.ReadObjectFields(nil, nil)
+	})
+	if err == nil || !strings.Contains(err.Error(), "duplicate object member name") {
+		t.Fatalf("expected duplicate name error, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package jsonstream
+
+import (
+	"bytes"
+	"io"
+)
+
+// A LinesWriter writes JSON documents as JSON Lines (NDJSON), i.e. each document is written as a record that consists
+// of the document followed by a newline. Each record is marshaled in full before it is written, so a record that
+// fails to marshal leaves no trace in the output.
+//
+// The output is delivered according to the FlushEvery and FlushHook options in the same way as the output of
+// MarshalWriter, except that each record counts as one value and that a flush only happens between records.
+//
+// The exported fields are optional and must be set before the first call to Write. They let a log shipper implement
+// delivery guarantees and rotation decisions outside of this package.
+type LinesWriter struct {
+	// OnRecord is called after each record has been written with the total number of bytes and records written so
+	// far, and after any flush that the record caused. An error returned by OnRecord is returned by Write.
+	OnRecord func(bytes, records int64) error
+
+	// Sync makes Flush, and every flush caused by the FlushEvery option, also call the Sync method of the writer, if
+	// it has one, e.g. to make an os.File commit the records to stable storage.
+	Sync bool
+
+	w       io.Writer
+	flusher *flusher
+	hook    func() error
+	options []MarshalOption
+	buf     bytes.Buffer
+	bytes   int64
+	records int64
+}
+
+// NewLinesWriter creates a new LinesWriter that writes records to the given io.Writer. The given options are applied
+// to each record, except for FlushEvery and FlushHook, which apply to the stream of records. The Indent option must
+// not be given since the documents cannot contain newlines.
+func NewLinesWriter(w io.Writer, options ...MarshalOption) *LinesWriter {
+	o := &marshalOptions{}
+	for _, option := range options {
+		option(o)
+	}
+	lw := &LinesWriter{w: w, hook: o.flushHook, options: append(options[:len(options):len(options)], noFlush)}
+	o.flushHook = lw.deliver
+	lw.flusher = newFlusher(w, o)
+	return lw
+}
+
+// noFlush is a MarshalOption that removes the FlushEvery and FlushHook options.
+func noFlush(o *marshalOptions) {
+	o.flushBytes = 0
+	o.flushValues = 0
+	o.flushHook = nil
+}
+
+// Write marshals the given streamer and writes the result as a record.
+func (lw *LinesWriter) Write(s Streamer) error {
+	lw.buf.Reset()
+	if err := MarshalBuffer(s, &lw.buf, lw.options...); err != nil {
+		return err
+	}
+	lw.buf.WriteByte('\n')
+	n, err := lw.flusher.Write(lw.buf.Bytes())
+	lw.bytes += int64(n)
+	if n == lw.buf.Len() {
+		lw.records++
+		if err == nil {
+			err = lw.flusher.countValue()
+		}
+	}
+	if err == nil && lw.OnRecord != nil {
+		err = lw.OnRecord(lw.bytes, lw.records)
+	}
+	return err
+}
+
+// Flush delivers the records written so far using the hook of the FlushHook option, when given, or otherwise the
+// Flush method of the writer, if it has one, and then calls its Sync method if the Sync field is true.
+func (lw *LinesWriter) Flush() error {
+	return lw.flusher.flush()
+}
+
+// deliver is the hook of the flusher of the LinesWriter.
+func (lw *LinesWriter) deliver() error {
+	var err error
+	if lw.hook != nil {
+		err = lw.hook()
+	} else {
+		err = flushWriter(lw.w)
+	}
+	if err == nil && lw.Sync {
+		if sw, ok := lw.w.(interface{ Sync() error }); ok {
+			err = sw.Sync()
+		}
+	}
+	return err
+}
+
+// Bytes returns the number of bytes written so far.
+func (lw *LinesWriter) Bytes() int64 {
+	return lw.bytes
+}
+
+// Records returns the number of records written so far.
+func (lw *LinesWriter) Records() int64 {
+	return lw.records
+}
@@ -0,0 +1,86 @@
+package jsonstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// syncRecorder is a flushRecorder that also records calls to Sync.
+type syncRecorder struct {
+	flushRecorder
+	syncs int
+}
+
+func (r *syncRecorder) Sync() error {
+	r.syncs++
+	return nil
+}
+
+func TestLinesWriter(t *testing.T) {
+	r := syncRecorder{}
+	lw := NewLinesWriter(&r, FlushEvery(0, 2))
+	lw.Sync = true
+	var calls []string
+	lw.OnRecord = func(bytes, records int64) error {
+		calls = append(calls, fmt.Sprintf("%d/%d", bytes, records))
+		return nil
+	}
+	for i := 1; i <= 3; i++ {
+		if err := lw.Write(&ts{v: time.Duration(i) * time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ex := "{\"v\":1}\n{\"v\":2}\n{\"v\":3}\n"
+	if a := r.String(); a != ex {
+		t.Errorf("expected %q, got %q", ex, a)
+	}
+	if a := fmt.Sprint(calls, r.flushes, r.syncs, lw.Bytes(), lw.Records()); a != `[8/1 16/2 24/3] [16] 1 24 3` {
+		t.Errorf("unexpected hooks %s", a)
+	}
+	if err := lw.Flush(); err != nil || r.syncs != 2 {
+		t.Errorf("expected second sync, got %d, %v", r.syncs, err)
+	}
+}
+
+func TestLinesWriter_flushHook(t *testing.T) {
+	r := flushRecorder{}
+	var flushes []int
+	lw := NewLinesWriter(&r, FlushEvery(10, 0), FlushHook(func() error {
+		flushes = append(flushes, r.Len())
+		return nil
+	}))
+	for _, v := range []time.Duration{1, 1000, 2} {
+		if err := lw.Write(&ts{v: v * time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a := fmt.Sprint(flushes, r.flushes); a != `[19] []` {
+		t.Errorf("unexpected flushes %s", a)
+	}
+	lw = NewLinesWriter(&r, FlushEvery(0, 1), FlushHook(func() error { return errors.New("flush failed") }))
+	if err := lw.Write(&ts{}); err == nil || err.Error() != "flush failed" || lw.Records() != 1 {
+		t.Errorf("expected flush failed error, got %v", err)
+	}
+}
+
+func TestLinesWriter_errors(t *testing.T) {
+	r := flushRecorder{}
+	lw := NewLinesWriter(&r)
+	err := lw.Write(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		WriteString(w, "partial")
+		return errors.New("failed")
+	})))
+	if err == nil || err.Error() != "failed" || r.Len() != 0 || lw.Records() != 0 {
+		t.Errorf("expected failed error and no output, got %v, %q", err, r.String())
+	}
+	lw.OnRecord = func(bytes, records int64) error { return errors.New("rotate") }
+	if err = lw.Write(&ts{}); err == nil || err.Error() != "rotate" || lw.Records() != 1 {
+		t.Errorf("expected rotate error, got %v", err)
+	}
+	if err = NewLinesWriter(failingWriter{}).Write(&ts{}); err == nil || err.Error() != "write failed" {
+		t.Errorf("expected write failed error, got %v", err)
+	}
+}
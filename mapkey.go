@@ -0,0 +1,77 @@
+package jsonstream
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+
+	"github.com/tada/catch"
+)
+
+// MapKey returns the object key that encoding/json writes for the given map key. A key of a string kind is used as
+// is, the text of an encoding.TextMarshaler is used, and a key of an integer kind is formatted in base 10. It makes
+// maps with non-string keys, such as map[int64]T, straightforward to stream:
+//
+//	o := jsonstream.NewObjectWriter(w)
+//	for k, v := range m {
+//		o.Int(jsonstream.MapKey(k), v)
+//	}
+//	o.Close()
+//
+// A panic with a catch.Error is raised if the key is of any other type or if its MarshalText method fails.
+func MapKey(k interface{}) string {
+	v := reflect.ValueOf(k)
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if tm, ok := k.(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		if err != nil {
+			panic(catch.Error(err))
+		}
+		return string(b)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+	panic(catch.Error("unsupported map key type %T", k))
+}
+
+// ParseMapKey parses the given object key into the map key that ptr points to in the way encoding/json does it,
+// i.e. the reverse of MapKey. The key is passed to the UnmarshalText method of an encoding.TextUnmarshaler, assigned
+// as is to a key of a string kind, and parsed as a base 10 integer for a key of an integer kind. A panic with a
+// catch.Error is raised if the key cannot be parsed or if ptr doesn't point to a supported type.
+func ParseMapKey(key string, ptr interface{}) {
+	if tu, ok := ptr.(encoding.TextUnmarshaler); ok {
+		if err := tu.UnmarshalText([]byte(key)); err != nil {
+			panic(catch.Error(err))
+		}
+		return
+	}
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		panic(catch.Error("ParseMapKey: unsupported type %T", ptr))
+	}
+	v = v.Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, v.Type().Bits())
+		if err != nil {
+			panic(catch.Error("invalid %s map key %q", v.Type(), key))
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(key, 10, v.Type().Bits())
+		if err != nil {
+			panic(catch.Error("invalid %s map key %q", v.Type(), key))
+		}
+		v.SetUint(u)
+	default:
+		panic(catch.Error("ParseMapKey: unsupported type %T", ptr))
+	}
+}
@@ -0,0 +1,105 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+type keyName string
+
+type int64Map map[int64]string
+
+func (m int64Map) MarshalToJSON(w io.Writer) {
+	o := NewObjectWriter(w)
+	for _, k := range []int64{-1, 2} {
+		if v, ok := m[k]; ok {
+			o.String(MapKey(k), v)
+		}
+	}
+	o.Close()
+}
+
+func (m int64Map) UnmarshalFromJSON(js Decoder, firstToken json.Token) {
+	AssertDelim(firstToken, '{')
+	for {
+		key, ok := js.ReadStringOrEnd('}')
+		if !ok {
+			break
+		}
+		var k int64
+		ParseMapKey(key, &k)
+		m[k] = js.ReadString()
+	}
+}
+
+func TestMapKey(t *testing.T) {
+	var keys []string
+	for _, k := range []interface{}{"s", keyName("n"), -8, uint8(255), net.IPv4(10, 0, 0, 1), big.NewInt(42)} {
+		keys = append(keys, MapKey(k))
+	}
+	if a := fmt.Sprintf("%q", keys); a != `["s" "n" "-8" "255" "10.0.0.1" "42"]` {
+		t.Fatalf("unexpected keys %s", a)
+	}
+	if err := catch.Do(func() { MapKey(1.5) }); err == nil || err.Error() != "unsupported map key type float64" {
+		t.Fatalf("expected unsupported type error, got %v", err)
+	}
+
+	m := int64Map{-1: "a", 2: "b"}
+	bs := MustMarshal(m)
+	if string(bs) != `{"-1":"a","2":"b"}` {
+		t.Fatalf("unexpected output %s", bs)
+	}
+	// the keys are compatible with encoding/json
+	var jm map[int64]string
+	if err := json.Unmarshal(bs, &jm); err != nil || fmt.Sprint(jm) != fmt.Sprint(map[int64]string(m)) {
+		t.Fatalf("unexpected map %v, %v", jm, err)
+	}
+	dm := int64Map{}
+	if err := Unmarshal(dm, bs); err != nil || fmt.Sprint(dm) != fmt.Sprint(m) {
+		t.Fatalf("unexpected map %v, %v", dm, err)
+	}
+}
+
+func TestParseMapKey(t *testing.T) {
+	var (
+		s  keyName
+		i8 int8
+		u  uint
+		ip net.IP
+		n  big.Int
+	)
+	err := catch.Do(func() {
+		ParseMapKey("x", &s)
+		ParseMapKey("-128", &i8)
+		ParseMapKey("7", &u)
+		ParseMapKey("10.0.0.1", &ip)
+		ParseMapKey("123456789012345678901234567890", &n)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprint(s, " ", i8, " ", u, " ", ip, " ", &n); a != "x -128 7 10.0.0.1 123456789012345678901234567890" {
+		t.Fatalf("unexpected result %s", a)
+	}
+	for _, tc := range []struct {
+		key string
+		ptr interface{}
+		ex  string
+	}{
+		{"128", &i8, `invalid int8 map key "128"`},
+		{"-1", &u, `invalid uint map key "-1"`},
+		{"1", &[]int{}, `ParseMapKey: unsupported type *[]int`},
+		{"1", 1, `ParseMapKey: unsupported type int`},
+	} {
+		err = catch.Do(func() { ParseMapKey(tc.key, tc.ptr) })
+		if err == nil || err.Error() != tc.ex {
+			t.Errorf("expected error %q, got %v", tc.ex, err)
+		}
+	}
+}
@@ -3,9 +3,15 @@
 package jsonstream
 
 import (
+	"bufio"
 	"bytes"
+	"encoding"
+	"fmt"
 	"io"
+	"math"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/tada/catch"
 	"github.com/tada/catch/pio"
@@ -24,21 +30,238 @@ type Streamer interface {
 // and the Streamer interface. The function creates a bytes.Buffer onto which the json stream
 // is written. The resulting bytes are returned.
 //
+// The given options are applied to the whole stream (see MarshalOption).
+//
 // The function will recover a pio.Error panic and return its cause.
-func Marshal(s Streamer) (result []byte, err error) {
+func Marshal(s Streamer, options ...MarshalOption) (result []byte, err error) {
 	err = catch.Do(func() {
 		w := bytes.Buffer{}
-		s.MarshalToJSON(&w)
+		s.MarshalToJSON(newOptionsWriter(&w, options))
 		result = w.Bytes()
 	})
 	return
 }
 
+// ErrorStreamer is an alternative to Streamer for instances that stream output and report errors by returning them
+// rather than by raising a catch.Error panic.
+type ErrorStreamer interface {
+	// MarshalToJSONE serializes the instance onto the given writer and returns the first error that occurred.
+	MarshalToJSONE(w io.Writer) error
+}
+
+// errorStreamer adapts an ErrorStreamer to the Streamer interface.
+type errorStreamer struct {
+	ErrorStreamer
+}
+
+// AsStreamer returns a Streamer that calls the MarshalToJSONE method of the given ErrorStreamer and raises a panic
+// with a catch.Error if that method returns an error. The Streamer can be passed to Marshal and all other functions
+// that stream output.
+func AsStreamer(s ErrorStreamer) Streamer {
+	return errorStreamer{s}
+}
+
+func (s errorStreamer) MarshalToJSON(w io.Writer) {
+	if err := s.MarshalToJSONE(w); err != nil {
+		panic(catch.Error(err))
+	}
+}
+
+// appendWriter is an io.Writer that appends to a byte slice.
+type appendWriter struct {
+	b []byte
+}
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	w.b = append(w.b, p...)
+	return len(p), nil
+}
+
+// MarshalAppend is like Marshal but appends the json stream to dst and returns the extended buffer. The given dst
+// is returned unchanged together with the error if the marshaling fails.
+//
+// The function will recover a catch.Error panic and return its cause.
+func MarshalAppend(dst []byte, s Streamer, options ...MarshalOption) ([]byte, error) {
+	w := appendWriter{b: dst}
+	err := catch.Do(func() {
+		s.MarshalToJSON(newOptionsWriter(&w, options))
+	})
+	if err != nil {
+		return dst, err
+	}
+	return w.b, nil
+}
+
+// MarshalBuffer is like Marshal but writes the json stream to the given buffer. The buffer is not reset so the stream
+// is appended to any bytes that the buffer already holds. High-throughput marshalers can reuse the same buffer for
+// each call, optionally after calling its Grow method with a size hint, to avoid allocating fresh storage every time.
+//
+// The function will recover a catch.Error panic and return its cause.
+func MarshalBuffer(s Streamer, buf *bytes.Buffer, options ...MarshalOption) error {
+	return catch.Do(func() {
+		s.MarshalToJSON(newOptionsWriter(buf, options))
+	})
+}
+
+// marshalReader is the io.ReadCloser returned by MarshalReader.
+type marshalReader struct {
+	s       Streamer
+	options []MarshalOption
+	once    sync.Once
+	pr      *io.PipeReader
+	pw      *io.PipeWriter
+}
+
+// MarshalReader returns an io.ReadCloser from which the output of the given Streamer can be read. The Streamer is
+// called in a separate goroutine when the reader is first read and its output is produced as it is consumed, so the
+// document is never held in memory in its entirety. This makes it possible to pass a streamed document to APIs that
+// want an io.Reader, such as the body of an http.Request. An error raised by the Streamer is returned by Read. Closing
+// the reader before the output has been consumed makes the Streamer fail with io.ErrClosedPipe on its next write.
+func MarshalReader(s Streamer, options ...MarshalOption) io.ReadCloser {
+	pr, pw := io.Pipe()
+	return &marshalReader{s: s, options: options, pr: pr, pw: pw}
+}
+
+func (r *marshalReader) Read(p []byte) (int, error) {
+	r.once.Do(func() {
+		go func() {
+			bw := bufio.NewWriter(r.pw)
+			err := MarshalWriter(r.s, bw, r.options...)
+			if err == nil {
+				err = bw.Flush()
+			}
+			r.pw.CloseWithError(err)
+		}()
+	})
+	return r.pr.Read(p)
+}
+
+// Close closes the reader.
+func (r *marshalReader) Close() error {
+	return r.pr.Close()
+}
+
+// A CountingWriter is an io.Writer that counts the bytes written to it. The bytes are passed on to W unless W is nil,
+// in which case they are discarded.
+type CountingWriter struct {
+	W io.Writer
+
+	// N is the number of bytes written
+	N int64
+}
+
+func (c *CountingWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	var err error
+	if c.W != nil {
+		n, err = c.W.Write(p)
+	}
+	c.N += int64(n)
+	return n, err
+}
+
+// MarshalSize returns the exact number of bytes that Marshal would produce for the given Streamer and options, without
+// allocating the output. It is typically used to set a Content-Length, to check a quota, or to decide how to chunk
+// the output.
+//
+// The function will recover a catch.Error panic and return its cause.
+func MarshalSize(s Streamer, options ...MarshalOption) (int64, error) {
+	cw := CountingWriter{}
+	err := MarshalWriter(s, &cw, options...)
+	return cw.N, err
+}
+
+// MarshalWriter streams the given Streamer onto the given writer.
+//
+// The function will recover a catch.Error panic and return its cause.
+func MarshalWriter(s Streamer, w io.Writer, options ...MarshalOption) error {
+	return catch.Do(func() {
+		s.MarshalToJSON(newOptionsWriter(w, options))
+	})
+}
+
+// MustMarshal is like Marshal but raises a panic with a catch.Error instead of returning an error.
+func MustMarshal(s Streamer, options ...MarshalOption) []byte {
+	bs, err := Marshal(s, options...)
+	if err != nil {
+		panic(catch.Error(err))
+	}
+	return bs
+}
+
+// WriteBool writes the JSON literal true or false on the writer.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteBool(w io.Writer, b bool) {
+	pio.WriteBool(w, b)
+}
+
+// WriteByte writes the given byte on the writer. It is typically used for writing delimiters, colons, and commas.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteByte(w io.Writer, b byte) {
+	pio.WriteByte(w, b)
+}
+
+// WriteFloat writes the given float on the writer using the same format as encoding/json, i.e. without an exponent
+// unless the number is very small or very large. NaN and infinity cannot be represented in JSON and result in an
+// error.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteFloat(w io.Writer, f float64) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic(catch.Error("unsupported float value %g", f))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	var buf [32]byte
+	b := strconv.AppendFloat(buf[:0], f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	pio.Write(w, b)
+}
+
+// WriteInt writes the given integer on the writer.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteInt(w io.Writer, i int64) {
+	var buf [20]byte
+	pio.Write(w, strconv.AppendInt(buf[:0], i, 10))
+}
+
+// WriteInt64String writes the given integer as a quoted string on the writer, e.g. "9007199254740993". The format is
+// commonly used for 64-bit integers that would lose precision when read by JavaScript, which reads all numbers as
+// float64. The string is read back using the ReadInt64String method of the Decoder.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteInt64String(w io.Writer, i int64) {
+	var buf [22]byte
+	b := append(buf[:0], '"')
+	b = strconv.AppendInt(b, i, 10)
+	pio.Write(w, append(b, '"'))
+}
+
+// WriteNull writes the JSON literal null on the writer.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteNull(w io.Writer) {
+	pio.WriteString(w, "null")
+}
+
 // WriteString writes s as double quoted string on the writer using '\' to escape
-// the '"' and the '\'.
+// the '"' and the '\'. The characters '<', '>', '&', U+2028, and U+2029 are also escaped
+// when the EscapeHTML option is in effect, and the '/' is escaped when the EscapeSlash option is in effect.
 //
 // If an error occurs the method panics with a Error with the Cause set to that error
 func WriteString(w io.Writer, s string) {
+	o := optionsOf(w)
 	pio.WriteByte(w, '"')
 	r := strings.NewReader(s)
 	for {
@@ -47,12 +270,78 @@ func WriteString(w io.Writer, s string) {
 			pio.WriteByte(w, '"')
 			return
 		}
-		switch r {
-		case '"', '\\':
+		writeStringRune(w, o, r)
+	}
+}
+
+// WriteStringFrom writes the contents of the given reader as a double quoted string on the writer, escaped in the
+// same way as by WriteString. The reader is read in chunks, so large contents, e.g. of a file, can be embedded
+// without first being read into memory. Invalid UTF-8 is replaced by U+FFFD.
+//
+// If an error occurs, including an error returned by the reader, the method panics with a catch.Error with the
+// Cause set to that error
+func WriteStringFrom(w io.Writer, r io.Reader) {
+	o := optionsOf(w)
+	pio.WriteByte(w, '"')
+	br := bufio.NewReader(r)
+	for {
+		r, _, err := br.ReadRune()
+		if err != nil {
+			if err != io.EOF {
+				panic(catch.Error(err))
+			}
+			pio.WriteByte(w, '"')
+			return
+		}
+		writeStringRune(w, o, r)
+	}
+}
+
+// writeStringRune writes the given rune of a string, escaped when needed.
+func writeStringRune(w io.Writer, o *marshalOptions, r rune) {
+	switch r {
+	case '"', '\\':
+		pio.WriteByte(w, '\\')
+		pio.WriteByte(w, byte(r))
+	case '/':
+		if o.escapeSlash {
 			pio.WriteByte(w, '\\')
-			pio.WriteByte(w, byte(r))
-		default:
+		}
+		pio.WriteByte(w, '/')
+	case '<', '>', '&', '\u2028', '\u2029':
+		if o.escapeHTML {
+			writeUnicodeEscape(w, r)
+		} else {
 			pio.WriteRune(w, r)
 		}
+	default:
+		pio.WriteRune(w, r)
 	}
 }
+
+// WriteStringer writes the string returned by the String method of the given value as a double quoted string on the
+// writer.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteStringer(w io.Writer, s fmt.Stringer) {
+	WriteString(w, s.String())
+}
+
+// WriteText writes the text returned by the MarshalText method of the given value as a double quoted string on the
+// writer. The text is read back using the ReadText method of the Decoder.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteText(w io.Writer, v encoding.TextMarshaler) {
+	b, err := v.MarshalText()
+	if err != nil {
+		panic(catch.Error(err))
+	}
+	WriteString(w, string(b))
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeUnicodeEscape writes the \uXXXX escape of the given rune, which must be in the Basic Multilingual Plane.
+func writeUnicodeEscape(w io.Writer, r rune) {
+	pio.Write(w, []byte{'\\', 'u', hexDigits[r>>12&0xf], hexDigits[r>>8&0xf], hexDigits[r>>4&0xf], hexDigits[r&0xf]})
+}
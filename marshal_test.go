@@ -2,8 +2,19 @@ package jsonstream
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
+
+	"github.com/tada/catch"
 )
 
 func TestMarshal(t *testing.T) {
@@ -27,3 +38,269 @@ func TestWriteString(t *testing.T) {
 		t.Fatalf("WriteString(): expected: %s, got %s", e, a)
 	}
 }
+
+func TestMarshalWriter(t *testing.T) {
+	b := bytes.Buffer{}
+	if err := MarshalWriter(&ts{v: time.Millisecond * 23}, &b); err != nil {
+		t.Fatal(err)
+	}
+	if a := b.String(); a != `{"v":23}` {
+		t.Fatalf("MarshalWriter(): expected: {\"v\":23}, got %s", a)
+	}
+	if err := MarshalWriter(&ts{}, failingWriter{}); err == nil || err.Error() != "write failed" {
+		t.Fatalf("expected write error, got %v", err)
+	}
+}
+
+func TestMarshalReader(t *testing.T) {
+	l := make(intList, 10000)
+	for i := range l {
+		l[i] = int64(i)
+	}
+	bs, err := ioutil.ReadAll(MarshalReader(&l, Indent("", " ")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := MustMarshal(&l, Indent("", " ")); !bytes.Equal(bs, ex) {
+		t.Fatalf("expected %d bytes, got %d", len(ex), len(bs))
+	}
+
+	_, err = ioutil.ReadAll(MarshalReader(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		return errors.New("failed")
+	}))))
+	if err == nil || err.Error() != "failed" {
+		t.Fatalf("expected failed error, got %v", err)
+	}
+
+	done := make(chan error)
+	r := MarshalReader(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		err := catch.Do(func() {
+			for {
+				WriteInt(w, 1)
+			}
+		})
+		done <- err
+		return err
+	})))
+	if _, err = r.Read(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; err != io.ErrClosedPipe {
+		t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+	}
+}
+
+func TestMarshalSize(t *testing.T) {
+	l := intList{1, 22, 333}
+	for _, options := range [][]MarshalOption{nil, {Indent("", "  ")}} {
+		n, err := MarshalSize(&l, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ex := len(MustMarshal(&l, options...)); n != int64(ex) {
+			t.Errorf("expected %d, got %d", ex, n)
+		}
+	}
+	b := bytes.Buffer{}
+	cw := CountingWriter{W: &b}
+	if err := MarshalWriter(&l, &cw); err != nil || cw.N != 10 || b.String() != `[1,22,333]` {
+		t.Fatalf("unexpected count %d of %s, %v", cw.N, b.String(), err)
+	}
+}
+
+func TestMustMarshal(t *testing.T) {
+	if a := string(MustMarshal(&ts{v: time.Millisecond * 23})); a != `{"v":23}` {
+		t.Fatalf("MustMarshal(): expected: {\"v\":23}, got %s", a)
+	}
+}
+
+func TestWritePrimitives(t *testing.T) {
+	b := bytes.Buffer{}
+	WriteByte(&b, '[')
+	WriteBool(&b, true)
+	for _, f := range []float64{0, -1.5, 1e21, 1e20, 1e-7, 0.000001, 123456789} {
+		WriteByte(&b, ',')
+		WriteFloat(&b, f)
+	}
+	WriteByte(&b, ',')
+	WriteInt(&b, -42)
+	WriteByte(&b, ',')
+	WriteNull(&b)
+	WriteByte(&b, ']')
+	e := `[true,0,-1.5,1e+21,100000000000000000000,1e-7,0.000001,123456789,-42,null]`
+	if a := b.String(); a != e {
+		t.Fatalf("expected: %s, got %s", e, a)
+	}
+	err := catch.Do(func() {
+		WriteFloat(&b, math.NaN())
+	})
+	if err == nil || err.Error() != "unsupported float value NaN" {
+		t.Fatalf("expected unsupported value error, got %v", err)
+	}
+}
+
+func TestWriteInt64String(t *testing.T) {
+	b := bytes.Buffer{}
+	WriteInt64String(&b, math.MaxInt64)
+	WriteInt64String(&b, math.MinInt64)
+	if a := b.String(); a != `"9223372036854775807""-9223372036854775808"` {
+		t.Fatalf("unexpected output %s", a)
+	}
+	var i int64
+	if err := catch.Do(func() { i = NewBytesDecoder(b.Bytes()).ReadInt64String() }); err != nil || i != math.MaxInt64 {
+		t.Fatalf("expected round trip of %d, got %d, %v", int64(math.MaxInt64), i, err)
+	}
+}
+
+func TestWriteString_escapeSlash(t *testing.T) {
+	s := errorStreamerFunc(func(w io.Writer) error {
+		WriteString(w, "</script>")
+		return nil
+	})
+	if bs, err := Marshal(AsStreamer(s)); err != nil || string(bs) != `"</script>"` {
+		t.Fatalf("expected \"</script>\", got %s, %v", bs, err)
+	}
+	if bs, err := Marshal(AsStreamer(s), EscapeSlash()); err != nil || string(bs) != `"<\/script>"` {
+		t.Fatalf("expected \"<\\/script>\", got %s, %v", bs, err)
+	}
+	var ac string
+	if err := json.Unmarshal([]byte(`"<\/script>"`), &ac); err != nil || ac != "</script>" {
+		t.Fatalf("expected escaped slash to be valid JSON, got %q, %v", ac, err)
+	}
+}
+
+func TestWriteStringFrom(t *testing.T) {
+	content := strings.Repeat("<line> \"å\" ", 10000)
+	s := errorStreamerFunc(func(w io.Writer) error {
+		WriteStringFrom(w, strings.NewReader(content))
+		return nil
+	})
+	bs, err := Marshal(AsStreamer(s), EscapeHTML())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(bs, []byte(`"\u003cline\u003e \"å\" \u003cline`)) {
+		t.Fatalf("unexpected output %.40s", bs)
+	}
+	var ac string
+	if err = json.Unmarshal(bs, &ac); err != nil || ac != content {
+		t.Fatalf("expected output to unmarshal to the content, got %v", err)
+	}
+	r := io.MultiReader(strings.NewReader("abc"), iotest.TimeoutReader(strings.NewReader("x")))
+	err = catch.Do(func() {
+		WriteStringFrom(&bytes.Buffer{}, r)
+	})
+	if err == nil || err.Error() != "timeout" {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestWriteStringer(t *testing.T) {
+	b := bytes.Buffer{}
+	WriteStringer(&b, 90*time.Minute)
+	WriteStringer(&b, net.IPv4(10, 0, 0, 1))
+	if a := b.String(); a != `"1h30m0s""10.0.0.1"` {
+		t.Fatalf("unexpected output %s", a)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	b := bytes.Buffer{}
+	WriteText(&b, net.IPv4(192, 168, 0, 1))
+	WriteText(&b, big.NewInt(-42))
+	if a := b.String(); a != `"192.168.0.1""-42"` {
+		t.Fatalf("unexpected output %s", a)
+	}
+	err := catch.Do(func() {
+		WriteText(&b, time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC))
+	})
+	if err == nil {
+		t.Fatal("expected an error for a year outside of the range [0,9999]")
+	}
+}
+
+func TestMarshalBuffer(t *testing.T) {
+	b := bytes.Buffer{}
+	b.Grow(64)
+	for _, v := range []time.Duration{23, 4} {
+		if err := MarshalBuffer(&ts{v: time.Millisecond * v}, &b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a := b.String(); a != `{"v":23}{"v":4}` {
+		t.Fatalf("MarshalBuffer(): expected: {\"v\":23}{\"v\":4}, got %s", a)
+	}
+}
+
+type failingStreamer struct{}
+
+func (failingStreamer) MarshalToJSON(w io.Writer) {
+	WriteByte(w, '[')
+	panic(catch.Error("marshal failed"))
+}
+
+func TestMarshalAppend(t *testing.T) {
+	bs, err := MarshalAppend([]byte("Content-Length: 8\r\n\r\n"), &ts{v: time.Millisecond * 23})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := string(bs); a != "Content-Length: 8\r\n\r\n{\"v\":23}" {
+		t.Fatalf("MarshalAppend(): unexpected result %q", a)
+	}
+	bs, err = MarshalAppend([]byte("x"), failingStreamer{})
+	if err == nil || err.Error() != "marshal failed" || string(bs) != "x" {
+		t.Fatalf("expected marshal error and unchanged buffer, got %q, %v", bs, err)
+	}
+}
+
+type errorStreamerFunc func(w io.Writer) error
+
+func (f errorStreamerFunc) MarshalToJSONE(w io.Writer) error {
+	return f(w)
+}
+
+func TestAsStreamer(t *testing.T) {
+	bs, err := Marshal(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		_, err := w.Write([]byte(`"ok"`))
+		return err
+	})))
+	if err != nil || string(bs) != `"ok"` {
+		t.Fatalf(`expected "ok", got %s, %v`, bs, err)
+	}
+	err = MarshalWriter(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		_, err := w.Write([]byte(`"ok"`))
+		return err
+	})), failingWriter{})
+	if err == nil || err.Error() != "write failed" {
+		t.Fatalf("expected write error, got %v", err)
+	}
+}
+
+type optionsStreamer struct{}
+
+func (optionsStreamer) MarshalToJSON(w io.Writer) {
+	o := NewObjectWriter(w)
+	o.String("html", "<a&b>")
+	o.Null("n")
+	o.Field("a")
+	a := NewArrayWriter(w)
+	a.Int(1)
+	a.Streamer(&ts{v: time.Millisecond})
+	a.Close()
+	o.Close()
+}
+
+func TestMarshal_options(t *testing.T) {
+	bs, err := Marshal(optionsStreamer{})
+	if ex := `{"html":"<a&b>","n":null,"a":[1,{"v":1}]}`; err != nil || string(bs) != ex {
+		t.Fatalf("expected %s, got %s, %v", ex, bs, err)
+	}
+	bs, err = Marshal(optionsStreamer{}, Indent("", "  "), EscapeHTML(), OmitNull())
+	ex := "{\n  \"html\": \"\\u003ca\\u0026b\\u003e\",\n  \"a\": [\n    1,\n    {\n      \"v\": 1\n    }\n  ]\n}"
+	if err != nil || string(bs) != ex {
+		t.Fatalf("expected %s, got %s, %v", ex, bs, err)
+	}
+}
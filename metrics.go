@@ -0,0 +1,155 @@
+package jsonstream
+
+import (
+	"expvar"
+	"io"
+	"time"
+
+	"github.com/tada/catch"
+)
+
+// Metrics receives the measurements of the values that are decoded and encoded by a Meter. Implementations must be
+// safe for concurrent use when the Meter is shared between goroutines.
+type Metrics interface {
+	// Decoded is called when a value has been decoded with the number of bytes and of tokens that were read, the time
+	// it took, and the error that the decode returned.
+	Decoded(bytes, tokens int64, elapsed time.Duration, err error)
+
+	// Encoded is called when a value has been encoded with the number of bytes that were written, the time it took,
+	// and the error that the encode returned.
+	Encoded(bytes int64, elapsed time.Duration, err error)
+}
+
+// NopMetrics returns a Metrics that discards all measurements.
+func NopMetrics() Metrics {
+	return nopMetrics{}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Decoded(int64, int64, time.Duration, error) {}
+
+func (nopMetrics) Encoded(int64, time.Duration, error) {}
+
+// ExpvarMetrics is a Metrics that accumulates the measurements in expvar counters. All counters only increase, so
+// they can be scraped as Prometheus counters, e.g. the rate of DecodeNanos over the rate of DecodedValues gives the
+// average decode time.
+type ExpvarMetrics struct {
+	DecodedValues expvar.Int
+	DecodeErrors  expvar.Int
+	DecodedBytes  expvar.Int
+	DecodedTokens expvar.Int
+	DecodeNanos   expvar.Int
+	EncodedValues expvar.Int
+	EncodeErrors  expvar.Int
+	EncodedBytes  expvar.Int
+	EncodeNanos   expvar.Int
+}
+
+// NewExpvarMetrics creates a new ExpvarMetrics and publishes its counters as an expvar.Map with the given name, e.g.
+// one name per endpoint. The keys of the map are decoded_values, decode_errors, decoded_bytes, decoded_tokens,
+// decode_nanos, encoded_values, encode_errors, encoded_bytes, and encode_nanos. Like expvar.Publish, the function
+// panics if the name is already in use.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	e := &ExpvarMetrics{}
+	m := expvar.NewMap(name)
+	m.Set("decoded_values", &e.DecodedValues)
+	m.Set("decode_errors", &e.DecodeErrors)
+	m.Set("decoded_bytes", &e.DecodedBytes)
+	m.Set("decoded_tokens", &e.DecodedTokens)
+	m.Set("decode_nanos", &e.DecodeNanos)
+	m.Set("encoded_values", &e.EncodedValues)
+	m.Set("encode_errors", &e.EncodeErrors)
+	m.Set("encoded_bytes", &e.EncodedBytes)
+	m.Set("encode_nanos", &e.EncodeNanos)
+	return e
+}
+
+// Decoded adds the measurements of a decoded value to the counters.
+func (e *ExpvarMetrics) Decoded(bytes, tokens int64, elapsed time.Duration, err error) {
+	e.DecodedValues.Add(1)
+	if err != nil {
+		e.DecodeErrors.Add(1)
+	}
+	e.DecodedBytes.Add(bytes)
+	e.DecodedTokens.Add(tokens)
+	e.DecodeNanos.Add(int64(elapsed))
+}
+
+// Encoded adds the measurements of an encoded value to the counters.
+func (e *ExpvarMetrics) Encoded(bytes int64, elapsed time.Duration, err error) {
+	e.EncodedValues.Add(1)
+	if err != nil {
+		e.EncodeErrors.Add(1)
+	}
+	e.EncodedBytes.Add(bytes)
+	e.EncodeNanos.Add(int64(elapsed))
+}
+
+// A Meter decodes and encodes values in the same way as the Unmarshal and Marshal functions of this package and
+// reports the measurements of each call to its Metrics.
+type Meter struct {
+	m Metrics
+}
+
+// NewMeter creates a new Meter that reports to the given Metrics, or to NopMetrics() if the given Metrics is nil.
+func NewMeter(m Metrics) *Meter {
+	if m == nil {
+		m = NopMetrics()
+	}
+	return &Meter{m: m}
+}
+
+// Unmarshal is like the Unmarshal function of this package but reports the measurements of the call.
+func (mt *Meter) Unmarshal(c Consumer, bs []byte) error {
+	start := time.Now()
+	js := NewBytesDecoder(bs)
+	err := catch.Do(func() {
+		js.ReadConsumer(c)
+	})
+	mt.m.Decoded(int64(len(bs)), js.(*decoder).tokenCount(), time.Since(start), err)
+	return err
+}
+
+// UnmarshalReader is like the UnmarshalReader function of this package but reports the measurements of the call. The
+// number of bytes reported is the number of bytes read from the reader, which includes any bytes that the decoder read
+// ahead of the value.
+func (mt *Meter) UnmarshalReader(c Consumer, r io.Reader) error {
+	cr := &meteredReader{r: r}
+	start := time.Now()
+	js := NewDecoder(cr)
+	err := catch.Do(func() {
+		js.ReadConsumer(c)
+	})
+	mt.m.Decoded(cr.n, js.(*decoder).tokenCount(), time.Since(start), err)
+	return err
+}
+
+// Marshal is like the Marshal function of this package but reports the measurements of the call.
+func (mt *Meter) Marshal(s Streamer, options ...MarshalOption) ([]byte, error) {
+	start := time.Now()
+	bs, err := Marshal(s, options...)
+	mt.m.Encoded(int64(len(bs)), time.Since(start), err)
+	return bs, err
+}
+
+// MarshalWriter is like the MarshalWriter function of this package but reports the measurements of the call.
+func (mt *Meter) MarshalWriter(s Streamer, w io.Writer, options ...MarshalOption) error {
+	cw := &CountingWriter{W: w}
+	start := time.Now()
+	err := MarshalWriter(s, cw, options...)
+	mt.m.Encoded(cw.N, time.Since(start), err)
+	return err
+}
+
+// meteredReader counts the bytes read from a reader.
+type meteredReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *meteredReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
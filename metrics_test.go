@@ -0,0 +1,114 @@
+package jsonstream
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordedMetrics struct {
+	decoded []int64
+	tokens  []int64
+	encoded []int64
+	errors  int
+}
+
+func (r *recordedMetrics) Decoded(bytes, tokens int64, elapsed time.Duration, err error) {
+	r.decoded = append(r.decoded, bytes)
+	r.tokens = append(r.tokens, tokens)
+	if err != nil {
+		r.errors++
+	}
+}
+
+func (r *recordedMetrics) Encoded(bytes int64, elapsed time.Duration, err error) {
+	r.encoded = append(r.encoded, bytes)
+	if err != nil {
+		r.errors++
+	}
+}
+
+func TestMeter(t *testing.T) {
+	r := &recordedMetrics{}
+	mt := NewMeter(r)
+	tv := ts{}
+	if err := mt.Unmarshal(&tv, []byte(`{"v":38}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mt.UnmarshalReader(&tv, strings.NewReader(`{"v":`)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := mt.Marshal(&tv); err != nil {
+		t.Fatal(err)
+	}
+	b := bytes.Buffer{}
+	if err := mt.MarshalWriter(&tv, &b); err != nil {
+		t.Fatal(err)
+	}
+	err := mt.MarshalWriter(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		return errors.New("failed")
+	})), &b)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(r.decoded) != 2 || r.decoded[0] != 8 || r.decoded[1] != 5 {
+		t.Errorf("unexpected decoded bytes %v", r.decoded)
+	}
+	if len(r.tokens) != 2 || r.tokens[0] != 4 || r.tokens[1] != 2 {
+		t.Errorf("unexpected decoded tokens %v", r.tokens)
+	}
+	if len(r.encoded) != 3 || r.encoded[0] != 8 || r.encoded[1] != 8 || r.encoded[2] != 0 {
+		t.Errorf("unexpected encoded bytes %v", r.encoded)
+	}
+	if r.errors != 2 {
+		t.Errorf("expected 2 errors, got %d", r.errors)
+	}
+}
+
+func TestNopMetrics(t *testing.T) {
+	mt := NewMeter(nil)
+	if mt.m != NopMetrics() {
+		t.Fatalf("expected NopMetrics, got %T", mt.m)
+	}
+	tv := ts{}
+	if err := mt.Unmarshal(&tv, []byte(`{"v":1}`)); err != nil || tv.v != time.Millisecond {
+		t.Fatalf("expected 1ms, got %s, %v", tv.v, err)
+	}
+	if bs, err := mt.Marshal(&tv); err != nil || string(bs) != `{"v":1}` {
+		t.Fatalf("expected {\"v\":1}, got %s, %v", bs, err)
+	}
+}
+
+func TestNewExpvarMetrics(t *testing.T) {
+	e := NewExpvarMetrics("jsonstream_test")
+	mt := NewMeter(e)
+	tv := ts{}
+	if err := mt.Unmarshal(&tv, []byte(`{"v":38}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mt.Unmarshal(&tv, []byte(`[`)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := mt.Marshal(&tv); err != nil {
+		t.Fatal(err)
+	}
+	_, err := mt.Marshal(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		return errors.New("failed")
+	})))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	m := expvar.Get("jsonstream_test").(*expvar.Map)
+	for k, ex := range map[string]string{
+		"decoded_values": "2", "decode_errors": "1", "decoded_bytes": "9", "decoded_tokens": "5",
+		"encoded_values": "2", "encode_errors": "1", "encoded_bytes": "8",
+	} {
+		if a := m.Get(k).String(); a != ex {
+			t.Errorf("expected %s to be %s, got %s", k, ex, a)
+		}
+	}
+}
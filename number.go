@@ -0,0 +1,176 @@
+package jsonstream
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// parseInt parses the bytes of a JSON number as an int64. Numbers with at most 18 digits are parsed directly from
+// the bytes. Other numbers are parsed using strconv.ParseInt.
+func parseInt(b []byte) (int64, error) {
+	i := 0
+	neg := len(b) > 0 && b[0] == '-'
+	if neg {
+		i++
+	}
+	if n := len(b) - i; n == 0 || n > 18 {
+		return strconv.ParseInt(string(b), 10, 64)
+	}
+	var v int64
+	for ; i < len(b); i++ {
+		c := b[i]
+		if !isDigit(c) {
+			return strconv.ParseInt(string(b), 10, 64)
+		}
+		v = v*10 + int64(c-'0')
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// parseFloat parses the bytes of a JSON number as a float64. Numbers with a mantissa of at most 15 digits and a small
+// exponent are converted exactly using one float64 multiplication or division. Other numbers are parsed using
+// strconv.ParseFloat.
+func parseFloat(b []byte) (float64, error) {
+	if f, ok := parseFloatExact(b); ok {
+		return f, nil
+	}
+	return strconv.ParseFloat(string(b), 64)
+}
+
+func parseFloatExact(b []byte) (float64, bool) {
+	i := 0
+	neg := len(b) > 0 && b[0] == '-'
+	if neg {
+		i++
+	}
+	var mant int64
+	digits := 0
+	exp := 0
+	for ; i < len(b) && isDigit(b[i]); i++ {
+		mant = mant*10 + int64(b[i]-'0')
+		digits++
+	}
+	if i < len(b) && b[i] == '.' {
+		for i++; i < len(b) && isDigit(b[i]); i++ {
+			mant = mant*10 + int64(b[i]-'0')
+			digits++
+			exp--
+		}
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		eneg := false
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			eneg = b[i] == '-'
+			i++
+		}
+		e := 0
+		for ; i < len(b) && isDigit(b[i]); i++ {
+			if e < 1000 {
+				e = e*10 + int(b[i]-'0')
+			}
+		}
+		if eneg {
+			e = -e
+		}
+		exp += e
+	}
+	if i != len(b) || digits == 0 || digits > 15 || exp < -22 || exp > 22 {
+		return 0, false
+	}
+	// the powers of ten up to 1e22 are exactly representable as a float64, and math.Pow10 returns them exactly
+	f := float64(mant)
+	if exp < 0 {
+		f /= math.Pow10(-exp)
+	} else {
+		f *= math.Pow10(exp)
+	}
+	if neg {
+		f = -f
+	}
+	return f, true
+}
+
+// isIntegral returns true if the bytes of a JSON number contain neither a fraction nor an exponent part, and aren't
+// one of the non-finite literals of the AllowNonFinite option.
+func isIntegral(b []byte) bool {
+	for _, c := range b {
+		switch c {
+		case '.', 'e', 'E', 'N', 'I':
+			return false
+		}
+	}
+	return true
+}
+
+// isNumber returns true if the given string is a valid JSON number.
+func isNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && isDigit(s[i]):
+		i = skipDigits(s, i)
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		j := skipDigits(s, i+1)
+		if j == i+1 {
+			return false
+		}
+		i = j
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		j := skipDigits(s, i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+	return i == len(s)
+}
+
+// skipDigits returns the index of the first byte at or after i in s that isn't a digit.
+func skipDigits(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// isExactFloat returns true if f is exactly equal to the value of the given bytes of a JSON number.
+func isExactFloat(b []byte, f float64) bool {
+	if math.IsInf(f, 0) {
+		return false
+	}
+	if f == 0 {
+		// avoid evaluating a huge exponent when all digits are zero
+		for _, c := range b {
+			switch {
+			case c == 'e' || c == 'E':
+				return true
+			case '1' <= c && c <= '9':
+				return false
+			}
+		}
+		return true
+	}
+	r, ok := new(big.Rat).SetString(string(b))
+	if !ok {
+		return false
+	}
+	_, exact := r.Float64()
+	return exact
+}
@@ -0,0 +1,93 @@
+package jsonstream
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestParseInt(t *testing.T) {
+	inputs := []string{`0`, `-0`, `1`, `-1`, `123456789012345678`, `-123456789012345678`, `9223372036854775807`,
+		`-9223372036854775808`, `9223372036854775808`, `1.0`, `1e2`, `-`}
+	for _, input := range inputs {
+		ex, exErr := strconv.ParseInt(input, 10, 64)
+		ac, acErr := parseInt([]byte(input))
+		if ex != ac || (exErr == nil) != (acErr == nil) {
+			t.Errorf("%q: expected %d, %v, got %d, %v", input, ex, exErr, ac, acErr)
+		}
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	inputs := []string{`0`, `-0`, `1`, `-1.5`, `0.1`, `3.14159`, `123456789012345`, `1234567890123456789`,
+		`1e22`, `1e23`, `1.5e-22`, `1.5e-23`, `-0.25e10`, `1E+2`, `3e-4`, `2.2250738585072014e-308`, `1e400`,
+		`0.000000000000000000000000000001`}
+	for _, input := range inputs {
+		ex, exErr := strconv.ParseFloat(input, 64)
+		ac, acErr := parseFloat([]byte(input))
+		if math.Float64bits(ex) != math.Float64bits(ac) || (exErr == nil) != (acErr == nil) {
+			t.Errorf("%q: expected %g, %v, got %g, %v", input, ex, exErr, ac, acErr)
+		}
+	}
+}
+
+func TestNewBytesDecoder_numbers(t *testing.T) {
+	js := NewBytesDecoder([]byte(`[42, -1.25, 7, 0.5] 1.5`))
+	err := catch.Do(func() {
+		js.ReadDelim('[')
+		if i := js.ReadInt(); i != 42 {
+			t.Fatalf("expected 42, got %d", i)
+		}
+		if f := js.ReadFloat(); f != -1.25 {
+			t.Fatalf("expected -1.25, got %g", f)
+		}
+		if i, ok := js.ReadIntOrEnd(']'); !(ok && i == 7) {
+			t.Fatalf("expected 7, got %d", i)
+		}
+		if f, ok := js.ReadFloatOrEnd(']'); !(ok && f == 0.5) {
+			t.Fatalf("expected 0.5, got %g", f)
+		}
+		if _, ok := js.ReadFloatOrEnd(']'); ok {
+			t.Fatal("expected end")
+		}
+		js.ReadInt()
+	})
+	if err == nil || err.Error() != "expected an integer, got json.Number 1.5" {
+		t.Fatalf("expected integer error, got %v", err)
+	}
+}
+
+func TestIsNumber(t *testing.T) {
+	for _, input := range []string{`0`, `-0`, `12`, `1.5`, `-0.5e10`, `1E+2`, `1e-2`} {
+		if !isNumber(input) {
+			t.Errorf("expected %q to be a number", input)
+		}
+	}
+	for _, input := range []string{``, `-`, `01`, `+1`, `1.`, `.5`, `1e`, `1e+`, `0x10`, `NaN`, `1 `} {
+		if isNumber(input) {
+			t.Errorf("expected %q not to be a number", input)
+		}
+	}
+}
+
+func TestIsExactFloat(t *testing.T) {
+	inputs := map[string]bool{
+		`0`:                true,
+		`-0.0e99999999999`: true,
+		`1e-400`:           false,
+		`0.5`:              true,
+		`0.1`:              false,
+		`9007199254740992`: true,
+		`9007199254740993`: false,
+		`1.25e2`:           true,
+		`1e23`:             false,
+	}
+	for input, ex := range inputs {
+		f, _ := strconv.ParseFloat(input, 64)
+		if ac := isExactFloat([]byte(input), f); ac != ex {
+			t.Errorf("%q: expected %t, got %t", input, ex, ac)
+		}
+	}
+}
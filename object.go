@@ -0,0 +1,181 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tada/catch"
+)
+
+// An ObjectSchema is a Consumer that reads a JSON object into variables that are bound to its keys. It is created
+// with the Object function and then configured using method chaining:
+//
+//	jsonstream.Object().String("name", &s).Int("count", &n).Consumer("child", c).Required("name")
+//
+// Keys that are not bound are skipped. Since the schema writes to the bound variables, it must not be used by more
+// than one decoder at a time.
+type ObjectSchema struct {
+	fields   map[string]func(Decoder)
+	index    map[string]int
+	bindings []*objectBinding
+	required []string
+}
+
+type objectBinding struct {
+	key string
+
+	// set returns a function that assigns the given value to the bound variable or nil if the value has the wrong type
+	set func(value interface{}) func()
+
+	// def assigns the default value, or is nil
+	def func()
+
+	seen bool
+}
+
+// Object creates a new ObjectSchema without any bound keys.
+func Object() *ObjectSchema {
+	return &ObjectSchema{fields: make(map[string]func(Decoder)), index: make(map[string]int)}
+}
+
+// Bool binds the given key to a boolean variable.
+func (o *ObjectSchema) Bool(key string, v *bool) *ObjectSchema {
+	o.Field(key, func(js Decoder) { *v = js.ReadBool() })
+	o.binding(key).set = func(value interface{}) func() {
+		if b, ok := value.(bool); ok {
+			return func() { *v = b }
+		}
+		return nil
+	}
+	return o
+}
+
+// Consumer binds the given key to a Consumer.
+func (o *ObjectSchema) Consumer(key string, c Consumer) *ObjectSchema {
+	return o.Field(key, func(js Decoder) { js.ReadConsumer(c) })
+}
+
+// Default declares a value that is assigned to the variable bound to the given key when the key is missing or when
+// its value is null. The value must be of the same type as the variable, except that an int can be given for an
+// integer or a float variable. The function panics if the key isn't bound using Bool, Float, Int, or String, or
+// if the value has the wrong type.
+func (o *ObjectSchema) Default(key string, value interface{}) *ObjectSchema {
+	b := o.binding(key)
+	var def func()
+	if b.set != nil {
+		def = b.set(value)
+	}
+	if def == nil {
+		panic(fmt.Errorf("default value %v of type %T cannot be assigned to the %q variable", value, value, key))
+	}
+	b.def = def
+	return o
+}
+
+// DefaultFunc declares a function that is called when the given key is missing or when its value is null. The
+// function panics if the key isn't bound.
+func (o *ObjectSchema) DefaultFunc(key string, f func()) *ObjectSchema {
+	o.binding(key).def = f
+	return o
+}
+
+// Field binds the given key to a function that reads the value of the key from the decoder.
+func (o *ObjectSchema) Field(key string, f func(Decoder)) *ObjectSchema {
+	i, ok := o.index[key]
+	if !ok {
+		i = len(o.bindings)
+		o.index[key] = i
+		o.bindings = append(o.bindings, &objectBinding{key: key})
+	}
+	b := o.bindings[i]
+	b.set = nil
+	o.fields[key] = func(js Decoder) {
+		b.seen = true
+		f(js)
+		if b.def != nil && js.WasNull() {
+			b.def()
+		}
+	}
+	return o
+}
+
+// Float binds the given key to a float variable.
+func (o *ObjectSchema) Float(key string, v *float64) *ObjectSchema {
+	o.Field(key, func(js Decoder) { *v = js.ReadFloat() })
+	o.binding(key).set = func(value interface{}) func() {
+		switch f := value.(type) {
+		case float64:
+			return func() { *v = f }
+		case int:
+			return func() { *v = float64(f) }
+		}
+		return nil
+	}
+	return o
+}
+
+// Int binds the given key to an integer variable.
+func (o *ObjectSchema) Int(key string, v *int64) *ObjectSchema {
+	o.Field(key, func(js Decoder) { *v = js.ReadInt() })
+	o.binding(key).set = func(value interface{}) func() {
+		switch i := value.(type) {
+		case int64:
+			return func() { *v = i }
+		case int:
+			return func() { *v = int64(i) }
+		}
+		return nil
+	}
+	return o
+}
+
+// Required declares that the given keys must be present in the object. A panic with a catch.Error is raised by
+// UnmarshalFromJSON when a required key is missing. A required key that isn't bound is skipped when found.
+func (o *ObjectSchema) Required(keys ...string) *ObjectSchema {
+	for _, key := range keys {
+		if _, ok := o.index[key]; !ok {
+			o.Field(key, func(js Decoder) { js.Skip() })
+		}
+	}
+	o.required = append(o.required, keys...)
+	return o
+}
+
+// String binds the given key to a string variable.
+func (o *ObjectSchema) String(key string, v *string) *ObjectSchema {
+	o.Field(key, func(js Decoder) { *v = js.ReadString() })
+	o.binding(key).set = func(value interface{}) func() {
+		if s, ok := value.(string); ok {
+			return func() { *v = s }
+		}
+		return nil
+	}
+	return o
+}
+
+// UnmarshalFromJSON reads the object that starts with the given firstToken and assigns the bound variables.
+func (o *ObjectSchema) UnmarshalFromJSON(js Decoder, firstToken json.Token) {
+	AssertDelim(firstToken, '{')
+	for _, b := range o.bindings {
+		b.seen = false
+	}
+	js.ReadObjectFields(o.fields, nil)
+	for _, key := range o.required {
+		if !o.bindings[o.index[key]].seen {
+			panic(catch.Error("missing required field %q", key))
+		}
+	}
+	for _, b := range o.bindings {
+		if !b.seen && b.def != nil {
+			b.def()
+		}
+	}
+}
+
+func (o *ObjectSchema) binding(key string) *objectBinding {
+	i, ok := o.index[key]
+	if !ok {
+		panic(fmt.Errorf("key %q is not bound", key))
+	}
+	return o.bindings[i]
+}
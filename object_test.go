@@ -0,0 +1,64 @@
+package jsonstream
+
+import "testing"
+
+func TestObject(t *testing.T) {
+	var (
+		name  string
+		count int64
+		ratio float64
+		ok    bool
+		child ts
+	)
+	o := Object().String("name", &name).Int("count", &count).Float("ratio", &ratio).Bool("ok", &ok).
+		Consumer("child", &child).Required("name", "id")
+	err := Unmarshal(o, []byte(`{"id":1,"name":"n","count":3,"ratio":0.5,"ok":true,"child":{"v":2},"extra":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(name == "n" && count == 3 && ratio == 0.5 && ok && child.v == 2000000) {
+		t.Fatalf("unexpected values %q, %d, %g, %t, %s", name, count, ratio, ok, child.v)
+	}
+	err = Unmarshal(o, []byte(`{"id":1}`))
+	if err == nil || err.Error() != `missing required field "name"` {
+		t.Fatalf("expected missing field error, got %v", err)
+	}
+}
+
+func TestObject_defaults(t *testing.T) {
+	var (
+		name    string
+		count   int64
+		ratio   float64
+		ok      bool
+		called  bool
+		nothing string
+	)
+	o := Object().String("name", &name).Int("count", &count).Float("ratio", &ratio).Bool("ok", &ok).
+		Consumer("child", &ts{}).String("nothing", &nothing).
+		Default("name", "anonymous").Default("count", 10).Default("ratio", 0.5).Default("ok", true).
+		DefaultFunc("child", func() { called = true })
+	if err := Unmarshal(o, []byte(`{"name":null,"ratio":null,"ok":false,"child":null,"nothing":null}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !(name == "anonymous" && count == 10 && ratio == 0.5 && !ok && called && nothing == "") {
+		t.Fatalf("unexpected values %q, %d, %g, %t, %t, %q", name, count, ratio, ok, called, nothing)
+	}
+}
+
+func TestObject_defaultErrors(t *testing.T) {
+	for _, f := range []func(){
+		func() { Object().Default("x", 1) },
+		func() { Object().Consumer("x", &ts{}).Default("x", 1) },
+		func() { var s string; Object().String("x", &s).Default("x", 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,421 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"hash"
+	"io"
+	"time"
+)
+
+// A DecoderOption configures a Decoder created by NewDecoder or NewBytesDecoder.
+type DecoderOption func(*decoderOptions)
+
+type decoderOptions struct {
+	keys          *InternTable
+	allocator     Allocator
+	inexact       func(json.Number)
+	unknownField  func(path, key string, raw []byte)
+	captureRaw    func(path string, raw []byte)
+	hooks         []DecodeHook
+	timeLocation  *time.Location
+	hash          hash.Hash
+	numbers       NumberMode
+	bufferSize    int
+	exactFloats   bool
+	strictStrings bool
+	uniqueKeys    bool
+	objectsOnly   bool
+	escapes       EscapeMode
+	rejectNaive   bool
+	nonFinite     bool
+	relaxed       bool
+	allowComments bool
+	coerceScalars bool
+	debug         bool
+}
+
+// A MarshalOption configures the output of Marshal and the other functions that stream output. The options are
+// propagated to the write helpers of this package, such as WriteString, through the io.Writer that is passed to the
+// MarshalToJSON method of the Streamer, so a Streamer that uses those helpers doesn't need to know about them.
+type MarshalOption func(*marshalOptions)
+
+type marshalOptions struct {
+	prefix      string
+	indent      string
+	escapeHTML  bool
+	escapeSlash bool
+	omitNull    bool
+	sortKeys    bool
+	durationMs  bool
+	hash        hash.Hash
+	inline      int
+	flushBytes  int
+	flushValues int
+	flushHook   func() error
+	flusher     *flusher
+}
+
+// optionsWriter is the io.Writer that carries the marshal options to the write helpers.
+type optionsWriter struct {
+	io.Writer
+	*marshalOptions
+}
+
+// newOptionsWriter returns a writer that carries the given options, or the given writer when there are no options.
+func newOptionsWriter(w io.Writer, options []MarshalOption) io.Writer {
+	if len(options) == 0 {
+		return w
+	}
+	o := &marshalOptions{}
+	for _, option := range options {
+		option(o)
+	}
+	if o.flushBytes > 0 || o.flushValues > 0 || o.flushHook != nil {
+		o.flusher = newFlusher(w, o)
+		w = o.flusher
+	}
+	if o.hash != nil {
+		w = io.MultiWriter(w, o.hash)
+	}
+	if o.prefix != "" || o.indent != "" {
+		w = &indentWriter{w: w, prefix: o.prefix, indent: o.indent, inline: o.inline}
+	}
+	return &optionsWriter{Writer: w, marshalOptions: o}
+}
+
+// optionsOf returns the marshal options carried by the given writer, or the default options when it carries none. The
+// default options are allocated on the stack of the caller since optionsOf is inlined.
+func optionsOf(w io.Writer) *marshalOptions {
+	if ow, ok := w.(*optionsWriter); ok {
+		return ow.marshalOptions
+	}
+	return &marshalOptions{}
+}
+
+// EscapeHTML makes WriteString escape the characters '<', '>', and '&' so that the output is safe to embed in HTML.
+// The line and paragraph separators U+2028 and U+2029 are escaped too.
+func EscapeHTML() MarshalOption {
+	return func(o *marshalOptions) {
+		o.escapeHTML = true
+	}
+}
+
+// EscapeSlash makes WriteString escape the character '/' as "\/", e.g. for output that is embedded in a <script>
+// element, where "</" must not occur, or that is read by legacy parsers that require it.
+func EscapeSlash() MarshalOption {
+	return func(o *marshalOptions) {
+		o.escapeSlash = true
+	}
+}
+
+// Indent makes the output indented in the same way as json.MarshalIndent does it. Each element of an array or an
+// object begins on a new line that starts with the given prefix followed by one or more copies of the given indent
+// according to the nesting depth.
+func Indent(prefix, indent string) MarshalOption {
+	return func(o *marshalOptions) {
+		o.prefix = prefix
+		o.indent = indent
+	}
+}
+
+// InlineSmall makes the Indent option write arrays and objects on one line when that line, from the opening to the
+// closing delimiter, is at most n bytes long. Elements on such a line are separated by ", " and keys by ": ", e.g.
+// {"x": 1, "y": [2, 3]}. The option is typically used for configuration files that are maintained by humans.
+func InlineSmall(n int) MarshalOption {
+	return func(o *marshalOptions) {
+		o.inline = n
+	}
+}
+
+// OmitNull makes the Null method of the ObjectWriter omit the field instead of writing a null value.
+func OmitNull() MarshalOption {
+	return func(o *marshalOptions) {
+		o.omitNull = true
+	}
+}
+
+// SortMapKeys makes WriteStreamerMap write the entries of a map in the order of their keys, as encoding/json does it,
+// instead of in the random order of map iteration, so that the output is deterministic.
+func SortMapKeys() MarshalOption {
+	return func(o *marshalOptions) {
+		o.sortKeys = true
+	}
+}
+
+// DurationMillis makes WriteDuration write durations as an integer number of milliseconds instead of as a string.
+func DurationMillis() MarshalOption {
+	return func(o *marshalOptions) {
+		o.durationMs = true
+	}
+}
+
+// HashOutput makes all output bytes also be written to the given hash, so that a digest of the output, e.g. for an
+// ETag or a signature, is computed in the same pass as the output is produced.
+func HashOutput(h hash.Hash) MarshalOption {
+	return func(o *marshalOptions) {
+		o.hash = h
+	}
+}
+
+// FlushEvery makes the output be delivered, using the same mechanism as the Flush function, whenever at least the
+// given number of bytes, or the given number of values, has been written since it was last delivered. The values are
+// the elements of arrays and the fields of objects that are written using an ArrayWriter or an ObjectWriter, at any
+// depth, and a flush caused by them always happens after a complete value. A threshold of zero is disabled. The
+// option gives latency-sensitive streams, such as server-sent events and chunked HTTP responses, a predictable
+// delivery of partial results.
+func FlushEvery(bytes, values int) MarshalOption {
+	return func(o *marshalOptions) {
+		o.flushBytes = bytes
+		o.flushValues = values
+	}
+}
+
+// FlushHook makes the Flush function and the FlushEvery option call the given function instead of the Flush method of
+// the writer. The function typically flushes a chain of writers or signals a consumer that data is available.
+func FlushHook(f func() error) MarshalOption {
+	return func(o *marshalOptions) {
+		o.flushHook = f
+	}
+}
+
+func newDecoderOptions(options []DecoderOption) *decoderOptions {
+	o := &decoderOptions{}
+	for _, option := range options {
+		option(o)
+	}
+	return o
+}
+
+// checksSyntax returns true if an option is given that checks or relaxes the syntax in a way that a json.Decoder
+// cannot.
+func (o *decoderOptions) checksSyntax() bool {
+	return o.strictStrings || o.uniqueKeys || o.objectsOnly || o.escapes != EscapesDecode || o.nonFinite || o.relaxed ||
+		o.allowComments
+}
+
+// scansInput returns true if an option is given that requires NewDecoder to scan its input rather than to read it
+// through a json.Decoder.
+func (o *decoderOptions) scansInput() bool {
+	return o.checksSyntax() || o.keys != nil
+}
+
+// InternKeys makes the decoder use the given table to intern the strings that it reads as object keys. The same
+// table can be shared by any number of decoders. A decoder created by NewDecoder with this option scans its input in
+// the same way as NewBytesDecoder does, rather than reading it through a json.Decoder, so that the keys can be
+// interned before a string is allocated for them. The JSONDecoder method of such a decoder returns nil.
+func InternKeys(t *InternTable) DecoderOption {
+	return func(o *decoderOptions) {
+		o.keys = t
+	}
+}
+
+// BufferSize makes the decoder read its input through a bufio.Reader of the given size. A large buffer reduces the
+// number of reads that are made on slow sources like network sockets. No buffer is added when the io.Reader already
+// is a bufio.Reader of at least the given size. A size of zero, which is the default, disables the extra buffering
+// so that the only buffer used is the one internal to the json.Decoder.
+//
+// The option is only effective for decoders created with NewDecoder.
+func BufferSize(size int) DecoderOption {
+	return func(o *decoderOptions) {
+		o.bufferSize = size
+	}
+}
+
+// Allocate makes ReadAny allocate the slices and maps of the values that it returns using the given allocator, e.g.
+// an Arena. The allocator is also returned by the Allocator method of the decoder.
+func Allocate(a Allocator) DecoderOption {
+	return func(o *decoderOptions) {
+		o.allocator = a
+	}
+}
+
+// ExactFloats makes ReadFloat and ReadFloatOrEnd verify that the numbers they read can be represented exactly as a
+// float64, e.g. to prevent that 64-bit identifiers are silently rounded. An inexact number is passed to the given
+// function, or, when the function is nil, causes a panic with a catch.Error. ReadInt and ReadIntOrEnd need no such
+// option since they always fail on numbers that don't fit an int64.
+func ExactFloats(inexact func(n json.Number)) DecoderOption {
+	return func(o *decoderOptions) {
+		o.exactFloats = true
+		o.inexact = inexact
+	}
+}
+
+// Numbers makes ReadAny represent numbers as given by the mode unless the call is given the AnyNumbers option, so
+// that the precision policy of a document is set once rather than per call.
+func Numbers(mode NumberMode) DecoderOption {
+	return func(o *decoderOptions) {
+		o.numbers = mode
+	}
+}
+
+// AllowComments makes the decoder skip // line comments and /* block comments */ like whitespace, so that JSONC files,
+// such as the configuration files of many editors, can be read. See ParseJSONC for a way to keep the comments.
+//
+// A decoder created with NewDecoder scans its input directly when the option is given (see NewDecoder).
+func AllowComments() DecoderOption {
+	return func(o *decoderOptions) {
+		o.allowComments = true
+	}
+}
+
+// StrictStrings makes the decoder reject strings that contain invalid UTF-8 or escaped surrogates that are not part of
+// a surrogate pair. Without this option, such bytes and surrogates are replaced by the Unicode replacement character.
+//
+// A decoder created with NewDecoder scans its input directly when the option is given (see NewDecoder).
+func StrictStrings() DecoderOption {
+	return func(o *decoderOptions) {
+		o.strictStrings = true
+	}
+}
+
+// AllowNonFinite makes the decoder accept the literals NaN, Infinity, and -Infinity, which are emitted by e.g. the
+// json module of Python, as numbers. ReadFloat and ReadAny read them as the corresponding float64 values. Other reads
+// of numbers fail on them, and ReadRawAppend and the functions that copy JSON retain them as is.
+//
+// A decoder created with NewDecoder scans its input directly when the option is given (see NewDecoder).
+func AllowNonFinite() DecoderOption {
+	return func(o *decoderOptions) {
+		o.nonFinite = true
+	}
+}
+
+// Relaxed makes the decoder accept strings in single quotes and object keys that are bare identifiers, as in
+// JavaScript object literals, e.g. {name: 'O\'Brien'}. An identifier consists of ASCII letters, digits, '_', and '$',
+// and doesn't start with a digit. The escape \' is accepted in both kinds of strings. ReadRawAppend and the functions
+// that copy JSON retain the relaxed syntax as is.
+//
+// A decoder created with NewDecoder scans its input directly when the option is given (see NewDecoder).
+func Relaxed() DecoderOption {
+	return func(o *decoderOptions) {
+		o.relaxed = true
+	}
+}
+
+// Strict makes the decoder reject input that RFC 8259 permits implementations to reject, so that a service can
+// guarantee that what it passes on is clean JSON. In addition to what StrictStrings rejects, an object must not have
+// the same key more than once. Numbers with leading zeros and other deviations from the grammar are always rejected.
+//
+// A decoder created with NewDecoder scans its input directly when the option is given (see NewDecoder).
+func Strict() DecoderOption {
+	return func(o *decoderOptions) {
+		o.strictStrings = true
+		o.uniqueKeys = true
+	}
+}
+
+// ObjectsOnly makes the decoder reject top level values that aren't objects, e.g. a lone scalar where a request body
+// is expected.
+//
+// A decoder created with NewDecoder scans its input directly when the option is given (see NewDecoder).
+func ObjectsOnly() DecoderOption {
+	return func(o *decoderOptions) {
+		o.objectsOnly = true
+	}
+}
+
+// EscapeMode determines how a decoder handles the escapes of the strings that it reads.
+type EscapeMode int
+
+const (
+	// EscapesDecode makes strings be read with their escapes decoded. It is the default mode.
+	EscapesDecode = EscapeMode(iota)
+
+	// EscapesPreserve makes strings, and object keys, be read as they are written in the input, i.e. with the escapes
+	// left as is, e.g. "a\u0041" is read as a\u0041 rather than as aA.
+	EscapesPreserve
+
+	// EscapesReject makes the decoder reject strings that contain \u escapes or escapes of control characters, such
+	// as \n. Only the escapes \", \\, and \/ are accepted.
+	EscapesReject
+)
+
+// Escapes makes the decoder handle the escapes of strings as given by the mode, e.g. so that security tooling can see
+// how a string was written in the input. Control characters that are not escaped are always rejected since they
+// cannot occur in a JSON string.
+//
+// A decoder created with NewDecoder scans its input directly when the option is given (see NewDecoder).
+func Escapes(mode EscapeMode) DecoderOption {
+	return func(o *decoderOptions) {
+		o.escapes = mode
+	}
+}
+
+// DecodeHooks makes ReadInto pass each scalar value through the given hooks, in order, before it is assigned, so that
+// conversions such as those of TimeHook and WeakTypingHook are configured once per decoder rather than per field. A
+// value returned by the last hook that is assignable to the type that the pointer points to is assigned as is. Any
+// other value is read in the regular way, as if it had been read from the input. The option can be given more than
+// once to extend the chain.
+func DecodeHooks(hooks ...DecodeHook) DecoderOption {
+	return func(o *decoderOptions) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
+// TimeLocation makes ReadTimeLayouts, and ReadInto with a *time.Time, interpret a time that is parsed using a layout
+// without a time zone, e.g. "2024-01-02 15:04:05", as a time in the given location, e.g. time.Local, instead of in
+// UTC. Times with a zone are not affected.
+func TimeLocation(loc *time.Location) DecoderOption {
+	return func(o *decoderOptions) {
+		o.timeLocation = loc
+	}
+}
+
+// RejectNaiveTimes makes ReadTimeLayouts, and ReadInto with a *time.Time, reject a time that is parsed using a layout
+// without a time zone, so that a feed that omits the zone is caught rather than silently assumed to be in UTC.
+func RejectNaiveTimes() DecoderOption {
+	return func(o *decoderOptions) {
+		o.rejectNaive = true
+	}
+}
+
+// UnknownFields makes the decoder call the given function with the raw value of each field that ReadObjectFields
+// skips because it isn't recognized. The path is the JSON Pointer of the object that holds the field, as returned by
+// CurrentPath, so it includes the indices of enclosing arrays. The raw bytes are only valid during the call. The
+// function is typically used for logging schema drift without failing the decode.
+func UnknownFields(f func(path, key string, raw []byte)) DecoderOption {
+	return func(o *decoderOptions) {
+		o.unknownField = f
+	}
+}
+
+// Debug makes the decoder annotate the errors that it raises with the state of the decoder as returned by its
+// DebugState method.
+func Debug() DecoderOption {
+	return func(o *decoderOptions) {
+		o.debug = true
+	}
+}
+
+// CoerceScalars makes the decoder accept scalars of the wrong type when they can be converted without loss.
+// ReadBool, ReadFloat, and ReadInt and their OrEnd variants then accept strings that contain a JSON number or a
+// boolean, e.g. "42" and "true", and ReadString and ReadStringOrEnd accept numbers. The option is meant for input
+// from sources that cannot be fixed to produce the correct types.
+func CoerceScalars() DecoderOption {
+	return func(o *decoderOptions) {
+		o.coerceScalars = true
+	}
+}
+
+// CaptureRaw makes the decoder call the given function with the raw bytes of each value that it passes to a Consumer
+// by ReadConsumer or ReadConsumerOrEnd, once the Consumer has read the value. The path is a JSON Pointer to the value
+// (see CurrentPath). The raw bytes are only valid during the call. The function is typically used to keep a verbatim
+// copy of a subtree, e.g. for signature verification or pass-through, while it is decoded.
+//
+// The option is only effective for decoders that scan their input directly (see NewBytesDecoder).
+func CaptureRaw(f func(path string, raw []byte)) DecoderOption {
+	return func(o *decoderOptions) {
+		o.captureRaw = f
+	}
+}
+
+// HashInput makes the decoder write all input bytes to the given hash, so that a digest of the input, e.g. for an ETag
+// or a signature, is computed in the same pass as the input is decoded. A decoder created with NewDecoder writes the
+// bytes as they are read from the io.Reader, which may be ahead of the decoded values, and a decoder created with
+// NewBytesDecoder writes all the given bytes when it is created.
+//
+// The option is only effective for decoders created with NewDecoder or NewBytesDecoder.
+func HashInput(h hash.Hash) DecoderOption {
+	return func(o *decoderOptions) {
+		o.hash = h
+	}
+}
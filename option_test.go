@@ -0,0 +1,248 @@
+package jsonstream
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tada/catch"
+)
+
+type countingReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads++
+	return c.r.Read(p)
+}
+
+func readAllInts(t *testing.T, js Decoder) {
+	t.Helper()
+	js.ReadDelim('[')
+	for {
+		if _, ok := js.ReadIntOrEnd(']'); !ok {
+			break
+		}
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	input := "[" + strings.Repeat("1,", 10000) + "1]"
+	unbuffered := &countingReader{r: strings.NewReader(input)}
+	readAllInts(t, NewDecoder(unbuffered))
+	buffered := &countingReader{r: strings.NewReader(input)}
+	readAllInts(t, NewDecoder(buffered, BufferSize(64*1024)))
+	if buffered.reads >= unbuffered.reads {
+		t.Fatalf("expected fewer reads with buffer, got %d >= %d", buffered.reads, unbuffered.reads)
+	}
+}
+
+func TestExactFloats(t *testing.T) {
+	input := `[0.5, 9007199254740993]`
+	for _, newDecoder := range []func(...DecoderOption) Decoder{
+		func(options ...DecoderOption) Decoder { return NewDecoder(strings.NewReader(input), options...) },
+		func(options ...DecoderOption) Decoder { return NewBytesDecoder([]byte(input), options...) },
+	} {
+		var inexact []json.Number
+		err := catch.Do(func() {
+			js := newDecoder(ExactFloats(func(n json.Number) { inexact = append(inexact, n) }))
+			js.ReadDelim('[')
+			js.ReadFloat()
+			js.ReadFloatOrEnd(']')
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(len(inexact) == 1 && inexact[0] == "9007199254740993") {
+			t.Fatalf("unexpected inexact numbers %v", inexact)
+		}
+		err = catch.Do(func() {
+			js := newDecoder(ExactFloats(nil))
+			js.ReadDelim('[')
+			js.ReadFloatOrEnd(']')
+			js.ReadFloat()
+		})
+		if err == nil || err.Error() != "number 9007199254740993 cannot be represented exactly as a float64" {
+			t.Fatalf("expected precision error, got %v", err)
+		}
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	input := `{"a":{"x/y":[1, 2],"b":true},"c":null}`
+	var skipped []string
+	err := catch.Do(func() {
+		js := NewBytesDecoder([]byte(input), UnknownFields(func(path, key string, raw []byte) {
+			skipped = append(skipped, path+" "+key+" "+string(raw))
+		}))
+		js.ReadDelim('{')
+		js.ReadObjectFields(map[string]func(Decoder){
+			"a": func(js Decoder) {
+				js.ReadDelim('{')
+				js.ReadObjectFields(map[string]func(Decoder){"b": func(js Decoder) { js.ReadBool() }}, nil)
+			},
+		}, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(len(skipped) == 2 && skipped[0] == "/a x/y [1, 2]" && skipped[1] == " c null") {
+		t.Fatalf("unexpected skipped fields %q", skipped)
+	}
+}
+
+func TestUnknownFields_arrays(t *testing.T) {
+	input := `{"items":[{"x":1},{"y":2,"x":3},{"z":[4]}]}`
+	for _, newDecoder := range []func(...DecoderOption) Decoder{
+		func(options ...DecoderOption) Decoder { return NewDecoder(strings.NewReader(input), options...) },
+		func(options ...DecoderOption) Decoder { return NewBytesDecoder([]byte(input), options...) },
+	} {
+		var skipped []string
+		js := newDecoder(UnknownFields(func(path, key string, raw []byte) {
+			skipped = append(skipped, path+" "+key+" "+string(raw))
+		}))
+		err := catch.Do(func() {
+			js.ReadDelim('{')
+			js.ReadObjectFields(map[string]func(Decoder){
+				"items": func(js Decoder) {
+					item := consumerFunc(func(js Decoder, firstToken json.Token) {
+						AssertDelim(firstToken, '{')
+						js.ReadObjectFields(map[string]func(Decoder){"x": func(js Decoder) { js.ReadInt() }}, nil)
+					})
+					js.ReadDelim('[')
+					for {
+						if _, ok := js.ReadConsumerOrEnd(item, ']'); !ok {
+							break
+						}
+					}
+				},
+			}, nil)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(skipped); a != `[/items/1 y 2 /items/2 z [4]]` {
+			t.Fatalf("unexpected skipped fields %s", a)
+		}
+	}
+}
+
+func TestCoerceScalars(t *testing.T) {
+	input := `["42", "-1.5e1", "true", 7, "4.5", "yes"]`
+	for _, newDecoder := range []func(...DecoderOption) Decoder{
+		func(options ...DecoderOption) Decoder { return NewDecoder(strings.NewReader(input), options...) },
+		func(options ...DecoderOption) Decoder { return NewBytesDecoder([]byte(input), options...) },
+	} {
+		js := newDecoder(CoerceScalars())
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			if i := js.ReadInt(); i != 42 {
+				t.Errorf("expected 42, got %d", i)
+			}
+			if f, _ := js.ReadFloatOrEnd(']'); f != -15 {
+				t.Errorf("expected -15, got %g", f)
+			}
+			if b := js.ReadBool(); !b {
+				t.Error("expected true")
+			}
+			if s := js.ReadString(); s != "7" {
+				t.Errorf("expected \"7\", got %q", s)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = catch.Do(func() {
+			js.ReadInt()
+		})
+		if ex := "expected an integer, got string 4.5"; err == nil || err.Error() != ex {
+			t.Errorf("expected error %q, got %v", ex, err)
+		}
+		err = catch.Do(func() {
+			js.ReadBool()
+		})
+		if err == nil || !strings.HasSuffix(err.Error(), "got string yes") {
+			t.Errorf("expected error for string yes, got %v", err)
+		}
+	}
+	err := catch.Do(func() {
+		NewBytesDecoder([]byte(`7`)).ReadString()
+	})
+	if ex := "expected a string, got json.Number 7"; err == nil || err.Error() != ex {
+		t.Errorf("expected error %q without the option, got %v", ex, err)
+	}
+}
+
+func TestCaptureRaw(t *testing.T) {
+	var captured []string
+	js := NewBytesDecoder([]byte(`{"a":[1, {"b": [true]}], "c":"x"}`), CaptureRaw(func(path string, raw []byte) {
+		captured = append(captured, path+" "+string(raw))
+	}))
+	var read []string
+	var c consumerFunc
+	c = func(js Decoder, firstToken json.Token) {
+		if firstToken == json.Delim('[') {
+			for {
+				if ok, _ := js.ReadConsumerOrEnd(c, ']'); !ok {
+					break
+				}
+			}
+			return
+		}
+		if firstToken == json.Delim('{') {
+			js.ReadObjectFields(map[string]func(Decoder){"b": func(js Decoder) { js.ReadConsumer(c) }}, nil)
+			return
+		}
+		read = append(read, fmt.Sprint(firstToken))
+	}
+	err := catch.Do(func() {
+		js.ReadDelim('{')
+		js.ReadObjectFields(map[string]func(Decoder){"a": func(js Decoder) { js.ReadConsumer(c) }}, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprint(read); a != `[1 true]` {
+		t.Fatalf("unexpected values %s", a)
+	}
+	ex := `["/a/0 1" "/a/1/b/0 true" "/a/1/b [true]" "/a/1 {\"b\": [true]}" "/a [1, {\"b\": [true]}]"]`
+	if a := fmt.Sprintf("%q", captured); a != ex {
+		t.Fatalf("expected %s, got %s", ex, a)
+	}
+}
+
+func TestHashInput(t *testing.T) {
+	input := `{"v":23} `
+	ex := sha256.Sum256([]byte(input))
+	for _, newDecoder := range []func(DecoderOption) Decoder{
+		func(o DecoderOption) Decoder { return NewDecoder(strings.NewReader(input), o) },
+		func(o DecoderOption) Decoder { return NewBytesDecoder([]byte(input), o) },
+	} {
+		h := sha256.New()
+		js := newDecoder(HashInput(h))
+		if err := catch.Do(func() { js.ReadConsumer(&ts{}) }); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(h.Sum(nil), ex[:]) {
+			t.Errorf("unexpected hash %x", h.Sum(nil))
+		}
+	}
+}
+
+func TestHashOutput(t *testing.T) {
+	h := sha256.New()
+	bs, err := Marshal(&ts{v: 23 * time.Millisecond}, HashOutput(h), Indent("", " "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := sha256.Sum256(bs); !bytes.Equal(h.Sum(nil), ex[:]) {
+		t.Errorf("unexpected hash %x", h.Sum(nil))
+	}
+}
@@ -0,0 +1,108 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/tada/catch/pio"
+)
+
+// A Member is a key of an OrderedObject and the raw JSON of its value.
+type Member struct {
+	Key   string
+	Value json.RawMessage
+}
+
+// An OrderedObject is a JSON object that keeps its members in the order they were read or set. It is both a Consumer
+// and a Streamer, so it can be used where key order is significant, e.g. when a signed document or a configuration
+// file must be written back unchanged. The values are kept as raw JSON, so the order of the keys of nested objects is
+// preserved too. The zero value is an empty object.
+type OrderedObject struct {
+	members []Member
+	index   map[string]int
+}
+
+// Get returns the raw JSON of the value of the given key and true, or nil and false if the key isn't present.
+func (o *OrderedObject) Get(key string) (json.RawMessage, bool) {
+	if i, ok := o.index[key]; ok {
+		return o.members[i].Value, true
+	}
+	return nil, false
+}
+
+// Set assigns the raw JSON of the value of the given key. The key keeps its position when it is already present and
+// is otherwise added last.
+func (o *OrderedObject) Set(key string, value json.RawMessage) {
+	if i, ok := o.index[key]; ok {
+		o.members[i].Value = value
+		return
+	}
+	if o.index == nil {
+		o.index = make(map[string]int)
+	}
+	o.index[key] = len(o.members)
+	o.members = append(o.members, Member{Key: key, Value: value})
+}
+
+// Delete removes the given key and returns true, or returns false if the key isn't present.
+func (o *OrderedObject) Delete(key string) bool {
+	i, ok := o.index[key]
+	if !ok {
+		return false
+	}
+	delete(o.index, key)
+	o.members = append(o.members[:i], o.members[i+1:]...)
+	for ; i < len(o.members); i++ {
+		o.index[o.members[i].Key] = i
+	}
+	return true
+}
+
+// Len returns the number of members.
+func (o *OrderedObject) Len() int {
+	return len(o.members)
+}
+
+// Members returns the members in order. The returned slice must not be modified.
+func (o *OrderedObject) Members() []Member {
+	return o.members
+}
+
+// MarshalToJSON writes the members in order.
+func (o *OrderedObject) MarshalToJSON(w io.Writer) {
+	pio.WriteByte(w, '{')
+	for i, m := range o.members {
+		if i > 0 {
+			pio.WriteByte(w, ',')
+		}
+		WriteString(w, m.Key)
+		pio.WriteByte(w, ':')
+		pio.Write(w, m.Value)
+	}
+	pio.WriteByte(w, '}')
+}
+
+// UnmarshalFromJSON replaces the members with those of the object that starts with the given firstToken. When a key
+// is repeated, the last value is kept at the position of the first occurrence.
+func (o *OrderedObject) UnmarshalFromJSON(js Decoder, firstToken json.Token) {
+	AssertDelim(firstToken, '{')
+	o.members = o.members[:0]
+	o.index = make(map[string]int)
+	for {
+		key, ok := js.ReadStringOrEnd('}')
+		if !ok {
+			break
+		}
+		o.Set(key, js.ReadRawAppend(nil))
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o *OrderedObject) MarshalJSON() ([]byte, error) {
+	return Marshal(o)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *OrderedObject) UnmarshalJSON(bs []byte) error {
+	return Unmarshal(o, bs)
+}
@@ -0,0 +1,47 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestOrderedObject(t *testing.T) {
+	input := `{"z":1,"a":{"y":true,"b":null},"m":[1,"x"],"a":"again"}`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		o := &OrderedObject{}
+		if err := catch.Do(func() { js.ReadConsumer(o) }); err != nil {
+			t.Fatal(err)
+		}
+		if bs := MustMarshal(o); string(bs) != `{"z":1,"a":"again","m":[1,"x"]}` {
+			t.Fatalf("unexpected output %s", bs)
+		}
+	}
+
+	o := &OrderedObject{}
+	if err := json.Unmarshal([]byte(`{"z":1,"a":{"y":true,"b":null}}`), o); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := o.Get("a"); !ok || string(v) != `{"y":true,"b":null}` {
+		t.Fatalf("unexpected value %s", v)
+	}
+	o.Set("q", json.RawMessage(`"new"`))
+	o.Set("z", json.RawMessage(`2`))
+	if !o.Delete("a") || o.Delete("a") {
+		t.Fatal("expected a to be deleted once")
+	}
+	if _, ok := o.Get("a"); ok {
+		t.Fatal("expected a to be deleted")
+	}
+	if v, ok := o.Get("q"); !ok || string(v) != `"new"` {
+		t.Fatalf("unexpected value %s", v)
+	}
+	bs, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != `{"z":2,"q":"new"}` || o.Len() != 2 || o.Members()[1].Key != "q" {
+		t.Fatalf("unexpected output %s", bs)
+	}
+}
@@ -0,0 +1,81 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tada/catch"
+)
+
+// A PageFetcher returns the body of the page of a paginated API that has the given page token. The first page is
+// fetched with an empty token.
+type PageFetcher func(token string) (io.ReadCloser, error)
+
+// ReadPages fetches the pages of a paginated API and reads each element of the array at the JSON Pointer items of each
+// page with a Consumer returned by newConsumer, which is then passed to onItem, so that the elements of all pages are
+// read as one stream in the same way as the documents of a StreamProcessor. Elements that are null are skipped. The
+// token of the next page is read from the JSON Pointer next of each page. It can be a string or a number. ReadPages
+// returns when a page has no next token, or when the token is null or an empty string.
+//
+// Each page is decoded as it is read and closed before the next page is fetched, so neither a page nor all elements
+// are held in memory. The given options are applied to the decoder of each page. An error is returned if fetch or
+// onItem returns one, if a page can't be decoded, or if a page returns the token that it was fetched with.
+func ReadPages(fetch PageFetcher, items, next string, newConsumer func() Consumer, onItem func(c Consumer) error,
+	options ...DecoderOption) error {
+	ec := elementConsumer{newConsumer: newConsumer, onItem: onItem}
+	token := ""
+	for {
+		body, err := fetch(token)
+		if err != nil {
+			return err
+		}
+		var nv Value
+		err = Extract(NewDecoder(body, options...), map[string]Consumer{items: ec, next: &nv})
+		if cerr := body.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		prev := token
+		switch nv.Kind() {
+		case KindString:
+			if token, err = nv.Str(); err != nil {
+				return err
+			}
+		case KindNumber:
+			token = nv.String()
+		default:
+			token = ""
+		}
+		if token == "" {
+			return nil
+		}
+		if token == prev {
+			return fmt.Errorf("page token %q repeats", token)
+		}
+	}
+}
+
+// elementConsumer reads each element of an array with a new Consumer and passes that Consumer to onItem.
+type elementConsumer struct {
+	newConsumer func() Consumer
+	onItem      func(c Consumer) error
+}
+
+func (e elementConsumer) UnmarshalFromJSON(js Decoder, firstToken json.Token) {
+	AssertDelim(firstToken, '[')
+	for {
+		c := e.newConsumer()
+		found, ok := js.ReadConsumerOrEnd(c, ']')
+		if !ok {
+			return
+		}
+		if found {
+			if err := e.onItem(c); err != nil {
+				panic(catch.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestReadPages(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"data": {"items": [1, 2]}, "next": "p2"}`,
+		"p2": `{"next": 3, "data": {"items": []}}`,
+		"3":  `{"data": {"items": [3, null]}, "next": null}`,
+	}
+	var fetched []string
+	fetch := func(token string) (io.ReadCloser, error) {
+		fetched = append(fetched, token)
+		return ioutil.NopCloser(strings.NewReader(pages[token])), nil
+	}
+	var items []string
+	err := ReadPages(fetch, "/data/items", "/next", func() Consumer { return &Value{} }, func(c Consumer) error {
+		items = append(items, c.(*Value).String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprint(items, fetched); a != `[1 2 3] [ p2 3]` {
+		t.Errorf("unexpected items and pages %s", a)
+	}
+}
+
+func TestReadPages_errors(t *testing.T) {
+	ignore := func() Consumer { return consumerFunc(func(js Decoder, firstToken json.Token) {}) }
+	accept := func(Consumer) error { return nil }
+	for page, ex := range map[string]string{
+		`{"items": [], "next": ""}`:  ``,
+		`{"items": [], "next": "x"}`: `page token "x" repeats`,
+		`{"items": {}}`:              `expected delimiter '[', got json.Delim {`,
+		`{"items": [1,]}`:            `invalid character ',' looking for beginning of value`,
+	} {
+		fetch := func(token string) (io.ReadCloser, error) {
+			if token == "x" {
+				page = `{"next": "x"}`
+			}
+			return ioutil.NopCloser(strings.NewReader(page)), nil
+		}
+		err := ReadPages(fetch, "/items", "/next", ignore, accept)
+		if ex == "" && err != nil || ex != "" && (err == nil || err.Error() != ex) {
+			t.Errorf("%s: expected error %q, got %v", page, ex, err)
+		}
+	}
+	offline := func(string) (io.ReadCloser, error) { return nil, errors.New("offline") }
+	err := ReadPages(offline, "/items", "/next", ignore, accept)
+	if err == nil || err.Error() != "offline" {
+		t.Errorf("expected offline error, got %v", err)
+	}
+	page := func(string) (io.ReadCloser, error) { return ioutil.NopCloser(strings.NewReader(`{"items": [1]}`)), nil }
+	err = ReadPages(page, "/items", "/next", ignore, func(Consumer) error { return errors.New("rejected") })
+	if err == nil || err.Error() != "rejected" {
+		t.Errorf("expected rejected error, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package jsonstream
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/tada/catch"
+)
+
+// recordSeparator is the byte that precedes each document of a JSON text sequence (RFC 7464).
+const recordSeparator = 0x1e
+
+// A StreamProcessor reads an unbounded sequence of JSON documents and passes each one of them to a callback. The
+// documents can be separated by newlines (NDJSON), preceded by record separators (RFC 7464 json-seq), or simply be
+// concatenated. When the input fails, e.g. because a connection is lost or a document is malformed, the processor
+// can restart by opening the input again after a backoff.
+//
+// The NewConsumer and OnDocument fields are required. All other fields are optional.
+type StreamProcessor struct {
+	// Open returns the input to read. It is called once when Run starts and again on each restart, so it should
+	// resume where it is appropriate, e.g. by reconnecting to a feed. An input that is an io.Closer is closed when it
+	// is no longer used. When Open is nil, Run reads from the Reader field and never restarts.
+	Open func() (io.Reader, error)
+
+	// Reader is the input when Open is nil.
+	Reader io.Reader
+
+	// NewConsumer returns the Consumer that reads the next document.
+	NewConsumer func() Consumer
+
+	// OnDocument is called with each Consumer after it has read its document. An error returned by OnDocument is
+	// passed to OnError but, since the input is still intact, it never causes a restart.
+	OnDocument func(c Consumer) error
+
+	// OnError is called with each error and returns true if the processing should continue, i.e. with the next
+	// document or with a restart. When OnError is nil, Run stops on the first error.
+	OnError func(err error) bool
+
+	// OnEOF is called when the input ends. Run then returns nil.
+	OnEOF func()
+
+	// Backoff returns the time to wait before the given restart, counting from 1. When Backoff is nil, restarts are
+	// made without waiting. See ExponentialBackoff.
+	Backoff func(restart int) time.Duration
+
+	// MaxRestarts is the maximum number of restarts, or -1 for no limit. The zero value disables restarts.
+	MaxRestarts int
+}
+
+// ExponentialBackoff returns a Backoff function for a StreamProcessor that waits min before the first restart and
+// then doubles the wait for each restart up to max.
+func ExponentialBackoff(min, max time.Duration) func(restart int) time.Duration {
+	return func(restart int) time.Duration {
+		d := min
+		for i := 1; i < restart && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// Run processes documents until the input ends, an error stops the processing, or the context is done. It returns
+// nil when the input ended and otherwise the error that stopped the processing. The context is checked between
+// documents, so a read that blocks is not interrupted.
+func (p *StreamProcessor) Run(ctx context.Context) error {
+	for restart := 0; ; restart++ {
+		if restart > 0 {
+			if err := p.wait(ctx, restart); err != nil {
+				return err
+			}
+		}
+		final, err := p.runOnce(ctx)
+		if err == nil {
+			if p.OnEOF != nil {
+				p.OnEOF()
+			}
+			return nil
+		}
+		if final || ctx.Err() != nil || !p.continueAfter(err) || p.Open == nil ||
+			p.MaxRestarts >= 0 && restart >= p.MaxRestarts {
+			return err
+		}
+	}
+}
+
+// runOnce opens the input and processes its documents. It returns a nil error when the input ends. The returned bool
+// is true when the error is one returned by OnDocument that stopped the processing, which must not cause a restart.
+func (p *StreamProcessor) runOnce(ctx context.Context) (bool, error) {
+	r := p.Reader
+	if p.Open != nil {
+		var err error
+		if r, err = p.Open(); err != nil {
+			return false, err
+		}
+		if c, ok := r.(io.Closer); ok {
+			defer c.Close()
+		}
+	}
+	d := NewDecoder(&separatorFilter{r: r})
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		c := p.NewConsumer()
+		end := false
+		err := catch.Do(func() {
+			if len(d.PeekN(1)) == 0 {
+				end = true
+				return
+			}
+			d.ReadConsumer(c)
+		})
+		if err != nil {
+			return false, err
+		}
+		if end {
+			return false, nil
+		}
+		if err = p.OnDocument(c); err != nil && !p.continueAfter(err) {
+			return true, err
+		}
+	}
+}
+
+func (p *StreamProcessor) continueAfter(err error) bool {
+	return p.OnError != nil && p.OnError(err)
+}
+
+// wait waits for the backoff of the given restart or until the context is done.
+func (p *StreamProcessor) wait(ctx context.Context, restart int) error {
+	if p.Backoff == nil {
+		return ctx.Err()
+	}
+	t := time.NewTimer(p.Backoff(restart))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// separatorFilter is an io.Reader that replaces the record separators of a JSON text sequence with spaces so that
+// the sequence can be read as concatenated documents. A record separator cannot occur in a document since control
+// characters in strings must be escaped.
+type separatorFilter struct {
+	r io.Reader
+}
+
+func (f *separatorFilter) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == recordSeparator {
+			p[i] = ' '
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,145 @@
+package jsonstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamProcessor(t *testing.T) {
+	inputs := []string{
+		"{\"v\":1}\n{\"v\":2}\n{\"v\":",
+		"\x1e{\"v\":3}\n\x1e{\"v\":4}{\"v\":5} ",
+	}
+	opened := 0
+	var values []time.Duration
+	var errs []string
+	eof := false
+	p := &StreamProcessor{
+		Open: func() (io.Reader, error) {
+			if opened == len(inputs) {
+				return nil, errors.New("no more inputs")
+			}
+			opened++
+			return strings.NewReader(inputs[opened-1]), nil
+		},
+		NewConsumer: func() Consumer { return &ts{} },
+		OnDocument: func(c Consumer) error {
+			v := c.(*ts).v
+			values = append(values, v)
+			if v == 4*time.Millisecond {
+				return errors.New("four")
+			}
+			return nil
+		},
+		OnError: func(err error) bool {
+			errs = append(errs, err.Error())
+			return true
+		},
+		OnEOF:       func() { eof = true },
+		Backoff:     ExponentialBackoff(time.Microsecond, time.Millisecond),
+		MaxRestarts: -1,
+	}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 5 || values[4] != 5*time.Millisecond || !eof {
+		t.Fatalf("unexpected values %v", values)
+	}
+	if strings.Join(errs, ", ") != "unexpected EOF, four" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestStreamProcessor_stop(t *testing.T) {
+	p := &StreamProcessor{
+		Reader:      strings.NewReader(`{"v":1} {"v":2}`),
+		NewConsumer: func() Consumer { return &ts{} },
+		OnDocument:  func(c Consumer) error { return errors.New("stop") },
+	}
+	if err := p.Run(context.Background()); err == nil || err.Error() != "stop" {
+		t.Fatalf("expected stop error, got %v", err)
+	}
+
+	p = &StreamProcessor{
+		Open:        func() (io.Reader, error) { return strings.NewReader(`{"v":`), nil },
+		NewConsumer: func() Consumer { return &ts{} },
+		OnDocument:  func(c Consumer) error { return nil },
+		OnError:     func(err error) bool { return true },
+		MaxRestarts: 2,
+	}
+	if err := p.Run(context.Background()); err == nil || err.Error() != "unexpected EOF" {
+		t.Fatalf("expected unexpected EOF error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.MaxRestarts = -1
+	if err := p.Run(ctx); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestStreamProcessor_restart(t *testing.T) {
+	var inputs []*closeRecorder
+	p := &StreamProcessor{
+		Open: func() (io.Reader, error) {
+			if len(inputs) > 0 {
+				return nil, errors.New("offline")
+			}
+			inputs = append(inputs, &closeRecorder{Reader: strings.NewReader(`{"v":1} {"v":`)})
+			return inputs[0], nil
+		},
+		NewConsumer: func() Consumer { return &ts{} },
+		OnDocument:  func(c Consumer) error { return nil },
+		OnError:     func(err error) bool { return true },
+		MaxRestarts: 1,
+	}
+	if err := p.Run(context.Background()); err == nil || err.Error() != "offline" {
+		t.Fatalf("expected offline error, got %v", err)
+	}
+	if !inputs[0].closed {
+		t.Fatal("expected the input to be closed")
+	}
+
+	// an error from OnDocument that stops the processing doesn't cause a restart
+	inputs = nil
+	p.OnDocument = func(c Consumer) error { return errors.New("stop") }
+	p.OnError = func(err error) bool { return err.Error() != "stop" }
+	if err := p.Run(context.Background()); err == nil || err.Error() != "stop" || len(inputs) != 1 {
+		t.Fatalf("expected stop error without restart, got %v after %d opens", err, len(inputs))
+	}
+
+	// the context is checked while waiting for a restart
+	inputs = nil
+	p.OnError = func(err error) bool { return true }
+	p.Backoff = func(int) time.Duration { return time.Hour }
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Run(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff(time.Second, 5*time.Second)
+	s := time.Second
+	for i, ex := range []time.Duration{s, 2 * s, 4 * s, 5 * s, 5 * s} {
+		if d := b(i + 1); d != ex {
+			t.Errorf("expected %v for restart %d, got %v", ex, i+1, d)
+		}
+	}
+}
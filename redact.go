@@ -0,0 +1,120 @@
+package jsonstream
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tada/catch"
+)
+
+// A RedactOption configures Redact.
+type RedactOption func(*redactor)
+
+// RedactWith makes Redact replace the redacted values with the given string instead of "[REDACTED]".
+func RedactWith(replacement string) RedactOption {
+	return func(r *redactor) {
+		r.replacement = replacement
+	}
+}
+
+// RedactHash makes Redact replace each redacted value with a string that contains the hex encoded SHA-256 hash of its
+// compact JSON, prefixed by "sha256:". Equal values then have equal replacements, so they can still be correlated.
+func RedactHash() RedactOption {
+	return func(r *redactor) {
+		r.hash = true
+	}
+}
+
+type redactor struct {
+	patterns    [][]string
+	replacement string
+	hash        bool
+	raw         []byte
+}
+
+// Redact copies the JSON values from src to dst, with insignificant whitespace removed, and replaces the values that
+// match any of the given patterns with the string "[REDACTED]". A pattern without dots, e.g. "password", matches the
+// value of that key at any depth. A pattern with dots, e.g. "user.token" or "*.token", is matched against the path of
+// the value from the top level, where each element is a key or an array index and * matches any one element. The
+// input is processed token by token so its size is not limited by the available memory.
+//
+// The function will recover a catch.Error panic and return its cause.
+func Redact(dst io.Writer, src io.Reader, patterns []string, options ...RedactOption) error {
+	r := &redactor{replacement: "[REDACTED]"}
+	for _, p := range patterns {
+		r.patterns = append(r.patterns, strings.Split(p, "."))
+	}
+	for _, option := range options {
+		option(r)
+	}
+	bw := bufio.NewWriter(dst)
+	return catch.Do(func() {
+		c := newTokenCopier(bw, bw, src)
+		for {
+			key := c.d.atKey
+			depth := len(c.d.stack)
+			t, ok := c.next()
+			if !ok {
+				break
+			}
+			if !key && !isDelim(t, ']') && !isDelim(t, '}') && r.matches(c.d, depth) {
+				t = r.redact(c, t, depth)
+			}
+			c.write(t, key)
+		}
+		c.flush()
+	})
+}
+
+// matches returns true if the path of the value at the given depth matches one of the patterns.
+func (r *redactor) matches(d *decoder, depth int) bool {
+	if depth == 0 {
+		return false
+	}
+	for _, p := range r.patterns {
+		if len(p) == 1 {
+			if d.stack[depth-1] == '{' && (p[0] == "*" || p[0] == d.path[depth-1].key) {
+				return true
+			}
+			continue
+		}
+		if len(p) != depth {
+			continue
+		}
+		i := 0
+		for ; i < depth && (p[i] == "*" || p[i] == pathSegment(d, i)); i++ {
+		}
+		if i == depth {
+			return true
+		}
+	}
+	return false
+}
+
+// pathSegment returns the key or the array index of the path element at the given depth.
+func pathSegment(d *decoder, i int) string {
+	if d.stack[i] == '[' {
+		return strconv.Itoa(d.path[i].index)
+	}
+	return d.path[i].key
+}
+
+// redact reads the rest of the value that starts with the given token and returns its replacement.
+func (r *redactor) redact(c *tokenCopier, t json.Token, depth int) json.Token {
+	r.raw = appendToken(r.raw[:0], 0, t, false)
+	for len(c.d.stack) > depth {
+		key := c.d.atKey
+		t, _ = c.next()
+		r.raw = appendToken(r.raw, r.raw[len(r.raw)-1], t, key)
+	}
+	if !r.hash {
+		return r.replacement
+	}
+	sum := sha256.Sum256(r.raw)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
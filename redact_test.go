@@ -0,0 +1,66 @@
+package jsonstream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	input := `{"user":{"name":"a", "password":"x", "token":[1]}, "items":[{"token":"t"}], "password":{"p":1}} {"token":2}`
+	for _, tc := range []struct {
+		patterns []string
+		options  []RedactOption
+		ex       string
+	}{
+		{[]string{"password"}, nil,
+			`{"user":{"name":"a","password":"[REDACTED]","token":[1]},"items":[{"token":"t"}],"password":"[REDACTED]"}` +
+				"\n" + `{"token":2}`},
+		{[]string{"*.token"}, []RedactOption{RedactWith("***")},
+			`{"user":{"name":"a","password":"x","token":"***"},"items":[{"token":"t"}],"password":{"p":1}}` +
+				"\n" + `{"token":2}`},
+		{[]string{"items.*.token", "user.name"}, nil,
+			`{"user":{"name":"[REDACTED]","password":"x","token":[1]},"items":[{"token":"[REDACTED]"}],` +
+				`"password":{"p":1}}` + "\n" + `{"token":2}`},
+	} {
+		b := bytes.Buffer{}
+		if err := Redact(&b, strings.NewReader(input), tc.patterns, tc.options...); err != nil {
+			t.Fatal(err)
+		}
+		if a := b.String(); a != tc.ex {
+			t.Errorf("%q: expected %s, got %s", tc.patterns, tc.ex, a)
+		}
+	}
+}
+
+func TestRedactHash(t *testing.T) {
+	b := bytes.Buffer{}
+	err := Redact(&b, strings.NewReader(`[{"k":{"a": 1}}, {"k":{"a":1}}]`), []string{"k"}, RedactHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := `[{"k":"sha256:015abd7f5cc57a2dd94b7590f04ad8084273905ee33ec5cebeae62276a97f862"},` +
+		`{"k":"sha256:015abd7f5cc57a2dd94b7590f04ad8084273905ee33ec5cebeae62276a97f862"}]`
+	if a := b.String(); a != ex {
+		t.Fatalf("expected %s, got %s", ex, a)
+	}
+}
+
+func TestRedact_controlCharacters(t *testing.T) {
+	b := bytes.Buffer{}
+	err := Redact(&b, strings.NewReader(`{"note\t":"a\nb", "secret":"x\u0001"}`), []string{"secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := `{"note\t":"a\nb","secret":"[REDACTED]"}`; b.String() != ex {
+		t.Fatalf("expected %s, got %q", ex, b.String())
+	}
+	b.Reset()
+	err = Redact(&b, strings.NewReader(`{"k":["a\nb"]} {"k":["a\nb"]}`), []string{"k"}, RedactHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs := strings.Split(b.String(), "\n"); len(docs) != 2 || docs[0] != docs[1] {
+		t.Fatalf("expected two equal documents, got %q", b.String())
+	}
+}
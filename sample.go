@@ -0,0 +1,97 @@
+package jsonstream
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/tada/catch"
+)
+
+// A SampleOption selects the elements that SampleArray keeps. The options are applied in the order Offset,
+// TakeEvery, Limit regardless of the order they are given in.
+type SampleOption func(*sampler)
+
+// Offset makes SampleArray drop the first n elements.
+func Offset(n int) SampleOption {
+	return func(s *sampler) {
+		s.offset = n
+	}
+}
+
+// TakeEvery makes SampleArray keep only every nth element, starting with the first element after the offset.
+func TakeEvery(n int) SampleOption {
+	return func(s *sampler) {
+		s.every = n
+	}
+}
+
+// Limit makes SampleArray keep at most n elements.
+func Limit(n int) SampleOption {
+	return func(s *sampler) {
+		s.limit = n
+	}
+}
+
+type sampler struct {
+	offset int
+	every  int
+	limit  int
+}
+
+// keep returns true if the element at the given index, of which kept elements were kept before, is kept.
+func (s *sampler) keep(index, kept int) bool {
+	if index < s.offset || s.limit >= 0 && kept >= s.limit {
+		return false
+	}
+	return s.every <= 1 || (index-s.offset)%s.every == 0
+}
+
+// SampleArray copies the JSON values from src to dst, with insignificant whitespace removed, and drops the elements
+// of each array found at the given JSON Pointer that aren't selected by the given options. An empty pointer denotes a
+// top level array. The input is processed token by token, so a sample can be cut from an input of any size.
+//
+// The function will recover a catch.Error panic and return its cause.
+func SampleArray(dst io.Writer, src io.Reader, pointer string, options ...SampleOption) error {
+	s := &sampler{limit: -1}
+	for _, option := range options {
+		option(s)
+	}
+	bw := bufio.NewWriter(dst)
+	return catch.Do(func() {
+		c := newTokenCopier(bw, bw, src)
+		target := -1
+		index, kept := 0, 0
+		for {
+			key := c.d.atKey
+			depth := len(c.d.stack)
+			t, ok := c.next()
+			if !ok {
+				break
+			}
+			if depth == target {
+				if isDelim(t, ']') {
+					target = -1
+				} else {
+					index++
+					if !s.keep(index-1, kept) {
+						skipRest(c, depth)
+						continue
+					}
+					kept++
+				}
+			}
+			c.write(t, key)
+			if target < 0 && isDelim(t, '[') && c.d.CurrentPath() == pointer {
+				target, index, kept = depth+1, 0, 0
+			}
+		}
+		c.flush()
+	})
+}
+
+// skipRest reads the rest of the value whose first token was read at the given depth.
+func skipRest(c *tokenCopier, depth int) {
+	for len(c.d.stack) > depth {
+		c.next()
+	}
+}
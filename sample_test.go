@@ -0,0 +1,51 @@
+package jsonstream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSampleArray(t *testing.T) {
+	input := `{"items":[0, [1], {"a":[2]}, 3, 4, 5, 6], "other":[0, 1]}`
+	for _, tc := range []struct {
+		options []SampleOption
+		ex      string
+	}{
+		{nil, `{"items":[0,[1],{"a":[2]},3,4,5,6],"other":[0,1]}`},
+		{[]SampleOption{Limit(2)}, `{"items":[0,[1]],"other":[0,1]}`},
+		{[]SampleOption{Offset(2), Limit(2)}, `{"items":[{"a":[2]},3],"other":[0,1]}`},
+		{[]SampleOption{TakeEvery(3)}, `{"items":[0,3,6],"other":[0,1]}`},
+		{[]SampleOption{Limit(2), TakeEvery(2), Offset(1)}, `{"items":[[1],3],"other":[0,1]}`},
+		{[]SampleOption{Offset(10)}, `{"items":[],"other":[0,1]}`},
+	} {
+		b := bytes.Buffer{}
+		if err := SampleArray(&b, strings.NewReader(input), "/items", tc.options...); err != nil {
+			t.Fatal(err)
+		}
+		if a := b.String(); a != tc.ex {
+			t.Errorf("expected %s, got %s", tc.ex, a)
+		}
+	}
+}
+
+func TestSampleArray_topLevel(t *testing.T) {
+	b := bytes.Buffer{}
+	if err := SampleArray(&b, strings.NewReader(`[1, 2, 3] [4, 5]`), "", Limit(1)); err != nil {
+		t.Fatal(err)
+	}
+	if a := b.String(); a != "[1]\n[4]" {
+		t.Fatalf("unexpected output %q", a)
+	}
+}
+
+func TestSampleArray_controlCharacters(t *testing.T) {
+	b := bytes.Buffer{}
+	err := SampleArray(&b, strings.NewReader(`{"a\n":["x\ty", "\u0001", "z"]}`), "/a\n", Offset(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := `{"a\n":["\u0001","z"]}`; b.String() != ex {
+		t.Fatalf("expected %s, got %q", ex, b.String())
+	}
+}
@@ -0,0 +1,1026 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// scanner states, i.e. what the scanner expects to find next
+const (
+	scanTopValue    = iota // a top level value
+	scanArrayStart         // a value or the end of an array
+	scanArrayValue         // a value in an array
+	scanArrayComma         // a comma or the end of an array
+	scanObjectStart        // a key or the end of an object
+	scanObjectKey          // a key in an object
+	scanObjectColon        // the colon that separates a key from its value
+	scanObjectValue        // a value in an object
+	scanObjectComma        // a comma or the end of an object
+)
+
+// token kinds returned by scanner.next
+const (
+	kindString = '"'
+	kindNumber = '0'
+	kindTrue   = 't'
+	kindFalse  = 'f'
+	kindNull   = 'n'
+)
+
+// scanner is a tokenizer that scans a byte slice directly. It produces the same tokens as a json.Decoder that has
+// UseNumber enabled.
+type scanner struct {
+	buf   []byte
+	pos   int
+	state int
+	stack []byte
+
+	// r is the reader that buf is filled from, or nil when buf holds all of the input. rerr is the error that ended
+	// the reading of r, and base is the offset in the input of buf[0], which moves when buf is compacted
+	r    io.Reader
+	rerr error
+	base int
+
+	// pinned is true while a snapshot may be restored, during which buf is not compacted
+	pinned bool
+
+	// tokens is the number of tokens scanned, including those scanned again after a snapshot was restored
+	tokens int64
+
+	// fillPos and fillMode are the position and the mode of the scan that fill makes to find the end of the next token
+	fillPos  int
+	fillMode byte
+
+	// path holds, for each open container, the index of the current element of an array or the offset of the current
+	// key of an object. It is -1 before the first element or key has been scanned.
+	path []int
+
+	// opens holds the offsets of the delimiters that opened the currently open containers
+	opens []int
+
+	// start and end of the last scanned token
+	start int
+	end   int
+
+	// start and end of the last scanned complete value. The end is 0 when no value has been scanned
+	valueStart int
+	valueEnd   int
+
+	// escaped is true when the last scanned string contains escapes or bytes that must be validated as UTF-8
+	escaped bool
+
+	// start, end, and escaped of the last scanned object key
+	keyStart   int
+	keyEnd     int
+	keyEscaped bool
+
+	// keys is the table used for interning object keys, or nil
+	keys *InternTable
+
+	// strict is true when strings must be valid UTF-8 without lone surrogates
+	strict bool
+
+	// uniqueKeys is true when an object must not have the same key more than once. The keys of each open object are
+	// then held in keySets, which has a nil entry for each open array
+	uniqueKeys bool
+	keySets    []map[string]bool
+
+	// objectsOnly is true when top level values must be objects
+	objectsOnly bool
+
+	// escapes determines how the escapes of strings are handled
+	escapes EscapeMode
+
+	// nonFinite is true when the literals NaN, Infinity, and -Infinity are scanned as numbers
+	nonFinite bool
+
+	// relaxed is true when strings can be in single quotes and object keys can be bare identifiers
+	relaxed bool
+
+	// allowComments is true when // and /* */ comments are skipped like whitespace
+	allowComments bool
+
+	// keepComments is true when the skipped comments are collected in comments, together with an empty string for
+	// each blank line that separates tokens or comments
+	keepComments bool
+	comments     []string
+
+	// scratch is reused when unquoting strings that are returned as bytes
+	scratch []byte
+}
+
+func newScanner(bs []byte, o *decoderOptions) *scanner {
+	return &scanner{buf: bs, keys: o.keys, strict: o.strictStrings, allowComments: o.allowComments,
+		uniqueKeys: o.uniqueKeys, objectsOnly: o.objectsOnly, escapes: o.escapes, nonFinite: o.nonFinite,
+		relaxed: o.relaxed}
+}
+
+// newReaderScanner returns a scanner that reads its input from the given reader as it is needed.
+func newReaderScanner(r io.Reader, o *decoderOptions) *scanner {
+	size := o.bufferSize
+	if size < 4096 {
+		size = 4096
+	}
+	s := newScanner(make([]byte, 0, size), o)
+	s.r = r
+	return s
+}
+
+// Token returns the next JSON token in the input stream. At the end of the input stream, Token returns nil, io.EOF.
+func (s *scanner) Token() (json.Token, error) {
+	k, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	return s.token(k), nil
+}
+
+// numberOrToken returns the bytes of the next token when it is a number and the token itself otherwise. It is used
+// when a number is expected so that the number can be parsed without first being converted to a json.Number.
+func (s *scanner) numberOrToken() ([]byte, json.Token, error) {
+	k, err := s.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	if k == kindNumber {
+		return s.buf[s.start:s.end], nil, nil
+	}
+	return nil, s.token(k), nil
+}
+
+// rawValue scans the next value, including all nested values when it is an array or an object, and returns its bytes.
+func (s *scanner) rawValue() ([]byte, error) {
+	k, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	start := s.start
+	switch k {
+	case ']', '}':
+		return nil, fmt.Errorf("expected a value, got delimiter '%c' at offset %d", k, s.base+start)
+	case '[', '{':
+		for depth := len(s.stack); len(s.stack) >= depth; {
+			if _, err = s.next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.buf[start:s.end], nil
+}
+
+// stringBytesOrToken returns the unquoted bytes of the next token when it is a string and the token itself otherwise.
+// The returned bytes are only valid until the next call to the scanner.
+func (s *scanner) stringBytesOrToken() ([]byte, json.Token, error) {
+	k, err := s.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	if k == kindString {
+		raw := s.contents(s.start, s.end)
+		if s.escaped {
+			s.scratch = unquoteBytes(s.scratch[:0], raw)
+			raw = s.scratch
+		}
+		return raw, nil, nil
+	}
+	return nil, s.token(k), nil
+}
+
+// rawStringOrToken returns the contents of the next token, without the quotes and with the escapes left as is, when
+// it is a string and the token itself otherwise. The contents are a slice of the input.
+func (s *scanner) rawStringOrToken() ([]byte, json.Token, error) {
+	k, err := s.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	if k == kindString {
+		return s.contents(s.start, s.end), nil, nil
+	}
+	return nil, s.token(k), nil
+}
+
+// token returns the token of the given kind that was last scanned.
+func (s *scanner) token(k byte) json.Token {
+	switch k {
+	case kindString:
+		if s.keys != nil && s.state == scanObjectColon && !s.escaped {
+			return s.keys.Intern(s.contents(s.start, s.end))
+		}
+		return s.stringValue()
+	case kindNumber:
+		return json.Number(s.buf[s.start:s.end])
+	case kindTrue:
+		return true
+	case kindFalse:
+		return false
+	case kindNull:
+		return nil
+	default:
+		return json.Delim(k)
+	}
+}
+
+// next scans the next token and returns its kind. The kind of a delimiter is the delimiter itself. The scanned bytes
+// are found between s.start and s.end.
+func (s *scanner) next() (byte, error) {
+	if s.r != nil {
+		s.fill()
+	}
+	k, err := s.scan()
+	if err == nil {
+		s.tokens++
+	} else if s.r != nil && (err == io.EOF || err == io.ErrUnexpectedEOF) && s.rerr != io.EOF {
+		err = s.rerr
+	}
+	return k, err
+}
+
+// fill reads from the reader until buf holds the next token in full or the reader is exhausted. The bytes of the
+// values that have been scanned are discarded once they take up half of buf.
+func (s *scanner) fill() {
+	if s.pos > cap(s.buf)/2 {
+		s.compact()
+	}
+	s.fillPos, s.fillMode = s.pos, 0
+	for s.rerr == nil && !s.complete() {
+		if len(s.buf) == cap(s.buf) {
+			s.compact()
+		}
+		if len(s.buf) == cap(s.buf) {
+			buf := make([]byte, len(s.buf), 2*cap(s.buf))
+			copy(buf, s.buf)
+			s.buf = buf
+		}
+		n, err := s.r.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+n]
+		s.rerr = err
+	}
+}
+
+// fill modes that are not a quote
+const (
+	fillToken        = 'a' // in a number, a literal, or a bare key
+	fillSlash        = '/' // after a slash that may start a comment
+	fillLineComment  = 'l' // in a line comment
+	fillBlockComment = '*' // in a block comment
+)
+
+// complete returns true if buf holds the next token in full, together with the whitespace, comments, commas, and
+// colons that precede it and the byte that follows it when it is a number, a literal, or a bare key. The scan
+// continues from where the previous call for the same token stopped.
+func (s *scanner) complete() bool {
+	i := s.fillPos
+	for ; i < len(s.buf); i++ {
+		c := s.buf[i]
+		switch s.fillMode {
+		case 0:
+			switch {
+			case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ',' || c == ':':
+			case c == '"' || c == '\'' && s.relaxed:
+				s.fillMode = c
+			case c == '/' && s.allowComments:
+				s.fillMode = fillSlash
+			case isIdentifierPart(c) || c == '-' || c == '+' || c == '.':
+				s.fillMode = fillToken
+			default:
+				return true
+			}
+		case fillToken:
+			if !(isIdentifierPart(c) || c == '-' || c == '+' || c == '.') {
+				return true
+			}
+		case fillSlash:
+			switch c {
+			case '/':
+				s.fillMode = fillLineComment
+			case '*':
+				s.fillMode = fillBlockComment
+			default:
+				return true
+			}
+		case fillLineComment:
+			if c == '\n' {
+				s.fillMode = 0
+			}
+		case fillBlockComment:
+			if c == '*' {
+				if i+1 == len(s.buf) {
+					s.fillPos = i
+					return false
+				}
+				if s.buf[i+1] == '/' {
+					i++
+					s.fillMode = 0
+				}
+			}
+		default:
+			if c == '\\' {
+				if i+1 == len(s.buf) {
+					s.fillPos = i
+					return false
+				}
+				i++
+			} else if c == s.fillMode {
+				return true
+			}
+		}
+	}
+	s.fillPos = i
+	return false
+}
+
+// compact discards the bytes of the values that have been scanned when the scanner is between top level values and
+// no snapshot may be restored.
+func (s *scanner) compact() {
+	n := s.pos
+	if s.pinned || s.state != scanTopValue || n == 0 {
+		return
+	}
+	s.buf = s.buf[:copy(s.buf, s.buf[n:])]
+	s.base += n
+	s.pos -= n
+	s.start -= n
+	s.end -= n
+	s.valueStart -= n
+	s.valueEnd -= n
+	s.fillPos -= n
+	s.keyStart, s.keyEnd = 0, 0
+}
+
+// restore returns the scanner to the given snapshot. The input that has been read since the snapshot was taken is
+// kept, and so is the count of scanned tokens.
+func (s *scanner) restore(c scanner) {
+	buf, rerr, tokens := s.buf, s.rerr, s.tokens
+	*s = c
+	s.buf, s.rerr, s.tokens = buf, rerr, tokens
+}
+
+// scan scans the next token in buf.
+func (s *scanner) scan() (byte, error) {
+	for {
+		c, ok := s.skipSpace()
+		if !ok {
+			if s.state == scanTopValue {
+				return 0, io.EOF
+			}
+			return 0, io.ErrUnexpectedEOF
+		}
+		switch c {
+		case ',':
+			if err := s.comma(); err != nil {
+				return 0, err
+			}
+		case ':':
+			if s.state != scanObjectColon {
+				return 0, s.syntaxError(c, "looking for beginning of value")
+			}
+			s.state = scanObjectValue
+			s.pos++
+		case ']', '}':
+			return c, s.endContainer(c)
+		default:
+			return s.value(c)
+		}
+	}
+}
+
+func (s *scanner) comma() error {
+	switch s.state {
+	case scanArrayComma:
+		s.state = scanArrayValue
+	case scanObjectComma:
+		s.state = scanObjectKey
+	default:
+		return s.syntaxError(',', "looking for beginning of value")
+	}
+	s.pos++
+	return nil
+}
+
+func (s *scanner) endContainer(c byte) error {
+	n := len(s.stack) - 1
+	switch {
+	case c == ']' && (s.state == scanArrayStart || s.state == scanArrayComma):
+	case c == '}' && (s.state == scanObjectStart || s.state == scanObjectComma):
+	case s.state == scanObjectColon:
+		return s.syntaxError(c, "after object key")
+	default:
+		return s.syntaxError(c, "looking for beginning of value")
+	}
+	s.valueStart = s.opens[n]
+	if s.uniqueKeys {
+		s.keySets = s.keySets[:n]
+	}
+	s.stack = s.stack[:n]
+	s.path = s.path[:n]
+	s.opens = s.opens[:n]
+	s.start = s.pos
+	s.pos++
+	s.end = s.pos
+	s.valueEnded()
+	return nil
+}
+
+func (s *scanner) value(c byte) (byte, error) {
+	s.start = s.pos
+	switch s.state {
+	case scanObjectStart, scanObjectKey:
+		switch {
+		case c == '"' || c == '\'' && s.relaxed:
+			if err := s.scanString(); err != nil {
+				return 0, err
+			}
+		case s.relaxed && isIdentifierStart(c):
+			s.escaped = false
+			s.pos = s.keyEndAt(s.pos)
+			s.end = s.pos
+		default:
+			return 0, s.syntaxError(c, "looking for beginning of object key string")
+		}
+		s.keyStart, s.keyEnd, s.keyEscaped = s.start, s.end, s.escaped
+		if s.uniqueKeys {
+			if err := s.checkUniqueKey(); err != nil {
+				return 0, err
+			}
+		}
+		s.path[len(s.path)-1] = s.start
+		s.state = scanObjectColon
+		return kindString, nil
+	case scanObjectColon:
+		return 0, s.syntaxError(c, "after object key")
+	case scanArrayComma:
+		return 0, s.syntaxError(c, "after array element")
+	case scanObjectComma:
+		return 0, s.syntaxError(c, "after object key:value pair")
+	}
+
+	if s.state == scanArrayStart || s.state == scanArrayValue {
+		s.path[len(s.path)-1]++
+	} else if s.objectsOnly && s.state == scanTopValue && c != '{' {
+		return 0, s.syntaxError(c, "looking for beginning of top level object")
+	}
+
+	var err error
+	k := c
+	switch c {
+	case '[':
+		if s.uniqueKeys {
+			s.keySets = append(s.keySets, nil)
+		}
+		s.stack = append(s.stack, c)
+		s.path = append(s.path, -1)
+		s.opens = append(s.opens, s.pos)
+		s.pos++
+		s.end = s.pos
+		s.state = scanArrayStart
+		return c, nil
+	case '{':
+		if s.uniqueKeys {
+			s.keySets = append(s.keySets, make(map[string]bool))
+		}
+		s.stack = append(s.stack, c)
+		s.path = append(s.path, -1)
+		s.opens = append(s.opens, s.pos)
+		s.pos++
+		s.end = s.pos
+		s.state = scanObjectStart
+		return c, nil
+	case '"':
+		err = s.scanString()
+	case 't':
+		err = s.scanLiteral("true")
+	case 'f':
+		err = s.scanLiteral("false")
+	case 'n':
+		err = s.scanLiteral("null")
+	default:
+		k = kindNumber
+		if c == '\'' && s.relaxed {
+			k = kindString
+			err = s.scanString()
+		} else if lit := s.nonFiniteLiteral(c); lit != "" {
+			err = s.scanLiteral(lit)
+		} else {
+			err = s.scanNumber()
+		}
+	}
+	if err == nil {
+		s.valueStart = s.start
+		s.valueEnded()
+	}
+	return k, err
+}
+
+// valueEnded updates the state after a complete value has been scanned.
+func (s *scanner) valueEnded() {
+	s.valueEnd = s.end
+	if n := len(s.stack); n > 0 {
+		if s.stack[n-1] == '[' {
+			s.state = scanArrayComma
+		} else {
+			s.state = scanObjectComma
+		}
+	} else {
+		s.state = scanTopValue
+	}
+}
+
+func (s *scanner) skipSpace() (byte, bool) {
+	newlines := 0
+	for s.pos < len(s.buf) {
+		c := s.buf[s.pos]
+		switch c {
+		case '\n':
+			newlines++
+			s.pos++
+		case ' ', '\t', '\r':
+			s.pos++
+		case '/':
+			if !s.allowComments {
+				return c, true
+			}
+			end := s.commentEnd()
+			if end < 0 {
+				return c, true
+			}
+			if s.keepComments {
+				s.keepBlankLine(newlines)
+				s.comments = append(s.comments, string(s.buf[s.pos:end]))
+			}
+			newlines = 0
+			s.pos = end
+		default:
+			if s.keepComments {
+				s.keepBlankLine(newlines)
+			}
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// commentEnd returns the position after the comment that starts at the current position, or -1 if no comment starts
+// there or if it is an unterminated block comment.
+func (s *scanner) commentEnd() int {
+	switch s.at(s.pos + 1) {
+	case '/':
+		for i := s.pos + 2; i < len(s.buf); i++ {
+			if s.buf[i] == '\n' {
+				return i
+			}
+		}
+		return len(s.buf)
+	case '*':
+		for i := s.pos + 2; i+1 < len(s.buf); i++ {
+			if s.buf[i] == '*' && s.buf[i+1] == '/' {
+				return i + 2
+			}
+		}
+	}
+	return -1
+}
+
+// keepBlankLine records a blank line if the given number of newlines separated the previous token or comment from
+// the next one.
+func (s *scanner) keepBlankLine(newlines int) {
+	if newlines > 1 && (s.end > 0 || len(s.comments) > 0) {
+		s.comments = append(s.comments, "")
+	}
+}
+
+// takeComments returns the comments and blank lines that were collected since the last call.
+func (s *scanner) takeComments() []string {
+	cs := s.comments
+	s.comments = nil
+	return cs
+}
+
+// scanString scans a string that is quoted with the character at the current position, i.e. a double quote or, when
+// the syntax is relaxed, a single quote.
+func (s *scanner) scanString() error {
+	s.escaped = false
+	q := s.buf[s.pos]
+	for i := s.pos + 1; i < len(s.buf); i++ {
+		c := s.buf[i]
+		switch {
+		case c == q:
+			s.pos = i + 1
+			s.end = s.pos
+			if s.escapes == EscapesPreserve {
+				s.escaped = false
+			}
+			return nil
+		case c == '\\':
+			s.escaped = true
+			i++
+			if i >= len(s.buf) {
+				return io.ErrUnexpectedEOF
+			}
+			switch e := s.buf[i]; {
+			case e == '"', e == '\\', e == '/', e == '\'' && s.relaxed:
+			case e == 'b', e == 'f', e == 'n', e == 'r', e == 't':
+				if s.escapes == EscapesReject {
+					return s.escapeError(i-1, 2)
+				}
+			case e == 'u':
+				if i+4 >= len(s.buf) {
+					return io.ErrUnexpectedEOF
+				}
+				r, ok := hex4(s.buf[i+1 : i+5])
+				if !ok {
+					s.pos = i
+					return s.syntaxError(s.buf[i+1], "in \\u hexadecimal character escape")
+				}
+				if s.escapes == EscapesReject {
+					return s.escapeError(i-1, 6)
+				}
+				if s.strict && utf16.IsSurrogate(r) {
+					if err := s.checkSurrogatePair(r, i-1); err != nil {
+						return err
+					}
+					i += 6
+				}
+				i += 4
+			default:
+				s.pos = i
+				return s.syntaxError(s.buf[i], "in string escape code")
+			}
+		case c < ' ':
+			s.pos = i
+			return s.syntaxError(c, "in string literal")
+		case c >= utf8.RuneSelf:
+			s.escaped = true
+			if s.strict {
+				r, n := utf8.DecodeRune(s.buf[i:])
+				if r == utf8.RuneError && n == 1 {
+					s.pos = i
+					return fmt.Errorf("invalid UTF-8 in string literal at offset %d", s.base+i)
+				}
+				i += n - 1
+			}
+		}
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// escapeError returns the error for the escape of the given length that starts at position i when escapes are
+// rejected.
+func (s *scanner) escapeError(i, n int) error {
+	s.pos = i
+	return fmt.Errorf("disallowed escape %s in string literal at offset %d", s.buf[i:i+n], s.base+i)
+}
+
+// checkSurrogatePair asserts that the \u escape of the given surrogate that starts at position i is the first half of
+// a surrogate pair and that it is followed by a \u escape of the second half.
+func (s *scanner) checkSurrogatePair(r rune, i int) error {
+	if r < 0xdc00 && i+12 <= len(s.buf) && s.buf[i+6] == '\\' && s.buf[i+7] == 'u' {
+		if r2, ok := hex4(s.buf[i+8 : i+12]); ok && utf16.DecodeRune(r, r2) != utf8.RuneError {
+			return nil
+		}
+	}
+	s.pos = i
+	return fmt.Errorf("invalid lone surrogate %s in string literal at offset %d", s.buf[i:i+6], s.base+i)
+}
+
+// checkUniqueKey asserts that the last scanned object key hasn't been scanned before in the same object.
+func (s *scanner) checkUniqueKey() error {
+	keys := s.keySets[len(s.keySets)-1]
+	key := s.lastKey()
+	if keys[key] {
+		s.pos = s.keyStart
+		return fmt.Errorf("duplicate key %q in object at offset %d", key, s.base+s.keyStart)
+	}
+	keys[key] = true
+	return nil
+}
+
+// contents returns the bytes between the quotes of the string at buf[start:end], or the bytes of a bare identifier key.
+func (s *scanner) contents(start, end int) []byte {
+	if c := s.buf[start]; c == '"' || c == '\'' {
+		return s.buf[start+1 : end-1]
+	}
+	return s.buf[start:end]
+}
+
+// keyEndAt returns the end of the quoted object key, or of the bare identifier key, that starts at the given position.
+func (s *scanner) keyEndAt(p int) int {
+	q := s.buf[p]
+	if q != '"' && q != '\'' {
+		for p < len(s.buf) && isIdentifierPart(s.buf[p]) {
+			p++
+		}
+		return p
+	}
+	for p++; s.buf[p] != q; p++ {
+		if s.buf[p] == '\\' {
+			p++
+		}
+	}
+	return p + 1
+}
+
+func isIdentifierStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == '$'
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || '0' <= c && c <= '9'
+}
+
+// stringValue returns the unquoted value of the last scanned string.
+func (s *scanner) stringValue() string {
+	raw := s.contents(s.start, s.end)
+	if !s.escaped {
+		return string(raw)
+	}
+	return string(unquoteBytes(make([]byte, 0, len(raw)), raw))
+}
+
+// lastKey returns the unquoted value of the last scanned object key.
+func (s *scanner) lastKey() string {
+	if s.keyEnd == 0 {
+		return ""
+	}
+	raw := s.contents(s.keyStart, s.keyEnd)
+	if !s.keyEscaped {
+		return string(raw)
+	}
+	return string(unquoteBytes(make([]byte, 0, len(raw)), raw))
+}
+
+// snapshot returns a copy of the scanner that doesn't share any mutable state with it, so that the scanner can be
+// restored by assigning the copy to it.
+func (s *scanner) snapshot() scanner {
+	c := *s
+	c.stack = append([]byte(nil), s.stack...)
+	c.path = append([]int(nil), s.path...)
+	c.opens = append([]int(nil), s.opens...)
+	if s.keySets != nil {
+		c.keySets = make([]map[string]bool, len(s.keySets))
+		for i, keys := range s.keySets {
+			if keys != nil {
+				c.keySets[i] = make(map[string]bool, len(keys))
+				for k := range keys {
+					c.keySets[i][k] = true
+				}
+			}
+		}
+	}
+	return c
+}
+
+// pointer returns a JSON Pointer to the value that was last scanned, or to the value of the last scanned key.
+func (s *scanner) pointer() string {
+	var b strings.Builder
+	for i, p := range s.path {
+		if p < 0 {
+			break
+		}
+		b.WriteByte('/')
+		if s.stack[i] == '[' {
+			b.WriteString(strconv.Itoa(p))
+			continue
+		}
+		b.WriteString(escapePointerKey(string(unquoteBytes(nil, s.contents(p, s.keyEndAt(p))))))
+	}
+	return b.String()
+}
+
+// unquoteBytes appends the unquoted form of the contents of a valid JSON string literal to dst. Invalid UTF-8 and
+// invalid surrogates are replaced by utf8.RuneError.
+func unquoteBytes(dst, raw []byte) []byte {
+	for i := 0; i < len(raw); {
+		c := raw[i]
+		switch {
+		case c == '\\':
+			c = raw[i+1]
+			i += 2
+			switch c {
+			case 'b':
+				dst = append(dst, '\b')
+			case 'f':
+				dst = append(dst, '\f')
+			case 'n':
+				dst = append(dst, '\n')
+			case 'r':
+				dst = append(dst, '\r')
+			case 't':
+				dst = append(dst, '\t')
+			case 'u':
+				var r rune
+				r, i = unquoteRune(raw, i)
+				dst = appendRune(dst, r)
+			default:
+				dst = append(dst, c)
+			}
+		case c < utf8.RuneSelf:
+			dst = append(dst, c)
+			i++
+		default:
+			r, n := utf8.DecodeRune(raw[i:])
+			dst = appendRune(dst, r)
+			i += n
+		}
+	}
+	return dst
+}
+
+// unquoteRune decodes the hexadecimal digits of a \u escape that starts at raw[i] and, if it is the first half of
+// a surrogate pair, also the escape that follows. It returns the rune and the position after the consumed bytes.
+func unquoteRune(raw []byte, i int) (rune, int) {
+	r, _ := hex4(raw[i : i+4])
+	i += 4
+	if utf16.IsSurrogate(r) {
+		if i+6 <= len(raw) && raw[i] == '\\' && raw[i+1] == 'u' {
+			r2, _ := hex4(raw[i+2 : i+6])
+			if dr := utf16.DecodeRune(r, r2); dr != utf8.RuneError {
+				return dr, i + 6
+			}
+		}
+		r = utf8.RuneError
+	}
+	return r, i
+}
+
+// unquoteReader is an io.Reader that unquotes the contents of a valid JSON string literal while it is read.
+type unquoteReader struct {
+	raw     []byte
+	pending []byte
+	buf     [utf8.UTFMax]byte
+}
+
+func (u *unquoteReader) Read(p []byte) (int, error) {
+	n := copy(p, u.pending)
+	u.pending = u.pending[n:]
+	for n < len(p) && len(u.raw) > 0 {
+		if c := u.raw[0]; c != '\\' && c < utf8.RuneSelf {
+			// copy the run of plain ASCII as is
+			i := 1
+			for i < len(u.raw) && i < len(p)-n && u.raw[i] != '\\' && u.raw[i] < utf8.RuneSelf {
+				i++
+			}
+			n += copy(p[n:], u.raw[:i])
+			u.raw = u.raw[i:]
+			continue
+		}
+		var i int
+		switch {
+		case u.raw[0] != '\\':
+			_, i = utf8.DecodeRune(u.raw)
+		case u.raw[1] == 'u':
+			_, i = unquoteRune(u.raw, 2)
+		default:
+			i = 2
+		}
+		u.pending = unquoteBytes(u.buf[:0], u.raw[:i])
+		u.raw = u.raw[i:]
+		m := copy(p[n:], u.pending)
+		u.pending = u.pending[m:]
+		n += m
+	}
+	if n == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func appendRune(dst []byte, r rune) []byte {
+	if r < utf8.RuneSelf {
+		return append(dst, byte(r))
+	}
+	var b [utf8.UTFMax]byte
+	n := utf8.EncodeRune(b[:], r)
+	return append(dst, b[:n]...)
+}
+
+// hex4 parses four hexadecimal digits.
+func hex4(b []byte) (rune, bool) {
+	var r rune
+	for _, c := range b {
+		switch {
+		case '0' <= c && c <= '9':
+			c -= '0'
+		case 'a' <= c && c <= 'f':
+			c = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
+
+func (s *scanner) scanLiteral(lit string) error {
+	for i := 0; i < len(lit); i++ {
+		p := s.pos + i
+		if p >= len(s.buf) {
+			return io.ErrUnexpectedEOF
+		}
+		if s.buf[p] != lit[i] {
+			s.pos = p
+			return s.syntaxError(s.buf[p], "in literal "+lit+" (expecting "+strconv.QuoteRune(rune(lit[i]))+")")
+		}
+	}
+	s.pos += len(lit)
+	s.end = s.pos
+	return s.checkDelimited()
+}
+
+// nonFiniteLiteral returns the non-finite literal that starts with the given byte at the current position when such
+// literals are allowed, or an empty string.
+func (s *scanner) nonFiniteLiteral(c byte) string {
+	if s.nonFinite {
+		switch {
+		case c == 'N':
+			return "NaN"
+		case c == 'I':
+			return "Infinity"
+		case c == '-' && s.at(s.pos+1) == 'I':
+			return "-Infinity"
+		}
+	}
+	return ""
+}
+
+// scanNumber scans a number in accordance with the JSON number grammar.
+func (s *scanner) scanNumber() error {
+	i := s.pos
+	if s.at(i) == '-' {
+		i++
+	}
+	switch c := s.at(i); {
+	case c == '0':
+		i++
+	case '1' <= c && c <= '9':
+		i = s.digits(i + 1)
+	default:
+		return s.numberError(i, "looking for beginning of value")
+	}
+	if s.at(i) == '.' {
+		i++
+		if !isDigit(s.at(i)) {
+			return s.numberError(i, "after decimal point in numeric literal")
+		}
+		i = s.digits(i + 1)
+	}
+	if c := s.at(i); c == 'e' || c == 'E' {
+		i++
+		if c = s.at(i); c == '+' || c == '-' {
+			i++
+		}
+		if !isDigit(s.at(i)) {
+			return s.numberError(i, "in exponent of numeric literal")
+		}
+		i = s.digits(i + 1)
+	}
+	s.pos = i
+	s.end = i
+	return s.checkDelimited()
+}
+
+func (s *scanner) numberError(i int, context string) error {
+	if i >= len(s.buf) {
+		return io.ErrUnexpectedEOF
+	}
+	s.pos = i
+	return s.syntaxError(s.buf[i], context)
+}
+
+// checkDelimited asserts that the scalar that ends at the current position isn't immediately followed by a letter
+// or a digit.
+func (s *scanner) checkDelimited() error {
+	if c := s.at(s.pos); isDigit(c) || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+		return s.syntaxError(c, "after value")
+	}
+	return nil
+}
+
+func (s *scanner) digits(i int) int {
+	for isDigit(s.at(i)) {
+		i++
+	}
+	return i
+}
+
+// at returns the byte at the given position or zero when the position is beyond the end of the input.
+func (s *scanner) at(i int) byte {
+	if i < len(s.buf) {
+		return s.buf[i]
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func (s *scanner) syntaxError(c byte, context string) error {
+	return fmt.Errorf("invalid character %q %s at offset %d", c, context, s.base+s.pos)
+}
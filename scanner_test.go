@@ -0,0 +1,438 @@
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/tada/catch"
+)
+
+func jsonTokens(t *testing.T, s string) ([]json.Token, error) {
+	t.Helper()
+	jd := json.NewDecoder(bytes.NewReader([]byte(s)))
+	jd.UseNumber()
+	return allTokens(jd)
+}
+
+func scannerTokens(s string) ([]json.Token, error) {
+	return allTokens(newScanner([]byte(s), &decoderOptions{}))
+}
+
+func allTokens(ts TokenSource) ([]json.Token, error) {
+	var tokens []json.Token
+	for {
+		t, err := ts.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return tokens, err
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+func TestScanner_sameTokensAsJSONDecoder(t *testing.T) {
+	inputs := []string{
+		``,
+		` `,
+		`{}`,
+		`[]`,
+		` { "a" : 1 , "b" : [ true , false , null ] , "c" : { } } `,
+		`[1, -1, 0, -0, 1.5, -0.25e10, 1E+2, 3e-4, 12345678901234567890]`,
+		`"a" "b" 1 [] {}`,
+		`"esc\"aped\\\/\b\f\n\r\t"`,
+		`"åäö 😀"`,
+		`"\ud83d"`,
+		`"\ud83dA"`,
+		`"\ude00"`,
+		`"\uD83D\uDE00 \u0041\u00e5"`,
+		`"\ud83d\u0041"`,
+		"\"\xff\xfe\"",
+		`"räksmörgås"`,
+		`[[[["deep"]]]]`,
+	}
+	for _, input := range inputs {
+		ex, err := jsonTokens(t, input)
+		if err != nil {
+			t.Fatalf("%q: json.Decoder failed: %v", input, err)
+		}
+		ac, err := scannerTokens(input)
+		if err != nil {
+			t.Fatalf("%q: scanner failed: %v", input, err)
+		}
+		if !reflect.DeepEqual(ex, ac) {
+			t.Errorf("%q: expected %v, got %v", input, ex, ac)
+		}
+	}
+}
+
+func TestScanner_errors(t *testing.T) {
+	inputs := map[string]string{
+		`[1,]`:         `invalid character ']' looking for beginning of value at offset 3`,
+		`[1 2]`:        `invalid character '2' after array element at offset 3`,
+		`{"a":1 "b"}`:  `invalid character '"' after object key:value pair at offset 7`,
+		`{"a" 1}`:      `invalid character '1' after object key at offset 5`,
+		`{"a`:          `unexpected EOF`,
+		`{1:2}`:        `invalid character '1' looking for beginning of object key string at offset 1`,
+		`{"a":1,}`:     `invalid character '}' looking for beginning of value at offset 7`,
+		`:`:            `invalid character ':' looking for beginning of value at offset 0`,
+		`,`:            `invalid character ',' looking for beginning of value at offset 0`,
+		`]`:            `invalid character ']' looking for beginning of value at offset 0`,
+		`[}`:           `invalid character '}' looking for beginning of value at offset 1`,
+		`x`:            `invalid character 'x' looking for beginning of value at offset 0`,
+		`tru`:          `unexpected EOF`,
+		`trux`:         `invalid character 'x' in literal true (expecting 'e') at offset 3`,
+		`nulls`:        `invalid character 's' after value at offset 4`,
+		`01`:           `invalid character '1' after value at offset 1`,
+		`-`:            `unexpected EOF`,
+		`-a`:           `invalid character 'a' looking for beginning of value at offset 1`,
+		`1.`:           `unexpected EOF`,
+		`1.e`:          `invalid character 'e' after decimal point in numeric literal at offset 2`,
+		`1e`:           `unexpected EOF`,
+		`1e+x`:         `invalid character 'x' in exponent of numeric literal at offset 3`,
+		`"abc`:         `unexpected EOF`,
+		`"\`:           `unexpected EOF`,
+		`"\x"`:         `invalid character 'x' in string escape code at offset 2`,
+		`"\u12"`:       `unexpected EOF`,
+		`"\u12x4"`:     `invalid character '1' in \u hexadecimal character escape at offset 2`,
+		"\"a\tb\"":     `invalid character '\t' in string literal at offset 2`,
+		`[`:            `unexpected EOF`,
+		`{"a":`:        `unexpected EOF`,
+		`{"a":[1,2]`:   `unexpected EOF`,
+		`[1,2]]`:       `invalid character ']' looking for beginning of value at offset 5`,
+		`{"a":1}}`:     `invalid character '}' looking for beginning of value at offset 7`,
+		`{"a"::1}`:     `invalid character ':' looking for beginning of value at offset 5`,
+		`[1,,2]`:       `invalid character ',' looking for beginning of value at offset 3`,
+		`{"a":1,,}`:    `invalid character ',' looking for beginning of value at offset 7`,
+		`{"a":1,"b"}`:  `invalid character '}' after object key at offset 10`,
+		`{"a":1, 2:3}`: `invalid character '2' looking for beginning of object key string at offset 8`,
+	}
+	for input, ex := range inputs {
+		_, err := scannerTokens(input)
+		if err == nil {
+			t.Errorf("%q: scanner did not fail", input)
+		} else if err.Error() != ex {
+			t.Errorf("%q: expected error %q, got %q", input, ex, err.Error())
+		}
+	}
+}
+
+func TestNewBytesDecoder(t *testing.T) {
+	js := NewBytesDecoder([]byte(`{"m":"message","i":42}`))
+	if js.JSONDecoder() != nil {
+		t.Fatal("expected JSONDecoder() to return nil")
+	}
+	tc := &testConsumer{t: t}
+	err := catch.Do(func() {
+		js.ReadConsumer(tc)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(tc.m == "message" && tc.i == 42) {
+		t.Fatal("unexpected consumer values")
+	}
+}
+
+func TestScanner_strictStrings(t *testing.T) {
+	valid := map[string]string{
+		`"åäö 😀"`:               "åäö 😀",
+		`"\ud83d\ude00 \u0041"`: "😀 A",
+		`"plain"`:               "plain",
+	}
+	for input, ex := range valid {
+		var a string
+		err := catch.Do(func() {
+			a = NewBytesDecoder([]byte(input), StrictStrings()).ReadString()
+		})
+		if err != nil || a != ex {
+			t.Errorf("%q: expected %q, got %q, %v", input, ex, a, err)
+		}
+	}
+	inputs := map[string]string{
+		"\"a\xffb\"": `invalid UTF-8 in string literal at offset 2`,
+		`"\ud83d"`:   `invalid lone surrogate \ud83d in string literal at offset 1`,
+		`"a\ude00"`:  `invalid lone surrogate \ude00 in string literal at offset 2`,
+		`"\ud83dA"`:  `invalid lone surrogate \ud83d in string literal at offset 1`,
+	}
+	for input, ex := range inputs {
+		err := catch.Do(func() {
+			NewBytesDecoder([]byte(input), StrictStrings()).ReadString()
+		})
+		if err == nil {
+			t.Errorf("%q: decoder did not fail", input)
+		} else if err.Error() != ex {
+			t.Errorf("%q: expected error %q, got %q", input, ex, err.Error())
+		}
+	}
+}
+
+func TestScanner_strict(t *testing.T) {
+	o := newDecoderOptions([]DecoderOption{Strict(), ObjectsOnly()})
+	valid := []string{`{"a": {"a": 1, "b": [{"a": 2}, {"a": 3}]}, "b": 2} {"a": 1}`, `{}`}
+	for _, input := range valid {
+		if _, err := allTokens(newScanner([]byte(input), o)); err != nil {
+			t.Errorf("%q: unexpected error %v", input, err)
+		}
+	}
+	inputs := map[string]string{
+		`{"a": 1, "b": {}, "a": 2}`: `duplicate key "a" in object at offset 18`,
+		`{"ab": 1, "ab": 2}`:        `duplicate key "ab" in object at offset 10`,
+		`{"a": 01}`:                 `invalid character '1' after value at offset 7`,
+		`{} 1`:                      `invalid character '1' looking for beginning of top level object at offset 3`,
+		`[{}]`:                      `invalid character '[' looking for beginning of top level object at offset 0`,
+		`"\ud83d"`:                  `invalid character '"' looking for beginning of top level object at offset 0`,
+		`{"s": "\ud83d"}`:           `invalid lone surrogate \ud83d in string literal at offset 7`,
+	}
+	for input, ex := range inputs {
+		_, err := allTokens(newScanner([]byte(input), o))
+		if err == nil {
+			t.Errorf("%q: scanner did not fail", input)
+		} else if err.Error() != ex {
+			t.Errorf("%q: expected error %q, got %q", input, ex, err.Error())
+		}
+	}
+
+	js := NewBytesDecoder([]byte(`{"a": 1, "b": 2}`), Strict())
+	err := catch.Do(func() {
+		js.ReadDelim('{')
+		js.Mark()
+		js.ReadObjectFields(nil, nil)
+		js.Reset()
+		js.ReadObjectFields(nil, nil)
+	})
+	if err != nil {
+		t.Errorf("unexpected error after Reset %v", err)
+	}
+}
+
+func TestScanner_escapes(t *testing.T) {
+	input := []byte(`{"k\n": "a\u0041\n", "\"": "\/\"\\"}`)
+	var a []string
+	err := catch.Do(func() {
+		js := NewBytesDecoder(input, Escapes(EscapesPreserve))
+		js.ReadDelim('{')
+		js.ReadObjectFields(nil, func(key string, d Decoder) {
+			a = append(a, key, d.ReadString())
+		})
+		bs, _ := ioutil.ReadAll(NewBytesDecoder(input[8:], Escapes(EscapesPreserve)).ReadStringReader())
+		a = append(a, string(bs))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := []string{`k\n`, `a\u0041\n`, `\"`, `\/\"\\`, `a\u0041\n`}; fmt.Sprint(a) != fmt.Sprint(ex) {
+		t.Errorf("expected %q, got %q", ex, a)
+	}
+
+	if _, err := allTokens(newScanner(input[:6], &decoderOptions{escapes: EscapesReject})); err == nil {
+		t.Error("expected error for escaped key")
+	}
+	inputs := map[string]string{
+		`"a\u0041"`: `disallowed escape \u0041 in string literal at offset 2`,
+		`"a \t"`:    `disallowed escape \t in string literal at offset 3`,
+	}
+	for input, ex := range inputs {
+		_, err := allTokens(newScanner([]byte(input), &decoderOptions{escapes: EscapesReject}))
+		if err == nil {
+			t.Errorf("%q: scanner did not fail", input)
+		} else if err.Error() != ex {
+			t.Errorf("%q: expected error %q, got %q", input, ex, err.Error())
+		}
+	}
+	if _, err := allTokens(newScanner([]byte(`"\"\/\\"`), &decoderOptions{escapes: EscapesReject})); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestScanner_nonFinite(t *testing.T) {
+	input := []byte(`[NaN, Infinity, -Infinity, -1.5, {"a": NaN}]`)
+	var fs []float64
+	var any interface{}
+	err := catch.Do(func() {
+		js := NewBytesDecoder(input, AllowNonFinite())
+		js.ReadDelim('[')
+		for i := 0; i < 4; i++ {
+			fs = append(fs, js.ReadFloat())
+		}
+		if js.LastNumberIntegral() {
+			t.Error("expected non-integral number")
+		}
+		any = js.ReadAny()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(fs[0]) || !math.IsInf(fs[1], 1) || !math.IsInf(fs[2], -1) || fs[3] != -1.5 {
+		t.Errorf("unexpected floats %v", fs)
+	}
+	if f, ok := any.(map[string]interface{})["a"].(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("expected NaN, got %v", any)
+	}
+	for input, ex := range map[string]string{
+		`[NaN]`:       `invalid character 'N' looking for beginning of value at offset 1`,
+		`[-Infinity]`: `invalid character 'I' looking for beginning of value at offset 2`,
+		`[Infinite]`:  `invalid character 'e' in literal Infinity (expecting 'y') at offset 8`,
+		`[Infinityx]`: `invalid character 'x' after value at offset 9`,
+	} {
+		var options []DecoderOption
+		if input != `[NaN]` && input != `[-Infinity]` {
+			options = append(options, AllowNonFinite())
+		}
+		err := catch.Do(func() {
+			NewBytesDecoder([]byte(input), options...).Skip()
+		})
+		if err == nil || err.Error() != ex {
+			t.Errorf("%s: expected error %q, got %v", input, ex, err)
+		}
+	}
+	if _, err := allTokens(newScanner([]byte(`[1, NaN]`), &decoderOptions{nonFinite: true})); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestScanner_relaxed(t *testing.T) {
+	input := []byte(`{name: 'O\'Brien', "q": 'say "hi"', $id_2: [1, 'x'], 'k\n': {a: 'b'}}`)
+	var ptrs []string
+	var v interface{}
+	err := catch.Do(func() {
+		js := NewBytesDecoder(input, Relaxed())
+		js.ReadDelim('{')
+		for _, ok := js.ReadStringOrEnd('}'); ok; _, ok = js.ReadStringOrEnd('}') {
+			ptrs = append(ptrs, js.CurrentPath())
+			js.Skip()
+		}
+		v = NewBytesDecoder(input, Relaxed()).ReadAny()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := `[/name /q /$id_2 /k` + "\n" + `]`; fmt.Sprint(ptrs) != ex {
+		t.Errorf("expected pointers %s, got %s", ex, ptrs)
+	}
+	ex := map[string]interface{}{
+		"name": "O'Brien", "q": `say "hi"`, "$id_2": []interface{}{1.0, "x"}, "k\n": map[string]interface{}{"a": "b"}}
+	if !reflect.DeepEqual(v, ex) {
+		t.Errorf("expected %v, got %v", ex, v)
+	}
+	for input, ex := range map[string]string{
+		`{a: 1}`:   `invalid character 'a' looking for beginning of object key string at offset 1`,
+		`['a']`:    `invalid character '\'' looking for beginning of value at offset 1`,
+		`{1a: 1}`:  `invalid character '1' looking for beginning of object key string at offset 1`,
+		`{a b: 1}`: `invalid character 'b' after object key at offset 3`,
+	} {
+		var options []DecoderOption
+		if input != `{a: 1}` && input != `['a']` {
+			options = append(options, Relaxed())
+		}
+		err := catch.Do(func() {
+			NewBytesDecoder([]byte(input), options...).Skip()
+		})
+		if err == nil || err.Error() != ex {
+			t.Errorf("%s: expected error %q, got %v", input, ex, err)
+		}
+	}
+}
+
+func TestNewDecoder_syntaxOptions(t *testing.T) {
+	tests := []struct {
+		input  string
+		option DecoderOption
+		err    string
+	}{
+		{`{"a": 1, "b": {}, "a": 2}`, Strict(), `duplicate key "a" in object at offset 18`},
+		{"\"a\xffb\"", Strict(), `invalid UTF-8 in string literal at offset 2`},
+		{`{"s": "\ud83d"}`, StrictStrings(), `invalid lone surrogate \ud83d in string literal at offset 7`},
+		{`"\ud83d\ude00" "\\"`, StrictStrings(), ``},
+		{`{} 1`, ObjectsOnly(), `invalid character '1' looking for beginning of top level object at offset 3`},
+		{`"a\u0041"`, Escapes(EscapesReject), `disallowed escape \u0041 in string literal at offset 2`},
+		{`{"k\n": "a\u0041\n"}`, Escapes(EscapesPreserve), ``},
+		{`[NaN, Infinity, -Infinity, -1.5e3, 12]`, AllowNonFinite(), ``},
+		{`[Infinite]`, AllowNonFinite(), `invalid character 'e' in literal Infinity (expecting 'y') at offset 8`},
+		{`{name: 'O\'Brien', $id_2: [1, 'x\\']}`, Relaxed(), ``},
+		{"// c\n{\"a\": /* b **/ 1} // end", AllowComments(), ``},
+		{"[1] /* x", AllowComments(), `invalid character '/' looking for beginning of value at offset 4`},
+		{"[1 /x]", AllowComments(), `invalid character '/' after array element at offset 3`},
+		{`[1, true, "x"`, Strict(), `unexpected EOF`},
+		{`["` + strings.Repeat(`\\x`, 5000) + `"]`, Strict(), ``},
+	}
+	for _, tc := range tests {
+		ex, err := allTokens(NewBytesDecoder([]byte(tc.input), tc.option).(TokenSource))
+		if fmt.Sprint(err) != fmt.Sprint(tc.err) && !(err == nil && tc.err == "") {
+			t.Fatalf("%q: expected error %q from bytes decoder, got %v", tc.input, tc.err, err)
+		}
+		for _, r := range []io.Reader{strings.NewReader(tc.input), iotest.OneByteReader(strings.NewReader(tc.input))} {
+			ac, err := allTokens(NewDecoder(r, tc.option).(TokenSource))
+			if fmt.Sprint(err) != fmt.Sprint(tc.err) && !(err == nil && tc.err == "") {
+				t.Errorf("%q: expected error %q, got %v", tc.input, tc.err, err)
+			}
+			if !reflect.DeepEqual(ex, ac) {
+				t.Errorf("%q: expected %v, got %v", tc.input, ex, ac)
+			}
+		}
+	}
+	if js := NewDecoder(strings.NewReader(`{}`), Strict()); js.JSONDecoder() != nil {
+		t.Error("expected no json.Decoder")
+	}
+	err := Valid(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader(`[1, 2]`))), ValidSyntax(Strict()))
+	if !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestNewDecoder_syntaxOptionsStream(t *testing.T) {
+	// enough values to make the buffer of the scanner be compacted several times
+	var b strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, "{\"i\": %d, \"s\": \"%s\"}\n", i, strings.Repeat("x", i%50))
+	}
+	b.WriteString(`{"i": 1, "i": 2}`)
+	input := b.String()
+
+	read := func(js Decoder) (n int64, start, end int64, err error) {
+		c := consumerFunc(func(js Decoder, firstToken json.Token) {
+			AssertDelim(firstToken, '{')
+			js.PeekN(3)
+			js.ReadObjectFields(map[string]func(Decoder){"i": func(js Decoder) { n += js.ReadInt() }}, nil)
+		})
+		err = catch.Do(func() {
+			for i := 0; ; i++ {
+				again := i%100 == 0
+				if again {
+					js.Mark()
+				}
+				if !js.ReadConsumer(c) {
+					return
+				}
+				if again {
+					js.Reset()
+					js.Skip()
+				}
+				start, end = js.ValueSpan()
+			}
+		})
+		return
+	}
+	exN, exStart, exEnd, exErr := read(NewBytesDecoder([]byte(input), Strict()))
+	if exErr == nil || !strings.HasPrefix(exErr.Error(), `duplicate key "i" in object at offset`) {
+		t.Fatalf("expected duplicate key error, got %v", exErr)
+	}
+	js := NewDecoder(iotest.HalfReader(strings.NewReader(input)), Strict())
+	n, start, end, err := read(js)
+	if a, ex := fmt.Sprint(n, start, end, err), fmt.Sprint(exN, exStart, exEnd, exErr); a != ex {
+		t.Errorf("expected %s, got %s", ex, a)
+	}
+	if s := js.(*decoder).TokenSource.(*scanner); s.base == 0 || cap(s.buf) > 8192 {
+		t.Errorf("expected compacted buffer, got base %d and capacity %d", s.base, cap(s.buf))
+	}
+}
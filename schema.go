@@ -0,0 +1,218 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/tada/catch"
+)
+
+// schema types in the order they are written
+const (
+	schemaNull = 1 << iota
+	schemaBoolean
+	schemaInteger
+	schemaNumber
+	schemaString
+	schemaArray
+	schemaObject
+)
+
+// A SchemaInferrer infers a JSON Schema from sample documents. The schema describes the types of the values found at
+// each location, the fields that are present in all sampled objects, the range of numbers, and, for strings that take
+// few distinct values, the candidate values of an enum. The inferred schema is written by MarshalToJSON, which makes
+// the SchemaInferrer a Streamer.
+type SchemaInferrer struct {
+	root    schemaNode
+	maxEnum int
+}
+
+type schemaNode struct {
+	types      int
+	min        float64
+	max        float64
+	strings    int
+	enum       []string
+	noEnum     bool
+	items      *schemaNode
+	objects    int
+	keys       []string
+	properties map[string]*schemaNode
+	present    int
+}
+
+// NewSchemaInferrer creates a new SchemaInferrer that lists at most maxEnum distinct strings as enum candidates. A
+// string location is only considered an enum when some of its values are repeated.
+func NewSchemaInferrer(maxEnum int) *SchemaInferrer {
+	return &SchemaInferrer{maxEnum: maxEnum}
+}
+
+// Add reads the JSON values from the given reader and adds each one of them as a sample document.
+//
+// The function will recover a catch.Error panic and return its cause.
+func (s *SchemaInferrer) Add(r io.Reader) error {
+	return catch.Do(func() {
+		d := NewDecoder(r).(*decoder)
+		for {
+			t, err := d.Token()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				panic(d.unexpectedError(err))
+			}
+			s.root.add(s, d, t)
+		}
+	})
+}
+
+func (n *schemaNode) add(s *SchemaInferrer, d *decoder, t json.Token) {
+	switch t := t.(type) {
+	case json.Delim:
+		if t == '[' {
+			n.types |= schemaArray
+			if n.items == nil {
+				n.items = &schemaNode{}
+			}
+			for {
+				e := nextToken(d)
+				if isDelim(e, ']') {
+					return
+				}
+				n.items.add(s, d, e)
+			}
+		}
+		n.types |= schemaObject
+		n.objects++
+		if n.properties == nil {
+			n.properties = make(map[string]*schemaNode)
+		}
+		for {
+			k, ok := nextToken(d).(string)
+			if !ok {
+				return
+			}
+			p := n.properties[k]
+			if p == nil {
+				p = &schemaNode{}
+				n.properties[k] = p
+				n.keys = append(n.keys, k)
+			}
+			p.present++
+			p.add(s, d, nextToken(d))
+		}
+	case string:
+		n.types |= schemaString
+		n.strings++
+		if !n.noEnum {
+			for _, e := range n.enum {
+				if e == t {
+					return
+				}
+			}
+			if len(n.enum) < s.maxEnum {
+				n.enum = append(n.enum, t)
+			} else {
+				n.noEnum, n.enum = true, nil
+			}
+		}
+	case json.Number:
+		if f, err := t.Float64(); err == nil {
+			if n.types&(schemaInteger|schemaNumber) == 0 {
+				n.min, n.max = f, f
+			} else {
+				n.min, n.max = math.Min(n.min, f), math.Max(n.max, f)
+			}
+		}
+		if isIntegral([]byte(t)) {
+			n.types |= schemaInteger
+		} else {
+			n.types |= schemaNumber
+		}
+	case bool:
+		n.types |= schemaBoolean
+	case nil:
+		n.types |= schemaNull
+	}
+}
+
+// nextToken returns the next token of the decoder or raises a panic with a catch.Error.
+func nextToken(d *decoder) json.Token {
+	t, err := d.Token()
+	if err != nil {
+		panic(d.unexpectedError(err))
+	}
+	return t
+}
+
+// MarshalToJSON writes the schema that was inferred from the sample documents added so far.
+func (s *SchemaInferrer) MarshalToJSON(w io.Writer) {
+	o := NewObjectWriter(w)
+	o.String("$schema", "https://json-schema.org/draft/2020-12/schema")
+	s.root.writeFields(o)
+	o.Close()
+}
+
+// MarshalToJSON writes the schema of the node.
+func (n *schemaNode) MarshalToJSON(w io.Writer) {
+	o := NewObjectWriter(w)
+	n.writeFields(o)
+	o.Close()
+}
+
+func (n *schemaNode) writeFields(o *ObjectWriter) {
+	types := n.types
+	if types&schemaNumber != 0 {
+		types &^= schemaInteger
+	}
+	typeNames := [...]string{"null", "boolean", "integer", "number", "string", "array", "object"}
+	var names []string
+	for i, name := range typeNames {
+		if types&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 1 {
+		o.String("type", names[0])
+	} else if len(names) > 1 {
+		writeStrings(o, "type", names)
+	}
+	if types&(schemaInteger|schemaNumber) != 0 {
+		o.Float("minimum", n.min)
+		o.Float("maximum", n.max)
+	}
+	if len(n.enum) > 0 && !n.noEnum && n.strings > len(n.enum) {
+		writeStrings(o, "enum", n.enum)
+	}
+	if n.items != nil {
+		o.Streamer("items", n.items)
+	}
+	if n.properties != nil {
+		o.Field("properties")
+		po := NewObjectWriter(o.w)
+		for _, k := range n.keys {
+			po.Streamer(k, n.properties[k])
+		}
+		po.Close()
+		var required []string
+		for _, k := range n.keys {
+			if n.properties[k].present == n.objects {
+				required = append(required, k)
+			}
+		}
+		if len(required) > 0 {
+			writeStrings(o, "required", required)
+		}
+	}
+}
+
+// writeStrings writes a field with an array of strings.
+func writeStrings(o *ObjectWriter, key string, ss []string) {
+	o.Field(key)
+	a := NewArrayWriter(o.w)
+	for _, s := range ss {
+		a.String(s)
+	}
+	a.Close()
+}
@@ -0,0 +1,33 @@
+package jsonstream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaInferrer(t *testing.T) {
+	s := NewSchemaInferrer(3)
+	docs := []string{
+		`{"id":1, "state":"active", "score":1.5, "tags":["a"], "note":null}`,
+		`{"id":7, "state":"active", "tags":[], "note":"x"} {"id":3, "state":"pending", "score":-2, "tags":["b", "c"]}`,
+	}
+	for _, doc := range docs {
+		if err := s.Add(strings.NewReader(doc)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bs, err := Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+		`"id":{"type":"integer","minimum":1,"maximum":7},"state":{"type":"string","enum":["active","pending"]},` +
+		`"score":{"type":"number","minimum":-2,"maximum":1.5},"tags":{"type":"array","items":{"type":"string"}},` +
+		`"note":{"type":["null","string"]}},"required":["id","state","tags"]}`
+	if string(bs) != ex {
+		t.Fatalf("expected %s, got %s", ex, bs)
+	}
+	if err = s.Add(strings.NewReader(`{"id":`)); err == nil {
+		t.Fatal("expected an error for truncated input")
+	}
+}
@@ -0,0 +1,109 @@
+package jsonstream
+
+import (
+	"context"
+	"io"
+)
+
+// A Span is the part of a tracing span, such as an OpenTelemetry trace.Span, that a SpanTracer uses.
+type Span interface {
+	// SetInt64 sets an integer attribute of the span.
+	SetInt64(key string, value int64)
+
+	// RecordError records the given error as an event of the span.
+	RecordError(err error)
+
+	// End completes the span.
+	End()
+}
+
+// A SpanStarter starts the spans of a SpanTracer. It keeps this package free of a dependency on a tracing library. An
+// adapter for OpenTelemetry is typically written as:
+//
+//	type otelStarter struct{ tracer trace.Tracer }
+//
+//	func (s otelStarter) StartSpan(ctx context.Context, name string) jsonstream.Span {
+//		_, span := s.tracer.Start(ctx, name)
+//		return otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetInt64(key string, value int64) {
+//		s.SetAttributes(attribute.Int64(key, value))
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//		s.Span.RecordError(err)
+//		s.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() {
+//		s.Span.End()
+//	}
+type SpanStarter interface {
+	// StartSpan starts a span with the given name as a child of the span in the given context.
+	StartSpan(ctx context.Context, name string) Span
+}
+
+// A SpanTracer decodes and encodes values in the same way as the Unmarshal and Marshal functions of this package and
+// wraps each call in a span. The span is named after the function, e.g. jsonstream.Unmarshal, and has the attribute
+// jsonstream.bytes with the size of the document. When the Consumer or Streamer has a Len() int method, the span also
+// has the attribute jsonstream.elements with the number of elements that it holds after the call. An error returned
+// by the call is recorded on the span.
+type SpanTracer struct {
+	ss SpanStarter
+}
+
+// NewSpanTracer creates a new SpanTracer that starts its spans using the given SpanStarter.
+func NewSpanTracer(ss SpanStarter) *SpanTracer {
+	return &SpanTracer{ss: ss}
+}
+
+// Unmarshal is like the Unmarshal function of this package but wraps the call in a span.
+func (st *SpanTracer) Unmarshal(ctx context.Context, c Consumer, bs []byte) error {
+	span := st.ss.StartSpan(ctx, "jsonstream.Unmarshal")
+	err := Unmarshal(c, bs)
+	endSpan(span, int64(len(bs)), c, err)
+	return err
+}
+
+// UnmarshalReader is like the UnmarshalReader function of this package but wraps the call in a span. The size of the
+// document is the number of bytes read from the reader, which includes any bytes that the decoder read ahead of the
+// value.
+func (st *SpanTracer) UnmarshalReader(ctx context.Context, c Consumer, r io.Reader) error {
+	span := st.ss.StartSpan(ctx, "jsonstream.UnmarshalReader")
+	cr := &meteredReader{r: r}
+	err := UnmarshalReader(c, cr)
+	endSpan(span, cr.n, c, err)
+	return err
+}
+
+// Marshal is like the Marshal function of this package but wraps the call in a span.
+func (st *SpanTracer) Marshal(ctx context.Context, s Streamer, options ...MarshalOption) ([]byte, error) {
+	span := st.ss.StartSpan(ctx, "jsonstream.Marshal")
+	bs, err := Marshal(s, options...)
+	endSpan(span, int64(len(bs)), s, err)
+	return bs, err
+}
+
+// MarshalWriter is like the MarshalWriter function of this package but wraps the call in a span.
+func (st *SpanTracer) MarshalWriter(ctx context.Context, s Streamer, w io.Writer, options ...MarshalOption) error {
+	span := st.ss.StartSpan(ctx, "jsonstream.MarshalWriter")
+	cw := &CountingWriter{W: w}
+	err := MarshalWriter(s, cw, options...)
+	endSpan(span, cw.N, s, err)
+	return err
+}
+
+// endSpan sets the attributes of the span, records the error, if any, and ends the span.
+func endSpan(span Span, size int64, v interface{}, err error) {
+	span.SetInt64("jsonstream.bytes", size)
+	if l, ok := v.(interface{ Len() int }); ok {
+		span.SetInt64("jsonstream.elements", int64(l.Len()))
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
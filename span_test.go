@@ -0,0 +1,98 @@
+package jsonstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type recordedSpan struct {
+	name   string
+	attrs  []string
+	ended  bool
+	errors []error
+}
+
+func (s *recordedSpan) SetInt64(key string, value int64) {
+	s.attrs = append(s.attrs, fmt.Sprintf("%s=%d", key, value))
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.errors = append(s.errors, err)
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+type recordingStarter struct {
+	spans []*recordedSpan
+}
+
+func (r *recordingStarter) StartSpan(ctx context.Context, name string) Span {
+	s := &recordedSpan{name: name}
+	r.spans = append(r.spans, s)
+	return s
+}
+
+type intList []int64
+
+func (l *intList) Len() int {
+	return len(*l)
+}
+
+func (l *intList) MarshalToJSON(w io.Writer) {
+	a := NewArrayWriter(w)
+	for _, i := range *l {
+		a.Int(i)
+	}
+	a.Close()
+}
+
+func (l *intList) UnmarshalFromJSON(js Decoder, firstToken json.Token) {
+	AssertDelim(firstToken, '[')
+	for {
+		i, ok := js.ReadIntOrEnd(']')
+		if !ok {
+			break
+		}
+		*l = append(*l, i)
+	}
+}
+
+func TestSpanTracer(t *testing.T) {
+	r := &recordingStarter{}
+	st := NewSpanTracer(r)
+	ctx := context.Background()
+	l := intList{}
+	if err := st.Unmarshal(ctx, &l, []byte(`[1, 2, 3]`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UnmarshalReader(ctx, &intList{}, strings.NewReader(`[1, "x"]`)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := st.Marshal(ctx, &l); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.MarshalWriter(ctx, &ts{}, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	var a []string
+	for _, s := range r.spans {
+		if !s.ended {
+			t.Errorf("span %s was not ended", s.name)
+		}
+		a = append(a, fmt.Sprintf("%s %v %v", s.name, s.attrs, s.errors))
+	}
+	ex := `jsonstream.Unmarshal [jsonstream.bytes=9 jsonstream.elements=3] []
+jsonstream.UnmarshalReader [jsonstream.bytes=8 jsonstream.elements=1] [expected an integer or the delimiter ']' got string x]
+jsonstream.Marshal [jsonstream.bytes=7 jsonstream.elements=3] []
+jsonstream.MarshalWriter [jsonstream.bytes=7] []`
+	if s := strings.Join(a, "\n"); s != ex {
+		t.Fatalf("expected:\n%s\ngot:\n%s", ex, s)
+	}
+}
@@ -0,0 +1,74 @@
+package jsonstream
+
+import (
+	"database/sql"
+	"io"
+)
+
+// ReadNullBool reads a boolean or null from the decoder. Null results in a sql.NullBool that isn't valid.
+func ReadNullBool(d Decoder) sql.NullBool {
+	b := d.ReadBool()
+	return sql.NullBool{Bool: b, Valid: !d.WasNull()}
+}
+
+// ReadNullFloat64 reads a float or null from the decoder. Null results in a sql.NullFloat64 that isn't valid.
+func ReadNullFloat64(d Decoder) sql.NullFloat64 {
+	f := d.ReadFloat()
+	return sql.NullFloat64{Float64: f, Valid: !d.WasNull()}
+}
+
+// ReadNullInt64 reads an integer or null from the decoder. Null results in a sql.NullInt64 that isn't valid.
+func ReadNullInt64(d Decoder) sql.NullInt64 {
+	i := d.ReadInt()
+	return sql.NullInt64{Int64: i, Valid: !d.WasNull()}
+}
+
+// ReadNullString reads a string or null from the decoder. Null results in a sql.NullString that isn't valid.
+func ReadNullString(d Decoder) sql.NullString {
+	s := d.ReadString()
+	return sql.NullString{String: s, Valid: !d.WasNull()}
+}
+
+// WriteNullBool writes the boolean of the given sql.NullBool on the writer, or null if it isn't valid.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteNullBool(w io.Writer, b sql.NullBool) {
+	if b.Valid {
+		WriteBool(w, b.Bool)
+	} else {
+		WriteNull(w)
+	}
+}
+
+// WriteNullFloat64 writes the float of the given sql.NullFloat64 on the writer, or null if it isn't valid.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteNullFloat64(w io.Writer, f sql.NullFloat64) {
+	if f.Valid {
+		WriteFloat(w, f.Float64)
+	} else {
+		WriteNull(w)
+	}
+}
+
+// WriteNullInt64 writes the integer of the given sql.NullInt64 on the writer, or null if it isn't valid.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteNullInt64(w io.Writer, i sql.NullInt64) {
+	if i.Valid {
+		WriteInt(w, i.Int64)
+	} else {
+		WriteNull(w)
+	}
+}
+
+// WriteNullString writes the string of the given sql.NullString on the writer, or null if it isn't valid.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteNullString(w io.Writer, s sql.NullString) {
+	if s.Valid {
+		WriteString(w, s.String)
+	} else {
+		WriteNull(w)
+	}
+}
@@ -0,0 +1,45 @@
+package jsonstream
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestReadNull(t *testing.T) {
+	input := `[true, 1.5, 2, "s", null, null, null, null]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var vs []interface{}
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 2; i++ {
+				vs = append(vs, ReadNullBool(js), ReadNullFloat64(js), ReadNullInt64(js), ReadNullString(js))
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex := `[{true true} {1.5 true} {2 true} {s true} {false false} {0 false} {0 false} { false}]`
+		if a := fmt.Sprint(vs); a != ex {
+			t.Fatalf("expected %s, got %s", ex, a)
+		}
+	}
+}
+
+func TestWriteNull_sql(t *testing.T) {
+	b := bytes.Buffer{}
+	WriteNullBool(&b, sql.NullBool{Bool: true, Valid: true})
+	WriteNullFloat64(&b, sql.NullFloat64{Float64: 1.5, Valid: true})
+	WriteNullInt64(&b, sql.NullInt64{Int64: 2, Valid: true})
+	WriteNullString(&b, sql.NullString{String: "s", Valid: true})
+	WriteNullBool(&b, sql.NullBool{})
+	WriteNullFloat64(&b, sql.NullFloat64{})
+	WriteNullInt64(&b, sql.NullInt64{})
+	WriteNullString(&b, sql.NullString{})
+	if a := b.String(); a != `true1.52"s"nullnullnullnull` {
+		t.Fatalf("unexpected output %s", a)
+	}
+}
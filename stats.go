@@ -0,0 +1,120 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/tada/catch"
+)
+
+// Stats is a profile of the JSON read by CollectStats. It is typically used to get an overview of a document with
+// an unknown schema.
+type Stats struct {
+	// Objects, Arrays, Strings, Numbers, Bools, and Nulls are the number of values of each kind
+	Objects int64
+	Arrays  int64
+	Strings int64
+	Numbers int64
+	Bools   int64
+	Nulls   int64
+
+	// Keys is the number of object keys
+	Keys int64
+
+	// MaxDepth is the maximum nesting depth of arrays and objects
+	MaxDepth int
+
+	// StringBytes is the total number of bytes in the unquoted string values, not counting keys
+	StringBytes int64
+
+	// MinNumber and MaxNumber are the smallest and the largest number. Both are 0 when there are no numbers
+	MinNumber float64
+	MaxNumber float64
+
+	keys map[string]int64
+}
+
+// A KeyCount is an object key and the number of times it was found.
+type KeyCount struct {
+	Key   string
+	Count int64
+}
+
+// CollectStats reads the JSON values from the given reader and returns a profile of them. The values are read token
+// by token, so the input can be of any size. Only the distinct object keys are kept in memory.
+//
+// The function will recover a catch.Error panic and return its cause.
+func CollectStats(r io.Reader) (*Stats, error) {
+	s := &Stats{MinNumber: math.Inf(1), MaxNumber: math.Inf(-1), keys: make(map[string]int64)}
+	err := catch.Do(func() {
+		d := NewDecoder(r).(*decoder)
+		for {
+			key := d.atKey
+			t, err := d.Token()
+			if err == io.EOF && len(d.stack) == 0 {
+				break
+			}
+			if err != nil {
+				panic(d.unexpectedError(err))
+			}
+			s.add(t, key, len(d.stack))
+		}
+	})
+	if s.Numbers == 0 {
+		s.MinNumber, s.MaxNumber = 0, 0
+	}
+	return s, err
+}
+
+func (s *Stats) add(t json.Token, key bool, depth int) {
+	switch t := t.(type) {
+	case json.Delim:
+		switch t {
+		case '[':
+			s.Arrays++
+		case '{':
+			s.Objects++
+		}
+		if depth > s.MaxDepth {
+			s.MaxDepth = depth
+		}
+	case string:
+		if key {
+			s.Keys++
+			s.keys[t]++
+		} else {
+			s.Strings++
+			s.StringBytes += int64(len(t))
+		}
+	case json.Number:
+		s.Numbers++
+		if f, err := t.Float64(); err == nil {
+			s.MinNumber = math.Min(s.MinNumber, f)
+			s.MaxNumber = math.Max(s.MaxNumber, f)
+		}
+	case bool:
+		s.Bools++
+	case nil:
+		s.Nulls++
+	}
+}
+
+// TopKeys returns the n most frequent object keys, most frequent first. Keys with the same count are sorted by key.
+func (s *Stats) TopKeys(n int) []KeyCount {
+	kcs := make([]KeyCount, 0, len(s.keys))
+	for k, c := range s.keys {
+		kcs = append(kcs, KeyCount{Key: k, Count: c})
+	}
+	sort.Slice(kcs, func(i, j int) bool {
+		if kcs[i].Count != kcs[j].Count {
+			return kcs[i].Count > kcs[j].Count
+		}
+		return kcs[i].Key < kcs[j].Key
+	})
+	if len(kcs) > n {
+		kcs = kcs[:n]
+	}
+	return kcs
+}
@@ -0,0 +1,31 @@
+package jsonstream
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCollectStats(t *testing.T) {
+	input := `[{"id":1, "name":"ab", "tags":["x"]}, {"id":-2.5, "name":null, "ok":true}] {"id":40}`
+	s, err := CollectStats(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := fmt.Sprint(s.Objects, s.Arrays, s.Strings, s.Numbers, s.Bools, s.Nulls, s.Keys, s.MaxDepth, s.StringBytes,
+		s.MinNumber, s.MaxNumber)
+	if ex := "3 2 2 3 1 1 7 3 3 -2.5 40"; a != ex {
+		t.Fatalf("expected %s, got %s", ex, a)
+	}
+	if a := fmt.Sprint(s.TopKeys(2)); a != "[{id 3} {name 2}]" {
+		t.Fatalf("unexpected top keys %s", a)
+	}
+
+	s, err = CollectStats(strings.NewReader(`["a", [`))
+	if err == nil || err.Error() != "unexpected EOF" {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+	if s.Strings != 1 || s.MinNumber != 0 || s.MaxNumber != 0 {
+		t.Fatalf("unexpected partial stats %+v", s)
+	}
+}
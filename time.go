@@ -0,0 +1,113 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultTimeLayouts returns the layouts that ReadTimeLayouts tries when it is called without layouts. The returned
+// slice is a new slice on each call.
+func DefaultTimeLayouts() []string {
+	return []string{time.RFC3339, time.RFC1123Z, time.RFC1123, "2006-01-02"}
+}
+
+// parseTimeLayouts parses the given string using the first of the given layouts that matches it. A time without a
+// zone is interpreted as given by the TimeLocation and RejectNaiveTimes options when the decoder options are not nil.
+func parseTimeLayouts(s string, layouts []string, o *decoderOptions) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts()
+	}
+	for _, layout := range layouts {
+		var t time.Time
+		var err error
+		if o != nil && o.timeLocation != nil {
+			t, err = time.ParseInLocation(layout, s, o.timeLocation)
+		} else {
+			t, err = time.Parse(layout, s)
+		}
+		if err == nil {
+			if o != nil && o.rejectNaive && !hasZone(layout) {
+				return time.Time{}, fmt.Errorf("time %q has no time zone", s)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("time %q does not match any of the layouts %q", s, layouts)
+}
+
+// hasZone returns true if the given layout contains a time zone element.
+func hasZone(layout string) bool {
+	return strings.Contains(layout, "MST") || strings.Contains(layout, "Z07") || strings.Contains(layout, "-07")
+}
+
+// epochTime returns the time that is the given number of units, which may have a fraction, after the Unix epoch. The
+// unit must divide a second.
+func epochTime(n []byte, integral bool, unit time.Duration) (time.Time, error) {
+	perSecond := int64(time.Second / unit)
+	if integral {
+		if i, err := parseInt(n); err == nil {
+			return time.Unix(i/perSecond, i%perSecond*int64(unit)), nil
+		}
+	}
+	f, err := parseFloat(n)
+	if err != nil || math.Abs(f*unit.Seconds()) > math.MaxInt64/1e9 {
+		return time.Time{}, fmt.Errorf("number %s is not a valid epoch time", n)
+	}
+	whole := math.Floor(f)
+	i := int64(whole)
+	return time.Unix(i/perSecond, i%perSecond*int64(unit)+int64(math.Round((f-whole)*float64(unit)))), nil
+}
+
+// readEpoch reads a number of the given unit since the Unix epoch, or null, and returns it as a time.
+func (d *decoder) readEpoch(unit time.Duration) time.Time {
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		if s, ok := t.(json.Number); ok {
+			n = []byte(s)
+		}
+		var tm time.Time
+		switch {
+		case n != nil:
+			tm, err = epochTime(n, d.integral, unit)
+		case t != nil:
+			err = fmt.Errorf("expected an epoch time, got %T %v", t, t)
+		}
+		if err == nil {
+			return tm
+		}
+	}
+	panic(d.unexpectedError(err))
+}
+
+// WriteDuration writes the given duration on the writer as a string in the format of time.Duration.String, e.g.
+// "1h30m0s", or, when the DurationMillis option is in effect, as an integer number of milliseconds. The duration is
+// read back using the ReadDuration method of the Decoder.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteDuration(w io.Writer, d time.Duration) {
+	if optionsOf(w).durationMs {
+		WriteInt(w, d.Milliseconds())
+		return
+	}
+	WriteString(w, d.String())
+}
+
+// WriteTimeUnix writes the given time on the writer as an integer number of seconds since the Unix epoch. The fraction
+// of a second is truncated. The time is read back using the ReadTimeUnix method of the Decoder.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteTimeUnix(w io.Writer, t time.Time) {
+	WriteInt(w, t.Unix())
+}
+
+// WriteTimeUnixMillis writes the given time on the writer as an integer number of milliseconds since the Unix epoch.
+// The fraction of a millisecond is truncated. The time is read back using the ReadTimeUnixMillis method of the Decoder.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteTimeUnixMillis(w io.Writer, t time.Time) {
+	WriteInt(w, t.Unix()*1e3+int64(t.Nanosecond())/1e6)
+}
@@ -0,0 +1,200 @@
+package jsonstream
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tada/catch"
+)
+
+func TestReadTimeLayouts(t *testing.T) {
+	input := `["2021-03-04T05:06:07Z", "Thu, 04 Mar 2021 05:06:07 GMT", "2021-03-04", 1614834367, 1614834367.5, null]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var times []time.Time
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 6; i++ {
+				times = append(times, js.ReadTimeLayouts().UTC())
+			}
+			js.ReadDelim(']')
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+		for i, ext := range []time.Time{
+			ex, ex, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), ex, ex.Add(500 * time.Millisecond), {},
+		} {
+			if !times[i].Equal(ext) {
+				t.Errorf("expected %v at index %d, got %v", ext, i, times[i])
+			}
+		}
+	}
+}
+
+func TestDefaultTimeLayouts(t *testing.T) {
+	layouts := DefaultTimeLayouts()
+	layouts[0] = "2006"
+	if DefaultTimeLayouts()[0] != time.RFC3339 {
+		t.Fatal("expected DefaultTimeLayouts to return a copy")
+	}
+}
+
+func TestReadTimeLayouts_custom(t *testing.T) {
+	js := NewBytesDecoder([]byte(`["04/03/2021", "2021-03-04", true]`))
+	var tm time.Time
+	err := catch.Do(func() {
+		js.ReadDelim('[')
+		tm = js.ReadTimeLayouts("02/01/2006")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tm.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time %v", tm)
+	}
+	for _, ex := range []string{`does not match any of the layouts ["02/01/2006"]`, `expected a time, got bool true`} {
+		err = catch.Do(func() {
+			js.ReadTimeLayouts("02/01/2006")
+		})
+		if err == nil || !strings.Contains(err.Error(), ex) {
+			t.Errorf("expected error containing %q, got %v", ex, err)
+		}
+	}
+}
+
+func TestReadTimeLayouts_naive(t *testing.T) {
+	const layout = "2006-01-02 15:04:05"
+	input := `["2024-01-02 15:04:05", "2024-01-02T15:04:05+01:00"]`
+	est := time.FixedZone("EST", -5*3600)
+	for _, tc := range []struct {
+		option DecoderOption
+		ex     time.Time
+	}{
+		{nil, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{TimeLocation(est), time.Date(2024, 1, 2, 15, 4, 5, 0, est)},
+	} {
+		var options []DecoderOption
+		if tc.option != nil {
+			options = append(options, tc.option)
+		}
+		var tm, zoned time.Time
+		err := catch.Do(func() {
+			js := NewBytesDecoder([]byte(input), options...)
+			js.ReadDelim('[')
+			tm = js.ReadTimeLayouts(layout)
+			js.ReadInto(&zoned)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tm.Equal(tc.ex) || tm.Location() != tc.ex.Location() {
+			t.Errorf("expected %v, got %v", tc.ex, tm)
+		}
+		if !zoned.Equal(time.Date(2024, 1, 2, 14, 4, 5, 0, time.UTC)) {
+			t.Errorf("unexpected zoned time %v", zoned)
+		}
+	}
+	var zoned time.Time
+	err := catch.Do(func() {
+		js := NewBytesDecoder([]byte(`["2024-01-02T15:04:05+01:00", "2024-01-02"]`), RejectNaiveTimes())
+		js.ReadDelim('[')
+		zoned = js.ReadTimeLayouts()
+		js.ReadTimeLayouts()
+	})
+	if err == nil || err.Error() != `time "2024-01-02" has no time zone` {
+		t.Errorf("expected no time zone error, got %v", err)
+	}
+	if zoned.IsZero() {
+		t.Error("expected zoned time to be accepted")
+	}
+}
+
+func TestReadTimeUnix(t *testing.T) {
+	input := `[1614834367, 1614834367.5, -1.5, null, 1614834367500, -1500, 1614834367500.25, null, "x"]`
+	ex := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var times []time.Time
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 4; i++ {
+				times = append(times, js.ReadTimeUnix())
+			}
+			for i := 0; i < 4; i++ {
+				times = append(times, js.ReadTimeUnixMillis())
+			}
+			js.ReadTimeUnix()
+		})
+		if err == nil || !strings.Contains(err.Error(), `expected an epoch time, got string x`) {
+			t.Errorf("expected epoch time error, got %v", err)
+		}
+		for i, ext := range []time.Time{
+			ex, ex.Add(500 * time.Millisecond), time.Unix(-2, 5e8), {},
+			ex.Add(500 * time.Millisecond), time.Unix(-2, 5e8), ex.Add(500*time.Millisecond + 250*time.Microsecond), {},
+		} {
+			if !times[i].Equal(ext) {
+				t.Errorf("expected %v at index %d, got %v", ext, i, times[i])
+			}
+		}
+	}
+}
+
+func TestWriteTimeUnix(t *testing.T) {
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 500600000, time.UTC)
+	bs, err := Marshal(AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		a := NewArrayWriter(w)
+		a.Element()
+		WriteTimeUnix(w, tm)
+		a.Element()
+		WriteTimeUnixMillis(w, tm)
+		a.Close()
+		return nil
+	})))
+	if err != nil || string(bs) != `[1614834367,1614834367500]` {
+		t.Fatalf("expected [1614834367,1614834367500], got %s, %v", bs, err)
+	}
+}
+
+func TestWriteDuration(t *testing.T) {
+	s := errorStreamerFunc(func(w io.Writer) error {
+		WriteDuration(w, 90*time.Minute)
+		return nil
+	})
+	if bs, err := Marshal(AsStreamer(s)); err != nil || string(bs) != `"1h30m0s"` {
+		t.Fatalf("expected \"1h30m0s\", got %s, %v", bs, err)
+	}
+	if bs, err := Marshal(AsStreamer(s), DurationMillis()); err != nil || string(bs) != `5400000` {
+		t.Fatalf("expected 5400000, got %s, %v", bs, err)
+	}
+}
+
+func TestReadDuration(t *testing.T) {
+	input := `["1h30m0s", 5400000, 1.5, null, "1x", true]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var ds []time.Duration
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 4; i++ {
+				ds = append(ds, js.ReadDuration())
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, ex := range []time.Duration{90 * time.Minute, 90 * time.Minute, 1500 * time.Microsecond, 0} {
+			if ds[i] != ex {
+				t.Errorf("expected %v at index %d, got %v", ex, i, ds[i])
+			}
+		}
+		for _, ex := range []string{`time: unknown unit "x" in duration "1x"`, `expected a duration, got bool true`} {
+			err = catch.Do(func() {
+				js.ReadDuration()
+			})
+			if err == nil || err.Error() != ex {
+				t.Errorf("expected error %q, got %v", ex, err)
+			}
+		}
+	}
+}
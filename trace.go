@@ -0,0 +1,392 @@
+package jsonstream
+
+import (
+	"encoding"
+	"encoding/json"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// tracingDecoder is a Decoder that logs the values read through it.
+type tracingDecoder struct {
+	Decoder
+	logf func(format string, args ...interface{})
+}
+
+// tracedConsumer passes the tracingDecoder to a Consumer in place of the decoder that it decorates.
+type tracedConsumer struct {
+	c Consumer
+	t *tracingDecoder
+}
+
+func (tc tracedConsumer) UnmarshalFromJSON(_ Decoder, firstToken json.Token) {
+	tc.t.trace("ReadConsumer", firstToken)
+	tc.c.UnmarshalFromJSON(tc.t, firstToken)
+}
+
+// NewTracingDecoder returns a Decoder that delegates to the given decoder and calls logf with the name of each Read,
+// Skip, or Peek method that is called, the path that the method left the decoder at (see CurrentPath), and the value
+// that it read. Consumers and the functions passed to ReadObjectFields are given the tracing decoder, so the values
+// that they read are logged too. A trace is typically used to find the place where a Consumer desynchronizes from the
+// stream, which often is far from where the resulting error is raised.
+func NewTracingDecoder(d Decoder, logf func(format string, args ...interface{})) Decoder {
+	return &tracingDecoder{Decoder: d, logf: logf}
+}
+
+func (t *tracingDecoder) trace(method string, v interface{}) {
+	switch tv := v.(type) {
+	case nil:
+		v = "null"
+	case string:
+		v = strconv.Quote(tv)
+	case []byte:
+		v = string(tv)
+	}
+	t.logf("%s %s: %v", method, t.CurrentPath(), v)
+}
+
+func (t *tracingDecoder) traceCall(method string) {
+	t.logf("%s %s", method, t.CurrentPath())
+}
+
+func (t *tracingDecoder) traceOrEnd(method string, v interface{}, ok bool, end byte) {
+	if !ok {
+		v = json.Delim(end)
+	}
+	t.trace(method, v)
+}
+
+// stringOrNil returns the given bytes as a string, or nil if they are nil.
+func stringOrNil(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+func (t *tracingDecoder) Mark() {
+	t.Decoder.Mark()
+	t.traceCall("Mark")
+}
+
+func (t *tracingDecoder) PeekKind() Kind {
+	k := t.Decoder.PeekKind()
+	t.trace("PeekKind", k)
+	return k
+}
+
+func (t *tracingDecoder) PeekN(n int) []json.Token {
+	ts := t.Decoder.PeekN(n)
+	t.trace("PeekN", ts)
+	return ts
+}
+
+func (t *tracingDecoder) ReadAny(options ...AnyOption) interface{} {
+	v := t.Decoder.ReadAny(options...)
+	t.trace("ReadAny", v)
+	return v
+}
+
+func (t *tracingDecoder) ReadBool() bool {
+	b := t.Decoder.ReadBool()
+	t.trace("ReadBool", b)
+	return b
+}
+
+func (t *tracingDecoder) ReadBoolLenient() bool {
+	b := t.Decoder.ReadBoolLenient()
+	t.trace("ReadBoolLenient", b)
+	return b
+}
+
+func (t *tracingDecoder) ReadBoolOrEnd(end byte) (bool, bool) {
+	b, ok := t.Decoder.ReadBoolOrEnd(end)
+	t.traceOrEnd("ReadBoolOrEnd", b, ok, end)
+	return b, ok
+}
+
+func (t *tracingDecoder) ReadByteSize() int64 {
+	i := t.Decoder.ReadByteSize()
+	t.trace("ReadByteSize", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadBytesTo(w io.Writer) int64 {
+	n := t.Decoder.ReadBytesTo(w)
+	t.trace("ReadBytesTo", n)
+	return n
+}
+
+func (t *tracingDecoder) ReadConsumer(c Consumer) bool {
+	ok := t.Decoder.ReadConsumer(tracedConsumer{c: c, t: t})
+	if !ok {
+		t.trace("ReadConsumer", nil)
+	}
+	return ok
+}
+
+func (t *tracingDecoder) ReadConsumerOrEnd(c Consumer, end byte) (bool, bool) {
+	found, ok := t.Decoder.ReadConsumerOrEnd(tracedConsumer{c: c, t: t}, end)
+	if !found {
+		t.traceOrEnd("ReadConsumerOrEnd", nil, ok, end)
+	}
+	return found, ok
+}
+
+// ReadConsumers passes the consumers on as is since they must read the value from the decoders that replay it.
+func (t *tracingDecoder) ReadConsumers(cs ...Consumer) bool {
+	ok := t.Decoder.ReadConsumers(cs...)
+	t.trace("ReadConsumers", ok)
+	return ok
+}
+
+func (t *tracingDecoder) ReadDecimal(v Decimal) {
+	t.Decoder.ReadDecimal(v)
+	t.trace("ReadDecimal", v)
+}
+
+func (t *tracingDecoder) ReadDelim(delim byte) {
+	t.Decoder.ReadDelim(delim)
+	t.trace("ReadDelim", json.Delim(delim))
+}
+
+func (t *tracingDecoder) ReadDuration() time.Duration {
+	d := t.Decoder.ReadDuration()
+	t.trace("ReadDuration", d)
+	return d
+}
+
+func (t *tracingDecoder) ReadEnumCode(c *EnumCodec) int64 {
+	i := t.Decoder.ReadEnumCode(c)
+	t.trace("ReadEnumCode", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadFlags(c *FlagCodec) uint64 {
+	f := t.Decoder.ReadFlags(c)
+	t.trace("ReadFlags", f)
+	return f
+}
+
+func (t *tracingDecoder) ReadFloat() float64 {
+	f := t.Decoder.ReadFloat()
+	t.trace("ReadFloat", f)
+	return f
+}
+
+func (t *tracingDecoder) ReadFloat32() float32 {
+	f := t.Decoder.ReadFloat32()
+	t.trace("ReadFloat32", f)
+	return f
+}
+
+func (t *tracingDecoder) ReadFloatOrEnd(end byte) (float64, bool) {
+	f, ok := t.Decoder.ReadFloatOrEnd(end)
+	t.traceOrEnd("ReadFloatOrEnd", f, ok, end)
+	return f, ok
+}
+
+func (t *tracingDecoder) ReadInt() int64 {
+	i := t.Decoder.ReadInt()
+	t.trace("ReadInt", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadInt16() int16 {
+	i := t.Decoder.ReadInt16()
+	t.trace("ReadInt16", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadInt32() int32 {
+	i := t.Decoder.ReadInt32()
+	t.trace("ReadInt32", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadInt64String() int64 {
+	i := t.Decoder.ReadInt64String()
+	t.trace("ReadInt64String", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadInt8() int8 {
+	i := t.Decoder.ReadInt8()
+	t.trace("ReadInt8", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadIntExact() int64 {
+	i := t.Decoder.ReadIntExact()
+	t.trace("ReadIntExact", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadIntExactOrEnd(end byte) (int64, bool) {
+	i, ok := t.Decoder.ReadIntExactOrEnd(end)
+	t.traceOrEnd("ReadIntExactOrEnd", i, ok, end)
+	return i, ok
+}
+
+func (t *tracingDecoder) ReadIntOrEnd(end byte) (int64, bool) {
+	i, ok := t.Decoder.ReadIntOrEnd(end)
+	t.traceOrEnd("ReadIntOrEnd", i, ok, end)
+	return i, ok
+}
+
+func (t *tracingDecoder) ReadInteger() (int64, *big.Int) {
+	i, b := t.Decoder.ReadInteger()
+	if b != nil {
+		t.trace("ReadInteger", b)
+	} else {
+		t.trace("ReadInteger", i)
+	}
+	return i, b
+}
+
+func (t *tracingDecoder) ReadInto(ptr interface{}) {
+	if c, ok := ptr.(Consumer); ok {
+		t.ReadConsumer(c)
+		return
+	}
+	t.Decoder.ReadInto(ptr)
+	t.trace("ReadInto", reflect.ValueOf(ptr).Elem().Interface())
+}
+
+func (t *tracingDecoder) ReadObjectFields(fields map[string]func(Decoder), unknown func(key string, d Decoder)) {
+	tf := make(map[string]func(Decoder), len(fields))
+	for k, f := range fields {
+		f := f
+		tf[k] = func(Decoder) {
+			f(t)
+		}
+	}
+	var tu func(key string, d Decoder)
+	if unknown != nil {
+		tu = func(key string, _ Decoder) {
+			unknown(key, t)
+		}
+	}
+	t.Decoder.ReadObjectFields(tf, tu)
+	t.trace("ReadObjectFields", json.Delim('}'))
+}
+
+func (t *tracingDecoder) ReadRawAppend(dst []byte) []byte {
+	n := len(dst)
+	dst = t.Decoder.ReadRawAppend(dst)
+	t.trace("ReadRawAppend", dst[n:])
+	return dst
+}
+
+func (t *tracingDecoder) ReadRawAppendOrEnd(dst []byte, end byte) ([]byte, bool) {
+	n := len(dst)
+	dst, ok := t.Decoder.ReadRawAppendOrEnd(dst, end)
+	t.traceOrEnd("ReadRawAppendOrEnd", dst[n:], ok, end)
+	return dst, ok
+}
+
+func (t *tracingDecoder) ReadString() string {
+	s := t.Decoder.ReadString()
+	t.trace("ReadString", s)
+	return s
+}
+
+func (t *tracingDecoder) ReadStringBytes() []byte {
+	b := t.Decoder.ReadStringBytes()
+	t.trace("ReadStringBytes", stringOrNil(b))
+	return b
+}
+
+func (t *tracingDecoder) ReadStringBytesOrEnd(end byte) ([]byte, bool) {
+	b, ok := t.Decoder.ReadStringBytesOrEnd(end)
+	t.traceOrEnd("ReadStringBytesOrEnd", stringOrNil(b), ok, end)
+	return b, ok
+}
+
+func (t *tracingDecoder) ReadStringReader() io.Reader {
+	r := t.Decoder.ReadStringReader()
+	t.traceCall("ReadStringReader")
+	return r
+}
+
+func (t *tracingDecoder) ReadStringEnum(allowed ...string) string {
+	s := t.Decoder.ReadStringEnum(allowed...)
+	t.trace("ReadStringEnum", s)
+	return s
+}
+
+func (t *tracingDecoder) ReadStringOrEnd(end byte) (string, bool) {
+	s, ok := t.Decoder.ReadStringOrEnd(end)
+	t.traceOrEnd("ReadStringOrEnd", s, ok, end)
+	return s, ok
+}
+
+func (t *tracingDecoder) ReadText(v encoding.TextUnmarshaler) {
+	t.Decoder.ReadText(v)
+	t.trace("ReadText", v)
+}
+
+func (t *tracingDecoder) ReadTimeLayouts(layouts ...string) time.Time {
+	tm := t.Decoder.ReadTimeLayouts(layouts...)
+	t.trace("ReadTimeLayouts", tm)
+	return tm
+}
+
+func (t *tracingDecoder) ReadTimeUnix() time.Time {
+	tm := t.Decoder.ReadTimeUnix()
+	t.trace("ReadTimeUnix", tm)
+	return tm
+}
+
+func (t *tracingDecoder) ReadTimeUnixMillis() time.Time {
+	tm := t.Decoder.ReadTimeUnixMillis()
+	t.trace("ReadTimeUnixMillis", tm)
+	return tm
+}
+
+func (t *tracingDecoder) ReadUint16() uint16 {
+	i := t.Decoder.ReadUint16()
+	t.trace("ReadUint16", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadUint32() uint32 {
+	i := t.Decoder.ReadUint32()
+	t.trace("ReadUint32", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadUint64() uint64 {
+	i := t.Decoder.ReadUint64()
+	t.trace("ReadUint64", i)
+	return i
+}
+
+func (t *tracingDecoder) ReadUint8() uint8 {
+	i := t.Decoder.ReadUint8()
+	t.trace("ReadUint8", i)
+	return i
+}
+
+func (t *tracingDecoder) Reset() {
+	t.Decoder.Reset()
+	t.traceCall("Reset")
+}
+
+func (t *tracingDecoder) Skip() {
+	t.Decoder.Skip()
+	t.traceCall("Skip")
+}
+
+func (t *tracingDecoder) SkipRemaining() {
+	t.Decoder.SkipRemaining()
+	t.traceCall("SkipRemaining")
+}
+
+func (t *tracingDecoder) Unmark() {
+	t.Decoder.Unmark()
+	t.traceCall("Unmark")
+}
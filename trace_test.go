@@ -0,0 +1,210 @@
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tada/catch"
+)
+
+func TestNewTracingDecoder(t *testing.T) {
+	var lines []string
+	logf := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	js := NewTracingDecoder(NewBytesDecoder([]byte(`[{"v":1000}, null, {"a":"x","b":[true]}]`)), logf)
+	err := catch.Do(func() {
+		js.ReadDelim('[')
+		js.ReadConsumer(&ts{})
+		js.ReadConsumer(&ts{})
+		js.ReadDelim('{')
+		js.ReadObjectFields(map[string]func(Decoder){
+			"a": func(d Decoder) { d.ReadString() },
+		}, func(key string, d Decoder) { d.Skip() })
+		if _, ok := js.ReadIntOrEnd(']'); ok {
+			t.Fatal("expected end of array")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := `ReadDelim : [
+ReadConsumer /0: {
+ReadStringOrEnd /0/v: "v"
+ReadInt /0/v: 1000
+ReadStringOrEnd /0: }
+ReadConsumer /1: null
+ReadDelim /2: {
+ReadString /2/a: "x"
+Skip /2/b
+ReadObjectFields /2: }
+ReadIntOrEnd : ]`
+	if a := strings.Join(lines, "\n"); a != ex {
+		t.Fatalf("expected:\n%s\ngot:\n%s", ex, a)
+	}
+}
+
+func TestNewTracingDecoder_methods(t *testing.T) {
+	enums := NewEnumCodec().Register("on", 1)
+	flags := NewFlagCodec().Register("read", 1).Register("write", 2).Register("exec", 4)
+	unix := time.Unix(1614834367, 0)
+	tests := []struct {
+		input string
+		read  func(d Decoder) interface{}
+		log   string
+		value interface{}
+	}{
+		{`[1]`, func(d Decoder) interface{} {
+			d.Mark()
+			d.ReadDelim('[')
+			d.Reset()
+			return d.PeekKind()
+		}, "Mark \nReadDelim : [\nReset \nPeekKind : array", KindArray},
+		{`[1]`, func(d Decoder) interface{} {
+			d.Mark()
+			d.Unmark()
+			return d.PeekKind()
+		}, "Mark \nUnmark \nPeekKind : array", KindArray},
+		{`[1, 2]`, func(d Decoder) interface{} { return d.PeekN(2) },
+			"PeekN : [[ 1]", []json.Token{json.Delim('['), json.Number("1")}},
+		{`{"a": [1]}`, func(d Decoder) interface{} { return d.ReadAny() },
+			"ReadAny : map[a:[1]]", map[string]interface{}{"a": []interface{}{1.0}}},
+		{`true`, func(d Decoder) interface{} { return d.ReadBool() }, "ReadBool : true", true},
+		{`"1"`, func(d Decoder) interface{} { return d.ReadBoolLenient() }, "ReadBoolLenient : true", true},
+		{`[true]`, func(d Decoder) interface{} {
+			d.ReadDelim('[')
+			b, _ := d.ReadBoolOrEnd(']')
+			return b
+		}, "ReadDelim : [\nReadBoolOrEnd /0: true", true},
+		{`"4kb"`, func(d Decoder) interface{} { return d.ReadByteSize() }, "ReadByteSize : 4000", int64(4000)},
+		{`"aGk="`, func(d Decoder) interface{} {
+			b := bytes.Buffer{}
+			d.ReadBytesTo(&b)
+			return b.String()
+		}, "ReadBytesTo : 2", "hi"},
+		{`[{"v":2}]`, func(d Decoder) interface{} {
+			d.ReadDelim('[')
+			v := ts{}
+			found, _ := d.ReadConsumerOrEnd(&v, ']')
+			return found && v.v == 2*time.Millisecond
+		}, "ReadDelim : [\nReadConsumer /0: {\nReadStringOrEnd /0/v: \"v\"\nReadInt /0/v: 2\nReadStringOrEnd /0: }", true},
+		{`[]`, func(d Decoder) interface{} {
+			d.ReadDelim('[')
+			_, ok := d.ReadConsumerOrEnd(&ts{}, ']')
+			return ok
+		}, "ReadDelim : [\nReadConsumerOrEnd : ]", false},
+		{`{"v":3}`, func(d Decoder) interface{} { return d.ReadConsumers(&ts{}, &ts{}) }, "ReadConsumers : true", true},
+		{`1.50`, func(d Decoder) interface{} {
+			var r rawDecimal
+			d.ReadDecimal(&r)
+			return string(r)
+		}, "ReadDecimal : 1.50", "1.50"},
+		{`"1m30s"`, func(d Decoder) interface{} { return d.ReadDuration() }, "ReadDuration : 1m30s", 90 * time.Second},
+		{`"on"`, func(d Decoder) interface{} { return d.ReadEnumCode(enums) }, "ReadEnumCode : 1", int64(1)},
+		{`["read", "exec"]`, func(d Decoder) interface{} { return d.ReadFlags(flags) }, "ReadFlags : 5", uint64(5)},
+		{`2.5`, func(d Decoder) interface{} { return d.ReadFloat() }, "ReadFloat : 2.5", 2.5},
+		{`0.25`, func(d Decoder) interface{} { return d.ReadFloat32() }, "ReadFloat32 : 0.25", float32(0.25)},
+		{`[2.5]`, func(d Decoder) interface{} {
+			d.ReadDelim('[')
+			f, _ := d.ReadFloatOrEnd(']')
+			return f
+		}, "ReadDelim : [\nReadFloatOrEnd /0: 2.5", 2.5},
+		{`-8`, func(d Decoder) interface{} { return d.ReadInt8() }, "ReadInt8 : -8", int8(-8)},
+		{`-16`, func(d Decoder) interface{} { return d.ReadInt16() }, "ReadInt16 : -16", int16(-16)},
+		{`-32`, func(d Decoder) interface{} { return d.ReadInt32() }, "ReadInt32 : -32", int32(-32)},
+		{`"9007199254740993"`, func(d Decoder) interface{} { return d.ReadInt64String() },
+			"ReadInt64String : 9007199254740993", int64(9007199254740993)},
+		{`42`, func(d Decoder) interface{} { return d.ReadIntExact() }, "ReadIntExact : 42", int64(42)},
+		{`[]`, func(d Decoder) interface{} {
+			d.ReadDelim('[')
+			_, ok := d.ReadIntExactOrEnd(']')
+			return ok
+		}, "ReadDelim : [\nReadIntExactOrEnd : ]", false},
+		{`123`, func(d Decoder) interface{} {
+			i, _ := d.ReadInteger()
+			return i
+		}, "ReadInteger : 123", int64(123)},
+		{`123456789012345678901234567890`, func(d Decoder) interface{} {
+			_, b := d.ReadInteger()
+			return b.String()
+		}, "ReadInteger : 123456789012345678901234567890", "123456789012345678901234567890"},
+		{`7`, func(d Decoder) interface{} {
+			var i uint16
+			d.ReadInto(&i)
+			return i
+		}, "ReadInto : 7", uint16(7)},
+		{`{"v":4}`, func(d Decoder) interface{} {
+			v := ts{}
+			d.ReadInto(&v)
+			return v.v
+		}, "ReadConsumer : {\nReadStringOrEnd /v: \"v\"\nReadInt /v: 4\nReadStringOrEnd : }", 4 * time.Millisecond},
+		{`{"a": [1, 2]}`, func(d Decoder) interface{} { return string(d.ReadRawAppend(nil)) },
+			`ReadRawAppend : {"a": [1, 2]}`, `{"a": [1, 2]}`},
+		{`[[1, 2]]`, func(d Decoder) interface{} {
+			d.ReadDelim('[')
+			b, _ := d.ReadRawAppendOrEnd([]byte("x"), ']')
+			return string(b)
+		}, "ReadDelim : [\nReadRawAppendOrEnd /0: [1, 2]", "x[1, 2]"},
+		{`null`, func(d Decoder) interface{} { return d.ReadStringBytes() }, "ReadStringBytes : null", []byte(nil)},
+		{`["ab"]`, func(d Decoder) interface{} {
+			d.ReadDelim('[')
+			b, _ := d.ReadStringBytesOrEnd(']')
+			return string(b)
+		}, "ReadDelim : [\nReadStringBytesOrEnd /0: \"ab\"", "ab"},
+		{`"abc"`, func(d Decoder) interface{} {
+			bs, _ := ioutil.ReadAll(d.ReadStringReader())
+			return string(bs)
+		}, "ReadStringReader ", "abc"},
+		{`"disabled"`, func(d Decoder) interface{} {
+			return testStatus(d.ReadStringEnum(string(statusActive), string(statusDisabled)))
+		}, "ReadStringEnum : \"disabled\"", statusDisabled},
+		{`"42"`, func(d Decoder) interface{} {
+			b := new(big.Int)
+			d.ReadText(b)
+			return b.Int64()
+		}, "ReadText : 42", int64(42)},
+		{`"2021-03-04"`, func(d Decoder) interface{} { return d.ReadTimeLayouts("2006-01-02") },
+			"ReadTimeLayouts : 2021-03-04 00:00:00 +0000 UTC", time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{`1614834367`, func(d Decoder) interface{} { return d.ReadTimeUnix().Equal(unix) },
+			"ReadTimeUnix : " + unix.String(), true},
+		{`1614834367000`, func(d Decoder) interface{} { return d.ReadTimeUnixMillis().Equal(unix) },
+			"ReadTimeUnixMillis : " + unix.String(), true},
+		{`8`, func(d Decoder) interface{} { return d.ReadUint8() }, "ReadUint8 : 8", uint8(8)},
+		{`16`, func(d Decoder) interface{} { return d.ReadUint16() }, "ReadUint16 : 16", uint16(16)},
+		{`32`, func(d Decoder) interface{} { return d.ReadUint32() }, "ReadUint32 : 32", uint32(32)},
+		{`18446744073709551615`, func(d Decoder) interface{} { return d.ReadUint64() },
+			"ReadUint64 : 18446744073709551615", uint64(18446744073709551615)},
+		{`[1, 2, 3]`, func(d Decoder) interface{} {
+			d.ReadDelim('[')
+			d.SkipRemaining()
+			return d.Depth()
+		}, "ReadDelim : [\nSkipRemaining ", 0},
+	}
+	for _, tc := range tests {
+		var lines []string
+		logf := func(format string, args ...interface{}) {
+			lines = append(lines, fmt.Sprintf(format, args...))
+		}
+		var v interface{}
+		err := catch.Do(func() {
+			v = tc.read(NewTracingDecoder(NewBytesDecoder([]byte(tc.input)), logf))
+		})
+		if err != nil {
+			t.Errorf("%s: %v", tc.input, err)
+			continue
+		}
+		if a := strings.Join(lines, "\n"); a != tc.log {
+			t.Errorf("%s: expected log:\n%s\ngot:\n%s", tc.input, tc.log, a)
+		}
+		if !reflect.DeepEqual(v, tc.value) {
+			t.Errorf("%s: expected %#v, got %#v", tc.input, tc.value, v)
+		}
+	}
+}
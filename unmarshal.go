@@ -1,30 +1,112 @@
 package jsonstream
 
 import (
+	"bufio"
 	"bytes"
+	"encoding"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/tada/catch"
 )
 
 // A Decoder provides methods to interpret JSON from a stream of tokens provided by a json.Decoder.
 type Decoder interface {
-	// JSONDecoder returns the underlying json.Decoder instance
+	// Allocator returns the allocator given with the Allocate option, or an allocator that allocates on the heap. A
+	// Consumer can use it to allocate the slices and maps of the values that it decodes.
+	Allocator() Allocator
+
+	// CurrentPath returns a JSON Pointer to the value that was last read, or, when the last token read was an object
+	// key, to the value of that key. The pointer of a top level value is an empty string. It is typically used for
+	// logging or to tell where in a document a generic Consumer is.
+	CurrentPath() string
+
+	// DebugState returns a description of the current state of the decoder that contains the nesting depth, the kinds
+	// of the open containers, the last key read from an object, and the byte offset in the input. The offset is -1 when
+	// it isn't known.
+	DebugState() string
+
+	// Depth returns the number of arrays and objects that are currently open, i.e. 0 at the top level, 1 after the
+	// opening delimiter of a top level array or object has been read, and so on.
+	Depth() int
+
+	// JSONDecoder returns the underlying json.Decoder instance or nil if the decoder reads its tokens without using
+	// a json.Decoder (see NewBytesDecoder). Delimiters that are read directly from the json.Decoder are not seen by
+	// the decoder and will cause the OrEnd methods to report mismatched containers.
 	JSONDecoder() *json.Decoder
 
+	// LastNumberIntegral returns true if the last number that was read by the decoder was written without a fraction
+	// or exponent part, i.e. true for 1 but false for 1.0 and 1e0.
+	LastNumberIntegral() bool
+
+	// Mark saves the current position of the decoder so that a later call to Reset can return to it. The decoder then
+	// keeps the tokens that it reads until Reset, Unmark, or Mark is called, which makes speculative decoding possible,
+	// e.g. trying one Consumer and, when it fails, reading the same value again using another. Tokens that are read
+	// directly from the json.Decoder returned by JSONDecoder are not kept.
+	Mark()
+
+	// PeekKind returns the kind of the next value without consuming it, so that dispatch code can switch on a Kind
+	// rather than on the type of a json.Token. KindInvalid is returned when the input ends or when the next token is
+	// the end of an array or an object. A panic with a catch.Error is raised if an error occurred.
+	PeekKind() Kind
+
+	// PeekN returns the next n tokens without consuming them, so that they are returned again by the following reads.
+	// Fewer than n tokens are returned when the input ends. It is typically used to look at the first key of an object
+	// before deciding how to read it. A panic with a catch.Error is raised if an error occurred.
+	PeekN(n int) []json.Token
+
+	// ReadAny reads the next value from the decoder and returns it in the form that encoding/json uses when it
+	// unmarshals into an interface{}, i.e. as a map[string]interface{}, a []interface{}, a string, a float64, a bool,
+	// or nil. The given options, and the Numbers option of the decoder, change how numbers and objects are
+	// represented and limit the depth. A panic with a catch.Error is raised if an error occurred.
+	ReadAny(options ...AnyOption) interface{}
+
 	// ReadBool reads next token from the decoder and asserts that it is an boolean or null. The function returns the
 	// boolean (or false in case of null) or raises a panic with a catch.Error if an error occurred or if the token
 	// didn't match a boolean or null.
 	ReadBool() bool
 
+	// ReadBoolLenient reads next token from the decoder and asserts that it is a boolean, one of the strings "true",
+	// "false", "1", and "0", one of the numbers 1 and 0, or null. The function returns the boolean (or false in case of
+	// null) or raises a panic with a catch.Error if an error occurred or if the token didn't match any of those. The
+	// method is meant for input from spreadsheets and shell scripts, where booleans are often written as strings or
+	// numbers.
+	ReadBoolLenient() bool
+
 	// ReadBoolOrEnd reads next token from the decoder and asserts that it is either a boolean, null, or a delimiter
 	// that matches the given end. The function returns the boolean (or false in case of null) and true if a boolean or
 	// null is found or false and false if the delimiter was found. A panic with a catch.Error is raised if neither of
 	// those cases are true.
 	ReadBoolOrEnd(end byte) (bool, bool)
 
+	// ReadByteSize reads next token from the decoder and asserts that it is a string with a byte size, an integer
+	// number of bytes, or null. A byte size is a non-negative number, which may have a fraction, optionally followed by
+	// a unit suffix, e.g. "10MB" or "1.5 GiB". The suffixes KB, MB, GB, TB, PB, and EB denote powers of 1000, KiB, MiB,
+	// GiB, TiB, PiB, and EiB denote powers of 1024, and B denotes bytes. Suffixes are case-insensitive. This is the
+	// format that WriteByteSize writes. The function returns the number of bytes (or 0 in case of null) or raises a
+	// panic with a catch.Error if an error occurred or if the token didn't match a byte size or null.
+	ReadByteSize() int64
+
+	// ReadBytesTo reads next token from the decoder and asserts that it is a base64 encoded string, in the format that
+	// encoding/json uses for a []byte, or null. The decoded bytes are written to the given writer in chunks rather than
+	// returned as one slice. The function returns the number of bytes written (or 0 in case of null) or raises a panic
+	// with a catch.Error if an error occurred, if the token didn't match a base64 encoded string or null, or if the
+	// writer returned an error.
+	//
+	// The encoded string is always held in memory as a whole: a decoder created by NewBytesDecoder decodes it from its
+	// input without a copy, a decoder that scans an io.Reader holds it in its buffer, and other decoders hold it as the
+	// token that the json.Decoder reads.
+	ReadBytesTo(w io.Writer) int64
+
 	// ReadConsumer reads next token from the decoder and, unless that token is null, it passes that token to the given
 	// consumers UnmarshalFromJSON and then returns true. If the null token is read, this function returns false
 	ReadConsumer(c Consumer) bool
@@ -34,15 +116,55 @@ type Decoder interface {
 	// false, false if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are true.
 	ReadConsumerOrEnd(c Consumer, end byte) (bool, bool)
 
+	// ReadConsumers reads the next value from the decoder and, unless that value is null, passes it to the
+	// UnmarshalFromJSON method of each of the given consumers in turn and then returns true. If the value is null, this
+	// function returns false. The value is read once and its tokens are kept so that they can be replayed to each
+	// consumer, which makes it possible to populate e.g. both a domain object and an audit projection in one pass over
+	// the input. A panic with a catch.Error is raised if an error occurred.
+	//
+	// The consumers read the value through decoders of their own, on which the value is a top level value, so the paths
+	// returned by CurrentPath are relative to the value. Any part of the value that a consumer doesn't read is ignored.
+	ReadConsumers(cs ...Consumer) bool
+
+	// ReadDecimal reads next token from the decoder and asserts that it is a number, a string, or null. The number, or
+	// the string, is assigned to the given value using its SetString method. A null leaves the value unchanged. A panic
+	// with a catch.Error is raised if an error occurred, if the token didn't match a number or a string, or if
+	// SetString returned an error.
+	//
+	// Strings are accepted since monetary amounts are often quoted to protect them from readers that use float64.
+	ReadDecimal(v Decimal)
+
 	// ReadDelim reads next token from the decoder and asserts that it is equal to the given delimiter. A panic
 	// with a catch.Error is raised if that is not the case.
 	ReadDelim(delim byte)
 
+	// ReadDuration reads next token from the decoder and asserts that it is a string in the format accepted by
+	// time.ParseDuration, a number of milliseconds, or null. This is the format that WriteDuration writes. The function
+	// returns the duration (or 0 in case of null) or raises a panic with a catch.Error if an error occurred or if the
+	// token didn't match a duration or null.
+	ReadDuration() time.Duration
+
+	// ReadEnumCode reads next token from the decoder and asserts that it is a string that is registered with the given
+	// codec, or null. The function returns the code of the string (or 0 in case of null) or raises a panic with a
+	// catch.Error if an error occurred or if the token didn't match a registered string or null.
+	ReadEnumCode(c *EnumCodec) int64
+
+	// ReadFlags reads next value from the decoder and asserts that it is an array of strings that are registered with
+	// the given codec, or null. The function returns the bitmask with the bits of all flags in the array set (or 0 in
+	// case of null) or raises a panic with a catch.Error if an error occurred or if the value didn't match an array of
+	// registered strings or null.
+	ReadFlags(c *FlagCodec) uint64
+
 	// ReadFloatOrEnd reads next token from the decoder and asserts that it is a float, null, or a delimiter that
 	// matches the given end. The function returns the float (or 0.0 in case of null) and true if a float was found or 0
 	// and false if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are true.
 	ReadFloat() float64
 
+	// ReadFloat32 is like ReadFloat but also asserts that the number is within the range of a float32. The number is
+	// rounded to the nearest float32. A panic with a catch.Error that contains the number and the path to it (see
+	// CurrentPath) is raised if it is out of range.
+	ReadFloat32() float32
+
 	// ReadFloatOrEnd reads next token from the decoder and asserts that it is either an float or a delimiter that
 	// matches the given end. The function returns the float and true if an integer is found or 0 and false
 	// if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are true.
@@ -53,26 +175,284 @@ type Decoder interface {
 	// match an integer or null.
 	ReadInt() int64
 
+	// ReadInt16 is like ReadInt but also asserts that the integer is within the range of an int16. A panic with a
+	// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is out of range.
+	ReadInt16() int16
+
+	// ReadInt32 is like ReadInt but also asserts that the integer is within the range of an int32. A panic with a
+	// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is out of range.
+	ReadInt32() int32
+
+	// ReadInt64String reads next token from the decoder and asserts that it is a string that contains an integer, or
+	// null. This is the format that WriteInt64String writes. The function returns the integer (or 0 in case of null) or
+	// raises a panic with a catch.Error if an error occurred or if the token didn't match a string that contains an
+	// integer or null.
+	ReadInt64String() int64
+
+	// ReadInt8 is like ReadInt but also asserts that the integer is within the range of an int8. A panic with a
+	// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is out of range.
+	ReadInt8() int8
+
+	// ReadIntExact reads next token from the decoder and asserts that it is a number that is written as an integer,
+	// i.e. without a fraction or exponent part. Unlike ReadInt, null is not accepted. The function returns the
+	// integer or raises a panic with a catch.Error if an error occurred or if the token didn't match an integer.
+	ReadIntExact() int64
+
+	// ReadIntExactOrEnd reads next token from the decoder and asserts that it is either a number that is written as an
+	// integer or a delimiter that matches the given end. The function returns the integer and true if an integer was
+	// found or 0 and false if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are
+	// true.
+	ReadIntExactOrEnd(end byte) (int64, bool)
+
 	// ReadIntOrEnd reads next token from the decoder and asserts that it is an integer, null, or a delimiter that
 	// matches the given end. The function returns the integer (or 0 in case of null) and true if an integer was found
 	// or 0 and false if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are
 	// true.
 	ReadIntOrEnd(end byte) (int64, bool)
 
+	// ReadInteger reads next token from the decoder and asserts that it is an integer or null. An integer that fits an
+	// int64 is returned as that int64 and a nil *big.Int, and any other integer as 0 and a *big.Int, so that
+	// identifiers from systems that use wider integers are neither rejected nor rounded. The function returns 0 and nil
+	// in case of null or raises a panic with a catch.Error if an error occurred or if the token didn't match an integer
+	// or null.
+	ReadInteger() (int64, *big.Int)
+
+	// ReadInto reads the next value from the decoder and assigns it to the given pointer using the Read method that
+	// matches the type of the pointer. Supported types are pointers to bool, string, the int and uint types, float32,
+	// float64, time.Duration, and time.Time, a Consumer, and an encoding.TextUnmarshaler. An integer that doesn't fit
+	// the type of the pointer is an error. The method raises a panic with a catch.Error if an error occurred, if the
+	// value didn't match the type of the pointer, or if the type isn't supported.
+	//
+	// The method is a convenience for Consumers that assign the fields of a struct directly in a switch on the key.
+	// Scalar values are first passed through the hooks of the DecodeHooks option.
+	ReadInto(ptr interface{})
+
+	// ReadObjectFields reads the keys and values of an object up to and including its closing '}'. The opening '{' must
+	// already have been read. The value of a key that is found in the given fields map is read by calling its function.
+	// The value of any other key is passed to the given unknown function, which must read or skip the value, or, if
+	// unknown is nil, skipped. A panic with a catch.Error is raised if an error occurs.
+	ReadObjectFields(fields map[string]func(Decoder), unknown func(key string, d Decoder))
+
+	// ReadRawAppend reads the next value from the decoder, appends its raw JSON bytes to dst, and returns the extended
+	// buffer. Passing a buffer that is reused between calls avoids an allocation per captured value. A panic with a
+	// catch.Error is raised if an error occurred or if the next token isn't the start of a value.
+	ReadRawAppend(dst []byte) []byte
+
+	// ReadRawAppendOrEnd reads the next value from the decoder, appends its raw JSON bytes to dst, and returns the
+	// extended buffer and true, or, if the next token is a delimiter that matches the given end, reads that delimiter
+	// and returns dst and false. A panic with a catch.Error is raised if neither of those cases are true.
+	ReadRawAppendOrEnd(dst []byte, end byte) ([]byte, bool)
+
 	// ReadString reads next token from the decoder and asserts that it is a string or null. The function returns the
 	// string (or an empty string in case of null) or raises a panic with a catch.Error if an error occurred or if the
 	// token didn't match a string.
 	ReadString() string
 
+	// ReadStringBytes reads next token from the decoder and asserts that it is a string or null. The function returns
+	// the unquoted bytes of the string (or nil in case of null) or raises a panic with a catch.Error if an error
+	// occurred or if the token didn't match a string. The returned bytes are only valid until the next call to the
+	// decoder and must not be modified.
+	ReadStringBytes() []byte
+
+	// ReadStringBytesOrEnd reads next token from the decoder and asserts that it is a string, null, or a delimiter that
+	// matches the given end. The function returns the unquoted bytes of the string (or nil in case of null) and true if
+	// a string or null is found or nil and false if the delimiter was found. A panic with a catch.Error is raised if
+	// neither of those cases are true. The returned bytes are only valid until the next call to the decoder and must not
+	// be modified.
+	ReadStringBytesOrEnd(end byte) ([]byte, bool)
+
+	// ReadStringEnum reads next token from the decoder and asserts that it is a string that is equal to one of the
+	// given allowed values, or null. The function returns the matching allowed value, so that no string is allocated
+	// for it, or an empty string in case of null. A typed string constant, e.g. of a type Status, is validated by
+	// converting it to a string and converting the result back. The function raises a panic with a catch.Error if an
+	// error occurred or if the token didn't match any of the allowed values.
+	ReadStringEnum(allowed ...string) string
+
 	// ReadStringOrEnd reads next token from the decoder and asserts that it is a string, null, or a delimiter that
 	// matches the given end. The delimiter must be either a '}' or a ']'. The function returns the string (or an empty
 	// string in case of null) and true if a string or null is found or an empty string and false if the delimiter was
 	// found. A panic with a catch.Error is raised if neither of those cases are true.
 	ReadStringOrEnd(end byte) (string, bool)
+
+	// ReadStringReader reads next token from the decoder and asserts that it is a string or null. The function returns
+	// a reader of the unquoted string (or of nothing in case of null) or raises a panic with a catch.Error if an error
+	// occurred or if the token didn't match a string. The reader remains valid after subsequent calls to the decoder.
+	//
+	// The reader is meant for strings that contain embedded documents or logs that should not be copied into a Go
+	// string. When the decoder scans its input (see NewBytesDecoder), the escapes are decoded while the reader is read,
+	// so neither a string nor a copy of the unquoted bytes is allocated. The quoted string is still held in memory as a
+	// whole, either as the input of NewBytesDecoder or in the buffer of a decoder that scans an io.Reader. Other
+	// decoders hold the unquoted string in memory since the json.Decoder reads the whole token.
+	ReadStringReader() io.Reader
+
+	// ReadText reads next token from the decoder and asserts that it is a string or null. The unquoted string is passed
+	// to the UnmarshalText method of the given value. A null leaves the value unchanged. The function raises a panic
+	// with a catch.Error if an error occurred, if the token didn't match a string, or if UnmarshalText returned an
+	// error.
+	//
+	// The method makes types like time.Time, net.IP, and big.Int, and other types that implement
+	// encoding.TextUnmarshaler, readable by a Consumer.
+	ReadText(v encoding.TextUnmarshaler)
+
+	// ReadTimeLayouts reads next token from the decoder and asserts that it is a string, a number, or null. A string is
+	// parsed using the first of the given layouts that matches it, or the layouts returned by DefaultTimeLayouts when
+	// no layouts are given. A number is the number of seconds, possibly with a fraction, since the Unix epoch. The
+	// function returns the time (or the zero time in case of null) or raises a panic with a catch.Error if an error
+	// occurred or if the token didn't match any of the layouts. A time without a zone is in UTC unless the TimeLocation
+	// or RejectNaiveTimes option is given.
+	ReadTimeLayouts(layouts ...string) time.Time
+
+	// ReadTimeUnix reads next token from the decoder and asserts that it is a number or null. The number is the number
+	// of seconds, possibly with a fraction, since the Unix epoch. The function returns the time (or the zero time in
+	// case of null) or raises a panic with a catch.Error if an error occurred or if the token didn't match a number.
+	ReadTimeUnix() time.Time
+
+	// ReadTimeUnixMillis reads next token from the decoder and asserts that it is a number or null. The number is the
+	// number of milliseconds, possibly with a fraction, since the Unix epoch, as used by e.g. JavaScript. The function
+	// returns the time (or the zero time in case of null) or raises a panic with a catch.Error if an error occurred or
+	// if the token didn't match a number.
+	ReadTimeUnixMillis() time.Time
+
+	// ReadUint16 is like ReadInt but also asserts that the integer is within the range of a uint16. A panic with a
+	// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is negative or out of
+	// range.
+	ReadUint16() uint16
+
+	// ReadUint32 is like ReadInt but also asserts that the integer is within the range of a uint32. A panic with a
+	// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is negative or out of
+	// range.
+	ReadUint32() uint32
+
+	// ReadUint64 reads next token from the decoder and asserts that it is a non-negative integer or null. Unlike
+	// ReadInt, the full range of a uint64 is accepted. The function returns the integer (or 0 in case of null) or
+	// raises a panic with a catch.Error if an error occurred, if the token didn't match an integer or null, or if the
+	// integer is negative or out of range, in which case the error contains the integer and the path to it (see
+	// CurrentPath).
+	ReadUint64() uint64
+
+	// ReadUint8 is like ReadInt but also asserts that the integer is within the range of a uint8. A panic with a
+	// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is negative or out of
+	// range.
+	ReadUint8() uint8
+
+	// Reset returns the decoder to the position that was saved by the last call to Mark and removes the mark, so that
+	// the tokens read since then will be read again. Mark can be called directly after Reset to make another attempt. A
+	// panic with a catch.Error is raised if there is no mark.
+	Reset()
+
+	// Skip reads and discards the next value, including all nested values when it is an array or an object. A panic
+	// with a catch.Error is raised if an error occurred or if the next token isn't the start of a value.
+	Skip()
+
+	// SkipRemaining reads and discards the remaining values of the current array or object, up to and including the
+	// delimiter that closes it. It is typically used by a Consumer that has found what it needs. A panic with a
+	// catch.Error is raised if an error occurred or if no array or object is open.
+	SkipRemaining()
+
+	// TokenSpan returns the byte offsets in the input of the start and the end of the last token that was read, so that
+	// tools such as linters and editors can map what they find back to the source. The span is only known for decoders
+	// that scan their input directly (see NewBytesDecoder). Other decoders, and decoders that haven't read a token yet,
+	// return -1, -1.
+	TokenSpan() (start, end int64)
+
+	// Unmark removes the mark that was saved by the last call to Mark without returning to it, e.g. when a speculative
+	// read succeeded, so that the decoder stops keeping the tokens that it reads. A panic with a catch.Error is raised
+	// if there is no mark.
+	Unmark()
+
+	// ValueSpan returns the byte offsets in the input of the start and the end of the last complete value that was
+	// read, i.e. the last string, number, literal, or the array or object that was closed by the last end delimiter.
+	// Object keys are not values. The span is only known for decoders that scan their input directly (see
+	// NewBytesDecoder). Other decoders, and decoders that haven't read a complete value yet, return -1, -1.
+	ValueSpan() (start, end int64)
+
+	// WasNull returns true if the value that was read by the last call to a Read method was null. It is typically used
+	// to tell a null apart from a zero value after a call to ReadBool, ReadFloat, ReadInt, or ReadString.
+	WasNull() bool
+}
+
+// A TokenSource is a tokenizer that a Decoder can read from. The tokens must be of the types returned by a
+// json.Decoder that has UseNumber enabled, i.e. json.Delim, bool, json.Number, string, or nil. A *json.Decoder is a
+// TokenSource, and so is the Decoder of other packages that mirror its API, such as github.com/goccy/go-json, which
+// makes it possible to choose a faster tokenizer without changing any Consumer.
+type TokenSource interface {
+	// Token returns the next JSON token in the input stream. At the end of the input stream, Token returns
+	// nil, io.EOF.
+	Token() (json.Token, error)
 }
 
 type decoder struct {
-	*json.Decoder
+	TokenSource
+	decoderOptions
+
+	// integral is true when the last number read was written without a fraction or exponent part
+	integral bool
+
+	// null is true when the last value read was null
+	null bool
+
+	// stack holds the currently open containers when the token source doesn't keep track of them
+	stack []byte
+
+	// path holds the current array index or object key of each open container. It is only maintained when the token
+	// source doesn't keep track of it
+	path []pathElem
+
+	// lastKey is the last key read from an object and atKey is true when the next token is expected to be a key. Both
+	// are only maintained when the token source doesn't keep track of them
+	lastKey string
+	atKey   bool
+
+	// scratch is reused when capturing raw values
+	scratch []byte
+
+	// mark is the state saved by Mark, or nil
+	mark *decoderMark
+
+	// buffered holds the tokens read from a json.Decoder since Mark was called and the tokens that remain to be read
+	// again after Reset. The next token to read is found at index next
+	buffered []json.Token
+	next     int
+
+	// tokens is the number of tokens read when the token source isn't a scanner, which counts them itself
+	tokens int64
+}
+
+// decoderMark is the state of a decoder that is restored by Reset.
+type decoderMark struct {
+	scanner  scanner
+	stack    []byte
+	path     []pathElem
+	lastKey  string
+	atKey    bool
+	integral bool
+	null     bool
+}
+
+// pathElem is the current array index or object key of an open container. The index is -1 before the first element
+// or key has been read.
+type pathElem struct {
+	key   string
+	index int
+}
+
+// escapePointerKey escapes the characters that have a special meaning in a JSON Pointer in the given key.
+func escapePointerKey(key string) string {
+	if !strings.ContainsAny(key, "~/") {
+		return key
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; c {
+		case '~':
+			b.WriteString("~0")
+		case '/':
+			b.WriteString("~1")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
 }
 
 // A Consumer can initialize itself using a json.Decoder
@@ -81,45 +461,584 @@ type Consumer interface {
 	UnmarshalFromJSON(js Decoder, firstToken json.Token)
 }
 
-// unexpectedError converts io.EOF to io.ErrUnexpectedEOF. All errors are wrapped in a catch.Error before returned
-func unexpectedError(err error) error {
+// A debugError is an error that is annotated with the state of the decoder that produced it.
+type debugError struct {
+	err   error
+	state string
+}
+
+func (e *debugError) Error() string {
+	return e.err.Error() + " (" + e.state + ")"
+}
+
+// Unwrap returns the annotated error.
+func (e *debugError) Unwrap() error {
+	return e.err
+}
+
+// NewDecoder creates a new Decoder that reads from the given io.Reader. The decoder reads its tokens using a
+// json.Decoder unless one of the options that check or relax the syntax, i.e. AllowComments, AllowNonFinite, Escapes,
+// ObjectsOnly, Relaxed, Strict, and StrictStrings, or the InternKeys option is given. The decoder then scans its input
+// directly, in the same way as a decoder created with NewBytesDecoder, reading from the io.Reader as needed, and holds
+// the top level value that it reads in memory. Its JSONDecoder method returns nil. Other options that only apply to
+// decoders that scan their input directly are ignored.
+func NewDecoder(r io.Reader, options ...DecoderOption) Decoder {
+	o := newDecoderOptions(options)
+	if o.hash != nil {
+		r = io.TeeReader(r, o.hash)
+	}
+	if o.scansInput() {
+		return &decoder{TokenSource: newReaderScanner(r, o), decoderOptions: *o}
+	}
+	if o.bufferSize > 0 {
+		r = bufio.NewReaderSize(r, o.bufferSize)
+	}
+	js := json.NewDecoder(r)
+	js.UseNumber()
+	return &decoder{TokenSource: js, decoderOptions: *o}
+}
+
+// NewTokenSourceDecoder creates a new Decoder that reads its tokens from the given TokenSource. A *json.Decoder that is
+// passed to this function must have UseNumber enabled. Options that only apply to decoders that scan their input
+// directly are ignored.
+func NewTokenSourceDecoder(ts TokenSource, options ...DecoderOption) Decoder {
+	return &decoder{TokenSource: ts, decoderOptions: *newDecoderOptions(options)}
+}
+
+// NewBytesDecoder creates a new Decoder that is optimized for input that is fully contained in memory. The decoder
+// scans the given bytes directly, without the io.Reader and buffer management imposed by a json.Decoder. The
+// decoder does not copy the bytes so they must not be modified while the decoder is in use.
+//
+// The JSONDecoder method of the returned decoder returns nil.
+func NewBytesDecoder(bs []byte, options ...DecoderOption) Decoder {
+	o := newDecoderOptions(options)
+	if o.hash != nil {
+		o.hash.Write(bs)
+	}
+	return &decoder{TokenSource: newScanner(bs, o), decoderOptions: *o}
+}
+
+// unexpectedError converts io.EOF to io.ErrUnexpectedEOF and annotates the error with the state of the decoder when the
+// Debug option is in effect. All errors are wrapped in a catch.Error before returned.
+func (d *decoder) unexpectedError(err error) error {
 	if err == io.EOF {
 		err = io.ErrUnexpectedEOF
 	}
+	if d.debug {
+		err = &debugError{err: err, state: d.DebugState()}
+	}
 	return catch.Error(err)
 }
 
-// NewDecoder creates a new Decoder that reads from the given io.Reader.
-func NewDecoder(r io.Reader) Decoder {
-	js := json.NewDecoder(r)
-	js.UseNumber()
-	return &decoder{js}
+// numberOrToken reads the next token. When the decoder scans its input directly, a number is returned as its
+// literal bytes so that it can be parsed without the allocation of a json.Number. All other tokens are returned as is.
+func (d *decoder) numberOrToken() ([]byte, json.Token, error) {
+	if s, ok := d.TokenSource.(*scanner); ok {
+		n, t, err := s.numberOrToken()
+		if n != nil {
+			d.integral = isIntegral(n)
+		}
+		d.null = n == nil && t == nil && err == nil
+		return n, t, err
+	}
+	t, err := d.Token()
+	if n, ok := t.(json.Number); ok {
+		d.integral = !strings.ContainsAny(string(n), ".eE")
+	}
+	return nil, t, err
+}
+
+// assertExact returns an error if the ExactFloats option is in effect and the given JSON number isn't exactly
+// represented by f, unless the option was given a function that accepts the inexact number.
+func (d *decoder) assertExact(n []byte, f float64) error {
+	if !d.exactFloats || isExactFloat(n, f) {
+		return nil
+	}
+	if d.inexact != nil {
+		d.inexact(json.Number(n))
+		return nil
+	}
+	return fmt.Errorf("number %s cannot be represented exactly as a float64", n)
+}
+
+// coerceToken returns the token that the given token is coerced to when the CoerceScalars option is in effect. A
+// string that contains a JSON number is coerced to a json.Number and the strings "true" and "false" are coerced to a
+// bool. All other tokens are returned as is.
+func (d *decoder) coerceToken(t json.Token) json.Token {
+	s, ok := t.(string)
+	if !(ok && d.coerceScalars) {
+		return t
+	}
+	switch {
+	case s == "true":
+		return true
+	case s == "false":
+		return false
+	case isNumber(s):
+		d.integral = isIntegral([]byte(s))
+		return json.Number(s)
+	}
+	return t
+}
+
+// consume passes the given first token of a value to the consumer. When the CaptureRaw option is in effect, the raw
+// bytes of the value are then passed to the capture function.
+func (d *decoder) consume(c Consumer, t json.Token) {
+	s, ok := d.TokenSource.(*scanner)
+	if !ok || d.captureRaw == nil {
+		c.UnmarshalFromJSON(d, t)
+		return
+	}
+	path, start := s.pointer(), s.start
+	c.UnmarshalFromJSON(d, t)
+	d.captureRaw(path, s.buf[start:s.end])
+}
+
+// Token returns the next token from the token source and keeps track of the containers that are opened and closed
+// unless the token source does that itself.
+func (d *decoder) Token() (json.Token, error) {
+	if _, ok := d.TokenSource.(*scanner); ok {
+		t, err := d.TokenSource.Token()
+		d.null = t == nil && err == nil
+		return t, err
+	}
+	var t json.Token
+	var err error
+	if d.next < len(d.buffered) {
+		t = d.buffered[d.next]
+		d.next++
+	} else {
+		if d.mark == nil && d.next > 0 {
+			d.buffered = d.buffered[:0]
+			d.next = 0
+		}
+		if t, err = d.TokenSource.Token(); err == nil && d.mark != nil {
+			d.buffered = append(d.buffered, t)
+			d.next++
+		}
+	}
+	d.null = t == nil && err == nil
+	if err == nil {
+		d.tokens++
+		d.track(t)
+	}
+	return t, err
+}
+
+// tokenCount returns the number of tokens that have been read.
+func (d *decoder) tokenCount() int64 {
+	if s, ok := d.TokenSource.(*scanner); ok {
+		return s.tokens
+	}
+	return d.tokens
+}
+
+// buffering returns true if the decoder reads from a json.Decoder and tokens are buffered for a Reset or remain to
+// be read again after a Reset.
+func (d *decoder) buffering() bool {
+	return d.mark != nil || d.next < len(d.buffered)
+}
+
+// appendTokens reads the next value token by token and appends its compact JSON form to dst. It is used in place
+// of json.Decoder.Decode when tokens are buffered.
+func (d *decoder) appendTokens(dst []byte) ([]byte, error) {
+	var last byte
+	depth := 0
+	for {
+		key := d.atKey
+		t, err := d.Token()
+		if err != nil {
+			return dst, err
+		}
+		if dl, ok := t.(json.Delim); ok {
+			if dl == '[' || dl == '{' {
+				depth++
+			} else if depth--; depth < 0 {
+				return dst, fmt.Errorf("expected a value, got delimiter '%c'", dl)
+			}
+		}
+		dst = appendToken(dst, last, t, key)
+		if depth == 0 {
+			return dst, nil
+		}
+		last = dst[len(dst)-1]
+	}
+}
+
+// appendToken appends the compact JSON form of the given token to b, preceded by a comma when one is needed after
+// the previously appended byte last, which is 0 at the start of a value, and followed by a colon when the token is
+// an object key.
+func appendToken(b []byte, last byte, t json.Token, key bool) []byte {
+	if dl, ok := t.(json.Delim); ok && (dl == ']' || dl == '}') {
+		return append(b, byte(dl))
+	}
+	switch last {
+	case 0, '[', '{', ':':
+	default:
+		b = append(b, ',')
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		b = append(b, byte(t))
+	case string:
+		b = appendTokenString(b, t)
+		if key {
+			b = append(b, ':')
+		}
+	case json.Number:
+		b = append(b, t...)
+	case bool:
+		b = strconv.AppendBool(b, t)
+	case nil:
+		b = append(b, "null"...)
+	}
+	return b
+}
+
+// appendTokenString appends the given string as a double quoted JSON string to b. Unlike WriteString, it escapes
+// control characters, so that a string token read from JSON input is written back as valid JSON.
+func appendTokenString(b []byte, s string) []byte {
+	b = append(b, '"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b = append(b, '\\', byte(r))
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		case '\b':
+			b = append(b, '\\', 'b')
+		case '\f':
+			b = append(b, '\\', 'f')
+		default:
+			if r < ' ' {
+				b = append(b, '\\', 'u', '0', '0', hexDigits[r>>4], hexDigits[r&0xf])
+			} else {
+				var buf [utf8.UTFMax]byte
+				b = append(b, buf[:utf8.EncodeRune(buf[:], r)]...)
+			}
+		}
+	}
+	return append(b, '"')
+}
+
+// track keeps track of the open containers and the last key read from an object for token sources that don't do that
+// themselves.
+func (d *decoder) track(t json.Token) {
+	n := len(d.stack)
+	if d.atKey {
+		if s, ok := t.(string); ok {
+			d.lastKey = s
+			d.path[n-1] = pathElem{key: s}
+			d.atKey = false
+			return
+		}
+	}
+	dl, isDelim := t.(json.Delim)
+	if isDelim && (dl == ']' || dl == '}') {
+		if n > 0 {
+			d.stack = d.stack[:n-1]
+			d.path = d.path[:n-1]
+		}
+	} else {
+		d.valueStarted()
+		if isDelim {
+			d.stack = append(d.stack, byte(dl))
+			d.path = append(d.path, pathElem{index: -1})
+			d.atKey = dl == '{'
+			return
+		}
+	}
+	d.atKey = d.container() == '{'
+}
+
+// valueStarted advances the index of the current array, if any, when a value starts.
+func (d *decoder) valueStarted() {
+	if n := len(d.stack); n > 0 && d.stack[n-1] == '[' {
+		d.path[n-1].index++
+	}
+}
+
+// atEnd returns true if the next token is a delimiter that ends an array or an object.
+func (d *decoder) atEnd() bool {
+	switch ts := d.TokenSource.(type) {
+	case *scanner:
+		c, ok := ts.skipSpace()
+		return ok && (c == ']' || c == '}')
+	case *json.Decoder:
+		if d.next < len(d.buffered) {
+			t := d.buffered[d.next]
+			return isDelim(t, ']') || isDelim(t, '}')
+		}
+		return !ts.More()
+	}
+	ts := d.PeekN(1)
+	return len(ts) > 0 && (isDelim(ts[0], ']') || isDelim(ts[0], '}'))
+}
+
+// containers returns the delimiters that opened the currently open containers, outermost first.
+func (d *decoder) containers() []byte {
+	if s, ok := d.TokenSource.(*scanner); ok {
+		return s.stack
+	}
+	return d.stack
+}
+
+// container returns the delimiter that opened the current container or 0 when the decoder is at the top level.
+func (d *decoder) container() byte {
+	stack := d.containers()
+	if n := len(stack); n > 0 {
+		return stack[n-1]
+	}
+	return 0
+}
+
+// assertEnd asserts that the given end delimiter matches the current container. A panic with a catch.Error is
+// raised if that is not the case. The assertion catches consumers that pass '}' when reading array elements or ']'
+// when reading object keys.
+func (d *decoder) assertEnd(end byte) {
+	switch c := d.container(); {
+	case c == 0, c == '[' && end == ']', c == '{' && end == '}':
+	default:
+		panic(d.unexpectedError(fmt.Errorf("end delimiter '%c' does not match the current container '%c'", end, c)))
+	}
 }
 
 // AssertDelim asserts that the given token is equal to the given delimiter. A panic
 // with a catch.Error is raised if that is not the case.
 func AssertDelim(t json.Token, delim byte) {
-	if d, ok := t.(json.Delim); ok {
-		s := d.String()
-		if len(s) == 1 && s[0] == delim {
-			return
-		}
+	if !isDelim(t, delim) {
+		panic(catch.Error(delimError(t, delim)))
 	}
-	panic(catch.Error("expected delimiter '%c', got %T %v", delim, t, t))
+}
+
+func isDelim(t json.Token, delim byte) bool {
+	d, ok := t.(json.Delim)
+	return ok && byte(d) == delim
+}
+
+func delimError(t json.Token, delim byte) error {
+	return fmt.Errorf("expected delimiter '%c', got %T %v", delim, t, t)
 }
 
 // Unmarshal is a helper function that makes it easy for consumers to implement the standard
 // json.Unmarshaller interface.
 func Unmarshal(c Consumer, bs []byte) error {
 	return catch.Do(func() {
-		js := NewDecoder(bytes.NewReader(bs))
+		js := NewBytesDecoder(bs)
 		js.ReadConsumer(c)
 	})
 }
 
-// JSONDecoder returns the underlying json.Decoder instance
+// UnmarshalReader reads the next JSON value from the given reader and passes it to the given Consumer. The given
+// options are applied to the decoder.
+func UnmarshalReader(c Consumer, r io.Reader, options ...DecoderOption) error {
+	return catch.Do(func() {
+		NewDecoder(r, options...).ReadConsumer(c)
+	})
+}
+
+// MustUnmarshal is like Unmarshal but raises a panic with a catch.Error instead of returning an error.
+func MustUnmarshal(c Consumer, bs []byte) {
+	if err := Unmarshal(c, bs); err != nil {
+		panic(catch.Error(err))
+	}
+}
+
+// Allocator returns the allocator given with the Allocate option, or an allocator that allocates on the heap.
+func (d *decoder) Allocator() Allocator {
+	if d.allocator == nil {
+		return heapAllocator{}
+	}
+	return d.allocator
+}
+
+// CurrentPath returns a JSON Pointer to the value that was last read, or, when the last token read was an object key,
+// to the value of that key. The pointer of a top level value is an empty string. It is typically used for logging or to
+// tell where in a document a generic Consumer is.
+func (d *decoder) CurrentPath() string {
+	if s, ok := d.TokenSource.(*scanner); ok {
+		return s.pointer()
+	}
+	var b strings.Builder
+	for i, p := range d.path {
+		if p.index < 0 {
+			break
+		}
+		b.WriteByte('/')
+		if d.stack[i] == '[' {
+			b.WriteString(strconv.Itoa(p.index))
+		} else {
+			b.WriteString(escapePointerKey(p.key))
+		}
+	}
+	return b.String()
+}
+
+// DebugState returns a description of the current state of the decoder that contains the nesting depth, the kinds
+// of the open containers, the last key read from an object, and the byte offset in the input. The offset is -1 when
+// it isn't known.
+func (d *decoder) DebugState() string {
+	stack := d.stack
+	key := d.lastKey
+	offset := int64(-1)
+	switch ts := d.TokenSource.(type) {
+	case *scanner:
+		stack = ts.stack
+		key = ts.lastKey()
+		offset = int64(ts.base + ts.pos)
+	case interface{ InputOffset() int64 }:
+		// json.Decoder has InputOffset since Go 1.14
+		offset = ts.InputOffset()
+	}
+	return fmt.Sprintf("depth %d, containers %q, last key %q, offset %d", len(stack), stack, key, offset)
+}
+
+// Depth returns the number of arrays and objects that are currently open, i.e. 0 at the top level, 1 after the opening
+// delimiter of a top level array or object has been read, and so on.
+func (d *decoder) Depth() int {
+	return len(d.containers())
+}
+
+// JSONDecoder returns the underlying json.Decoder instance or nil if the decoder reads its tokens without using
+// a json.Decoder (see NewBytesDecoder).
 func (d *decoder) JSONDecoder() *json.Decoder {
-	return d.Decoder
+	jd, _ := d.TokenSource.(*json.Decoder)
+	return jd
+}
+
+// LastNumberIntegral returns true if the last number that was read by the decoder was written without a fraction
+// or exponent part, i.e. true for 1 but false for 1.0 and 1e0.
+func (d *decoder) LastNumberIntegral() bool {
+	return d.integral
+}
+
+// Mark saves the current position of the decoder so that a later call to Reset can return to it. The decoder then keeps
+// the tokens that it reads until Reset, Unmark, or Mark is called, which makes speculative decoding possible, e.g.
+// trying one Consumer and, when it fails, reading the same value again using another. Tokens that are read directly
+// from the json.Decoder returned by JSONDecoder are not kept.
+func (d *decoder) Mark() {
+	m := &decoderMark{
+		stack:    append([]byte(nil), d.stack...),
+		path:     append([]pathElem(nil), d.path...),
+		lastKey:  d.lastKey,
+		atKey:    d.atKey,
+		integral: d.integral,
+		null:     d.null,
+	}
+	if s, ok := d.TokenSource.(*scanner); ok {
+		s.pinned = true
+		m.scanner = s.snapshot()
+	} else {
+		d.buffered = append(d.buffered[:0], d.buffered[d.next:]...)
+		d.next = 0
+	}
+	d.mark = m
+}
+
+// PeekKind returns the kind of the next value without consuming it, so that dispatch code can switch on a Kind rather
+// than on the type of a json.Token. KindInvalid is returned when the input ends or when the next token is the end of an
+// array or an object. A panic with a catch.Error is raised if an error occurred.
+func (d *decoder) PeekKind() Kind {
+	ts := d.PeekN(1)
+	if len(ts) == 0 {
+		return KindInvalid
+	}
+	return kindOfToken(ts[0])
+}
+
+// PeekN returns the next n tokens without consuming them, so that they are returned again by the following reads. Fewer
+// than n tokens are returned when the input ends. It is typically used to look at the first key of an object before
+// deciding how to read it. A panic with a catch.Error is raised if an error occurred.
+func (d *decoder) PeekN(n int) []json.Token {
+	ts := make([]json.Token, 0, n)
+	if s, ok := d.TokenSource.(*scanner); ok {
+		saved := s.snapshot()
+		s.pinned = true
+		defer s.restore(saved)
+	} else {
+		ts = append(ts, d.buffered[d.next:]...)
+	}
+	for len(ts) < n {
+		t, err := d.TokenSource.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(d.unexpectedError(err))
+		}
+		ts = append(ts, t)
+		if _, ok := d.TokenSource.(*scanner); !ok {
+			d.buffered = append(d.buffered, t)
+		}
+	}
+	if len(ts) > n {
+		ts = ts[:n]
+	}
+	return ts
+}
+
+// ReadAny reads the next value from the decoder and returns it in the form that encoding/json uses when it unmarshals
+// into an interface{}, i.e. as a map[string]interface{}, a []interface{}, a string, a float64, a bool, or nil. The
+// given options, and the Numbers option of the decoder, change how numbers and objects are represented and limit the
+// depth. A panic with a catch.Error is raised if an error occurred.
+func (d *decoder) ReadAny(options ...AnyOption) interface{} {
+	o := newAnyOptions(d.numbers, options)
+	o.allocator = d.Allocator()
+	return d.readAny(o, 0)
+}
+
+func (d *decoder) readAny(o *anyOptions, depth int) interface{} {
+	t, err := d.Token()
+	if err != nil {
+		panic(d.unexpectedError(err))
+	}
+	switch tv := t.(type) {
+	case json.Delim:
+		if tv == ']' || tv == '}' {
+			panic(d.unexpectedError(fmt.Errorf("expected a value, got delimiter '%c'", tv)))
+		}
+		if o.maxDepth > 0 && depth >= o.maxDepth {
+			panic(d.unexpectedError(fmt.Errorf("maximum depth %d exceeded", o.maxDepth)))
+		}
+		if tv == '[' {
+			// the elements are collected in the scratch of the options so that the slice can be allocated with the
+			// right size
+			start := len(o.scratch)
+			for !isDelim(peekToken(d), ']') {
+				v := d.readAny(o, depth+1)
+				o.scratch = append(o.scratch, v)
+			}
+			d.ReadDelim(']')
+			a := append(o.allocator.Slice(len(o.scratch)-start), o.scratch[start:]...)
+			o.scratch = o.scratch[:start]
+			return a
+		}
+		if o.ordered {
+			oo := &OrderedObject{}
+			oo.UnmarshalFromJSON(d, t)
+			return oo
+		}
+		m := o.allocator.Map(0)
+		for {
+			key, ok := d.ReadStringOrEnd('}')
+			if !ok {
+				return m
+			}
+			m[key] = d.readAny(o, depth+1)
+		}
+	case json.Number:
+		v, err := o.number(tv)
+		if err != nil {
+			panic(d.unexpectedError(err))
+		}
+		return v
+	default:
+		return t
+	}
 }
 
 // ReadBool reads next token from the decoder and asserts that it is an boolean or null. The function returns the
@@ -131,12 +1050,30 @@ func (d *decoder) ReadBool() bool {
 		if t == nil {
 			return false
 		}
-		if v, ok := t.(bool); ok {
+		if v, ok := d.coerceToken(t).(bool); ok {
 			return v
 		}
 		err = fmt.Errorf("expected an float, got %T %v", t, t)
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
+}
+
+// ReadBoolLenient reads next token from the decoder and asserts that it is a boolean, one of the strings "true",
+// "false", "1", and "0", one of the numbers 1 and 0, or null. The function returns the boolean (or false in case of
+// null) or raises a panic with a catch.Error if an error occurred or if the token didn't match any of those. The method
+// is meant for input from spreadsheets and shell scripts, where booleans are often written as strings or numbers.
+func (d *decoder) ReadBoolLenient() bool {
+	t, err := d.Token()
+	if err == nil {
+		switch t {
+		case nil, false, "false", "0", json.Number("0"):
+			return false
+		case true, "true", "1", json.Number("1"):
+			return true
+		}
+		err = fmt.Errorf("expected a boolean, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
 }
 
 // ReadBoolOrEnd reads next token from the decoder and asserts that it is either a boolean, null, or a delimiter
@@ -144,9 +1081,10 @@ func (d *decoder) ReadBool() bool {
 // null is found or false and false if the delimiter was found. A panic with a catch.Error is raised if neither of
 // those cases are true.
 func (d *decoder) ReadBoolOrEnd(end byte) (bool, bool) {
+	d.assertEnd(end)
 	t, err := d.Token()
 	if err == nil {
-		switch t := t.(type) {
+		switch t := d.coerceToken(t).(type) {
 		case nil:
 			return false, true
 		case bool:
@@ -159,7 +1097,63 @@ func (d *decoder) ReadBoolOrEnd(end byte) (bool, bool) {
 		}
 		err = fmt.Errorf("expected an boolean or the delimiter '%c' got %T %v", end, t, t)
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
+}
+
+// ReadByteSize reads next token from the decoder and asserts that it is a string with a byte size, an integer number of
+// bytes, or null. A byte size is a non-negative number, which may have a fraction, optionally followed by a unit
+// suffix, e.g. "10MB" or "1.5 GiB". The suffixes KB, MB, GB, TB, PB, and EB denote powers of 1000, KiB, MiB, GiB, TiB,
+// PiB, and EiB denote powers of 1024, and B denotes bytes. Suffixes are case-insensitive. This is the format that
+// WriteByteSize writes. The function returns the number of bytes (or 0 in case of null) or raises a panic with a
+// catch.Error if an error occurred or if the token didn't match a byte size or null.
+func (d *decoder) ReadByteSize() int64 {
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		if s, ok := t.(json.Number); ok {
+			n = []byte(s)
+		}
+		switch {
+		case n != nil:
+			var i int64
+			if i, err = parseInt(n); err == nil && d.integral && i >= 0 {
+				return i
+			}
+			t = json.Number(n)
+		case t == nil:
+			return 0
+		default:
+			if s, ok := t.(string); ok {
+				var i int64
+				if i, err = parseByteSize(s); err == nil {
+					return i
+				}
+				panic(d.unexpectedError(err))
+			}
+		}
+		err = fmt.Errorf("expected a byte size, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadBytesTo reads next token from the decoder and asserts that it is a base64 encoded string, in the format that
+// encoding/json uses for a []byte, or null. The decoded bytes are written to the given writer in chunks rather than
+// returned as one slice. The function returns the number of bytes written (or 0 in case of null) or raises a panic with
+// a catch.Error if an error occurred, if the token didn't match a base64 encoded string or null, or if the writer
+// returned an error.
+//
+// The encoded string is always held in memory as a whole: a decoder created by NewBytesDecoder decodes it from its
+// input without a copy, a decoder that scans an io.Reader holds it in its buffer, and other decoders hold it as the
+// token that the json.Decoder reads.
+func (d *decoder) ReadBytesTo(w io.Writer) int64 {
+	b := d.ReadStringBytes()
+	if b == nil {
+		return 0
+	}
+	n, err := io.Copy(w, base64.NewDecoder(base64.StdEncoding, bytes.NewReader(b)))
+	if err != nil {
+		panic(d.unexpectedError(err))
+	}
+	return n
 }
 
 // ReadConsumer reads next token from the decoder and, unless that token is null, it passes that token to the given
@@ -170,16 +1164,17 @@ func (d *decoder) ReadConsumer(c Consumer) bool {
 		if t == nil {
 			return false
 		}
-		c.UnmarshalFromJSON(d, t)
+		d.consume(c, t)
 		return true
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
 }
 
 // ReadConsumerOrEnd reads next token from the decoder and asserts that it is a consumer, null, or a delimiter that
 // matches the given end. The function returns true, true if a consumer is found, false, true if null is found, and
 // false, false if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are true.
 func (d *decoder) ReadConsumerOrEnd(c Consumer, end byte) (bool, bool) {
+	d.assertEnd(end)
 	t, err := d.Token()
 	if err == nil {
 		if t == nil {
@@ -191,56 +1186,239 @@ func (d *decoder) ReadConsumerOrEnd(c Consumer, end byte) (bool, bool) {
 				return false, false
 			}
 		}
-		c.UnmarshalFromJSON(d, t)
+		d.consume(c, t)
 		return true, true
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
 }
 
-// ReadDelim reads next token from the decoder and asserts that it is equal to the given delimiter. A panic
-// with a catch.Error is raised if that is not the case.
-func (d *decoder) ReadDelim(delim byte) {
-	t, err := d.Token()
-	if err == nil {
-		AssertDelim(t, delim)
-		return
+// ReadConsumers reads the next value from the decoder and, unless that value is null, passes it to the
+// UnmarshalFromJSON method of each of the given consumers in turn and then returns true. If the value is null, this
+// function returns false. The value is read once and its tokens are kept so that they can be replayed to each consumer,
+// which makes it possible to populate e.g. both a domain object and an audit projection in one pass over the input. A
+// panic with a catch.Error is raised if an error occurred.
+//
+// The consumers read the value through decoders of their own, on which the value is a top level value, so the paths
+// returned by CurrentPath are relative to the value. Any part of the value that a consumer doesn't read is ignored.
+func (d *decoder) ReadConsumers(cs ...Consumer) bool {
+	var tokens sliceSource
+	for depth := 0; ; {
+		t, err := d.Token()
+		if err != nil {
+			panic(d.unexpectedError(err))
+		}
+		if dl, ok := t.(json.Delim); ok {
+			if dl == '[' || dl == '{' {
+				depth++
+			} else if depth--; depth < 0 {
+				panic(d.unexpectedError(fmt.Errorf("expected a value, got delimiter '%c'", dl)))
+			}
+		}
+		tokens = append(tokens, t)
+		if depth == 0 {
+			break
+		}
+	}
+	if tokens[0] == nil {
+		return false
+	}
+	for _, c := range cs {
+		ts := tokens
+		(&decoder{TokenSource: &ts, decoderOptions: d.decoderOptions}).ReadConsumer(c)
+	}
+	return true
+}
+
+// ReadDecimal reads next token from the decoder and asserts that it is a number, a string, or null. The number, or the
+// string, is assigned to the given value using its SetString method. A null leaves the value unchanged. A panic with a
+// catch.Error is raised if an error occurred, if the token didn't match a number or a string, or if SetString returned
+// an error.
+//
+// Strings are accepted since monetary amounts are often quoted to protect them from readers that use float64.
+func (d *decoder) ReadDecimal(v Decimal) {
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		var s string
+		switch tv := t.(type) {
+		case nil:
+			if n == nil {
+				return
+			}
+			s = string(n)
+		case json.Number:
+			s = string(tv)
+		case string:
+			s = tv
+		default:
+			panic(d.unexpectedError(fmt.Errorf("expected a decimal number, got %T %v", t, t)))
+		}
+		if err = v.SetString(s); err == nil {
+			return
+		}
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadDelim reads next token from the decoder and asserts that it is equal to the given delimiter. A panic
+// with a catch.Error is raised if that is not the case.
+func (d *decoder) ReadDelim(delim byte) {
+	t, err := d.Token()
+	if err == nil {
+		if isDelim(t, delim) {
+			return
+		}
+		err = delimError(t, delim)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadDuration reads next token from the decoder and asserts that it is a string in the format accepted by
+// time.ParseDuration, a number of milliseconds, or null. This is the format that WriteDuration writes. The function
+// returns the duration (or 0 in case of null) or raises a panic with a catch.Error if an error occurred or if the token
+// didn't match a duration or null.
+func (d *decoder) ReadDuration() time.Duration {
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		if s, ok := t.(json.Number); ok {
+			n = []byte(s)
+		}
+		switch {
+		case n != nil:
+			var f float64
+			if f, err = parseFloat(n); err == nil && math.Abs(f) <= math.MaxInt64/float64(time.Millisecond) {
+				return time.Duration(math.Round(f * float64(time.Millisecond)))
+			}
+			t = json.Number(n)
+		case t == nil:
+			return 0
+		default:
+			if s, ok := t.(string); ok {
+				var dr time.Duration
+				if dr, err = time.ParseDuration(s); err == nil {
+					return dr
+				}
+				panic(d.unexpectedError(err))
+			}
+		}
+		err = fmt.Errorf("expected a duration, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadEnumCode reads next token from the decoder and asserts that it is a string that is registered with the given
+// codec, or null. The function returns the code of the string (or 0 in case of null) or raises a panic with a
+// catch.Error if an error occurred or if the token didn't match a registered string or null.
+func (d *decoder) ReadEnumCode(c *EnumCodec) int64 {
+	b := d.ReadStringBytes()
+	if b == nil && d.null {
+		return 0
+	}
+	if code, ok := c.codes[string(b)]; ok {
+		return code
+	}
+	panic(d.unexpectedError(fmt.Errorf("enum name %q is not registered", b)))
+}
+
+// ReadFlags reads next value from the decoder and asserts that it is an array of strings that are registered with the
+// given codec, or null. The function returns the bitmask with the bits of all flags in the array set (or 0 in case of
+// null) or raises a panic with a catch.Error if an error occurred or if the value didn't match an array of registered
+// strings or null.
+func (d *decoder) ReadFlags(c *FlagCodec) uint64 {
+	t, err := d.Token()
+	if err == nil {
+		if t == nil {
+			return 0
+		}
+		if !isDelim(t, '[') {
+			panic(d.unexpectedError(fmt.Errorf("expected an array of flags, got %T %v", t, t)))
+		}
+		var flags uint64
+		for {
+			b, ok := d.ReadStringBytesOrEnd(']')
+			if !ok {
+				return flags
+			}
+			bit, found := c.bits[string(b)]
+			if !found {
+				panic(d.unexpectedError(fmt.Errorf("flag name %q is not registered", b)))
+			}
+			flags |= bit
+		}
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
 }
 
 // ReadFloat reads next token from the decoder and asserts that it is a float or null. The function returns the
 // float (or 0.0 in case of null) or raises a panic with a catch.Error if an error occurred or if the token didn't
 // match a float or null.
 func (d *decoder) ReadFloat() float64 {
-	t, err := d.Token()
+	n, t, err := d.numberOrToken()
 	if err == nil {
+		if n != nil {
+			var f float64
+			if f, err = parseFloat(n); err == nil {
+				if err = d.assertExact(n, f); err == nil {
+					return f
+				}
+				panic(d.unexpectedError(err))
+			}
+			t = json.Number(n)
+		}
 		if t == nil {
 			return 0
 		}
-		if s, ok := t.(json.Number); ok {
+		if s, ok := d.coerceToken(t).(json.Number); ok {
 			var f float64
 			if f, err = s.Float64(); err == nil {
-				return f
+				if err = d.assertExact([]byte(s), f); err == nil {
+					return f
+				}
+				panic(d.unexpectedError(err))
 			}
 		}
 		err = fmt.Errorf("expected an float, got %T %v", t, t)
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
+}
+
+// ReadFloat32 is like ReadFloat but also asserts that the number is within the range of a float32. The number is
+// rounded to the nearest float32. A panic with a catch.Error that contains the number and the path to it (see
+// CurrentPath) is raised if it is out of range.
+func (d *decoder) ReadFloat32() float32 {
+	f := d.ReadFloat()
+	if math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+		panic(d.unexpectedError(fmt.Errorf("number %g at %q overflows float32", f, d.CurrentPath())))
+	}
+	return float32(f)
 }
 
 // ReadFloatOrEnd reads next token from the decoder and asserts that it is a float, null, or a delimiter that
 // matches the given end. The function returns the float (or 0.0 in case of null) and true if a float was found or 0
 // and false if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are true.
 func (d *decoder) ReadFloatOrEnd(end byte) (float64, bool) {
-	t, err := d.Token()
+	d.assertEnd(end)
+	n, t, err := d.numberOrToken()
 	if err == nil {
-		switch t := t.(type) {
+		if n != nil {
+			var f float64
+			if f, err = parseFloat(n); err == nil {
+				if err = d.assertExact(n, f); err == nil {
+					return f, true
+				}
+				panic(d.unexpectedError(err))
+			}
+			t = json.Number(n)
+		}
+		switch t := d.coerceToken(t).(type) {
 		case nil:
 			return 0, true
 		case json.Number:
 			var f float64
 			if f, err = t.Float64(); err == nil {
-				return f, true
+				if err = d.assertExact([]byte(t), f); err == nil {
+					return f, true
+				}
+				panic(d.unexpectedError(err))
 			}
 		case json.Delim:
 			s := t.String()
@@ -250,19 +1428,26 @@ func (d *decoder) ReadFloatOrEnd(end byte) (float64, bool) {
 		}
 		err = fmt.Errorf("expected an float or the delimiter '%c' got %T %v", end, t, t)
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
 }
 
 // ReadInt reads next token from the decoder and asserts that it is an integer or null. The function returns the
 // integer (or 0 in case of null) or raises a panic with a catch.Error if an error occurred or if the token didn't
 // match an integer or null.
 func (d *decoder) ReadInt() int64 {
-	t, err := d.Token()
+	n, t, err := d.numberOrToken()
 	if err == nil {
+		if n != nil {
+			var i int64
+			if i, err = parseInt(n); err == nil {
+				return i
+			}
+			t = json.Number(n)
+		}
 		if t == nil {
 			return 0
 		}
-		if s, ok := t.(json.Number); ok {
+		if s, ok := d.coerceToken(t).(json.Number); ok {
 			var i int64
 			if i, err = s.Int64(); err == nil {
 				return i
@@ -270,7 +1455,104 @@ func (d *decoder) ReadInt() int64 {
 		}
 		err = fmt.Errorf("expected an integer, got %T %v", t, t)
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
+}
+
+// ReadInt16 is like ReadInt but also asserts that the integer is within the range of an int16. A panic with a
+// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is out of range.
+func (d *decoder) ReadInt16() int16 {
+	return int16(d.readIntRange(math.MinInt16, math.MaxInt16, "int16"))
+}
+
+// ReadInt32 is like ReadInt but also asserts that the integer is within the range of an int32. A panic with a
+// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is out of range.
+func (d *decoder) ReadInt32() int32 {
+	return int32(d.readIntRange(math.MinInt32, math.MaxInt32, "int32"))
+}
+
+// ReadInt64String reads next token from the decoder and asserts that it is a string that contains an integer, or null.
+// This is the format that WriteInt64String writes. The function returns the integer (or 0 in case of null) or raises a
+// panic with a catch.Error if an error occurred or if the token didn't match a string that contains an integer or null.
+func (d *decoder) ReadInt64String() int64 {
+	var t json.Token
+	var err error
+	var b []byte
+	if s, ok := d.TokenSource.(*scanner); ok {
+		b, t, err = s.stringBytesOrToken()
+		d.null = b == nil && t == nil && err == nil
+	} else if t, err = d.Token(); err == nil {
+		if s, ok := t.(string); ok {
+			b = []byte(s)
+		}
+	}
+	if err == nil {
+		if b != nil {
+			if i, err := parseInt(b); err == nil {
+				return i
+			}
+			t = string(b)
+		} else if t == nil {
+			return 0
+		}
+		err = fmt.Errorf("expected a string that contains an integer, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadInt8 is like ReadInt but also asserts that the integer is within the range of an int8. A panic with a catch.Error
+// that contains the integer and the path to it (see CurrentPath) is raised if it is out of range.
+func (d *decoder) ReadInt8() int8 {
+	return int8(d.readIntRange(math.MinInt8, math.MaxInt8, "int8"))
+}
+
+// ReadIntExact reads next token from the decoder and asserts that it is a number that is written as an integer,
+// i.e. without a fraction or exponent part. Unlike ReadInt, null is not accepted. The function returns the integer or
+// raises a panic with a catch.Error if an error occurred or if the token didn't match an integer.
+func (d *decoder) ReadIntExact() int64 {
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		if s, ok := t.(json.Number); ok {
+			n = []byte(s)
+		}
+		if n != nil {
+			if d.integral {
+				var i int64
+				if i, err = parseInt(n); err == nil {
+					return i
+				}
+			}
+			t = json.Number(n)
+		}
+		err = fmt.Errorf("expected an integer, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadIntExactOrEnd reads next token from the decoder and asserts that it is either a number that is written as an
+// integer or a delimiter that matches the given end. The function returns the integer and true if an integer was
+// found or 0 and false if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are
+// true.
+func (d *decoder) ReadIntExactOrEnd(end byte) (int64, bool) {
+	d.assertEnd(end)
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		if s, ok := t.(json.Number); ok {
+			n = []byte(s)
+		}
+		if n != nil {
+			if d.integral {
+				var i int64
+				if i, err = parseInt(n); err == nil {
+					return i, true
+				}
+			}
+			t = json.Number(n)
+		} else if isDelim(t, end) {
+			return 0, false
+		}
+		err = fmt.Errorf("expected an integer or the delimiter '%c' got %T %v", end, t, t)
+	}
+	panic(d.unexpectedError(err))
 }
 
 // ReadIntOrEnd reads next token from the decoder and asserts that it is an integer, null, or a delimiter that
@@ -278,9 +1560,17 @@ func (d *decoder) ReadInt() int64 {
 // or 0 and false if the delimiter was found. A panic with a catch.Error is raised if neither of those cases are
 // true.
 func (d *decoder) ReadIntOrEnd(end byte) (int64, bool) {
-	t, err := d.Token()
+	d.assertEnd(end)
+	n, t, err := d.numberOrToken()
 	if err == nil {
-		switch t := t.(type) {
+		if n != nil {
+			var i int64
+			if i, err = parseInt(n); err == nil {
+				return i, true
+			}
+			t = json.Number(n)
+		}
+		switch t := d.coerceToken(t).(type) {
 		case nil:
 			return 0, true
 		case json.Number:
@@ -296,7 +1586,217 @@ func (d *decoder) ReadIntOrEnd(end byte) (int64, bool) {
 		}
 		err = fmt.Errorf("expected an integer or the delimiter '%c' got %T %v", end, t, t)
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
+}
+
+// ReadInteger reads next token from the decoder and asserts that it is an integer or null. An integer that fits an
+// int64 is returned as that int64 and a nil *big.Int, and any other integer as 0 and a *big.Int, so that identifiers
+// from systems that use wider integers are neither rejected nor rounded. The function returns 0 and nil in case of null
+// or raises a panic with a catch.Error if an error occurred or if the token didn't match an integer or null.
+func (d *decoder) ReadInteger() (int64, *big.Int) {
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		if n == nil {
+			if t == nil {
+				return 0, nil
+			}
+			if s, ok := d.coerceToken(t).(json.Number); ok {
+				n = []byte(s)
+			}
+		} else {
+			t = json.Number(n)
+		}
+		if n != nil {
+			if i, err := parseInt(n); err == nil {
+				return i, nil
+			}
+			if b, ok := new(big.Int).SetString(string(n), 10); ok {
+				return 0, b
+			}
+		}
+		err = fmt.Errorf("expected an integer, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadInto reads the next value from the decoder and assigns it to the given pointer using the Read method that matches
+// the type of the pointer. Supported types are pointers to bool, string, the int and uint types, float32, float64,
+// time.Duration, and time.Time, a Consumer, and an encoding.TextUnmarshaler. An integer that doesn't fit the type of
+// the pointer is an error. The method raises a panic with a catch.Error if an error occurred, if the value didn't match
+// the type of the pointer, or if the type isn't supported.
+//
+// The method is a convenience for Consumers that assign the fields of a struct directly in a switch on the key.
+// Scalar values are first passed through the hooks of the DecodeHooks option.
+func (d *decoder) ReadInto(ptr interface{}) {
+	if len(d.hooks) > 0 && d.readHooked(ptr) {
+		return
+	}
+	switch p := ptr.(type) {
+	case *bool:
+		*p = d.ReadBool()
+	case *string:
+		*p = d.ReadString()
+	case *int:
+		*p = int(d.readIntRange(-maxInt-1, maxInt, "int"))
+	case *int8:
+		*p = int8(d.readIntRange(math.MinInt8, math.MaxInt8, "int8"))
+	case *int16:
+		*p = int16(d.readIntRange(math.MinInt16, math.MaxInt16, "int16"))
+	case *int32:
+		*p = int32(d.readIntRange(math.MinInt32, math.MaxInt32, "int32"))
+	case *int64:
+		*p = d.ReadInt()
+	case *uint:
+		*p = uint(d.readUintRange(maxUint, "uint"))
+	case *uint8:
+		*p = uint8(d.readUintRange(math.MaxUint8, "uint8"))
+	case *uint16:
+		*p = uint16(d.readUintRange(math.MaxUint16, "uint16"))
+	case *uint32:
+		*p = uint32(d.readUintRange(math.MaxUint32, "uint32"))
+	case *uint64:
+		*p = d.ReadUint64()
+	case *float32:
+		*p = d.ReadFloat32()
+	case *float64:
+		*p = d.ReadFloat()
+	case *time.Duration:
+		*p = d.ReadDuration()
+	case *time.Time:
+		*p = d.ReadTimeLayouts()
+	case Consumer:
+		d.ReadConsumer(p)
+	case encoding.TextUnmarshaler:
+		d.ReadText(p)
+	default:
+		panic(catch.Error("ReadInto: unsupported type %T", ptr))
+	}
+}
+
+// maxInt and maxUint are the largest values of an int and a uint.
+const (
+	maxInt  = 1<<(strconv.IntSize-1) - 1
+	maxUint = 1<<strconv.IntSize - 1
+)
+
+// readIntRange reads an integer or null and asserts that it is within the range of the named type. The error of an
+// integer that is out of range contains the integer and the path to it.
+func (d *decoder) readIntRange(min, max int64, typeName string) int64 {
+	i := d.ReadInt()
+	if i < min || i > max {
+		panic(d.unexpectedError(fmt.Errorf("number %d at %q overflows %s", i, d.CurrentPath(), typeName)))
+	}
+	return i
+}
+
+// readUintRange reads a non-negative integer or null and asserts that it is within the range of the named type. The
+// error of an integer that is out of range contains the integer and the path to it.
+func (d *decoder) readUintRange(max uint64, typeName string) uint64 {
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		if n == nil {
+			if t == nil {
+				return 0
+			}
+			if s, ok := d.coerceToken(t).(json.Number); ok {
+				n = []byte(s)
+			}
+		}
+		if n != nil {
+			if u, perr := strconv.ParseUint(string(n), 10, 64); perr == nil && u <= max {
+				return u
+			}
+			if i, ierr := parseInt(n); ierr == nil && i == 0 {
+				// -0
+				return 0
+			}
+			if isIntegral(n) {
+				panic(d.unexpectedError(fmt.Errorf("number %s at %q overflows %s", n, d.CurrentPath(), typeName)))
+			}
+			t = json.Number(n)
+		}
+		err = fmt.Errorf("expected an integer, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadObjectFields reads the keys and values of an object up to and including its closing '}'. The opening '{' must
+// already have been read. The value of a key that is found in the given fields map is read by calling its function.
+// The value of any other key is passed to the given unknown function, which must read or skip the value, or, if
+// unknown is nil, skipped. A panic with a catch.Error is raised if an error occurs.
+//
+// A skipped value is passed to the function of the UnknownFields option when the decoder was created with that
+// option.
+func (d *decoder) ReadObjectFields(fields map[string]func(Decoder), unknown func(key string, d Decoder)) {
+	for {
+		key, ok := d.ReadStringOrEnd('}')
+		if !ok {
+			return
+		}
+		if f, ok := fields[key]; ok {
+			f(d)
+		} else if unknown != nil {
+			unknown(key, d)
+		} else if d.unknownField != nil {
+			// the current path ends with the key, which contains no '/' once escaped
+			p := d.CurrentPath()
+			p = p[:strings.LastIndexByte(p, '/')]
+			d.scratch = d.ReadRawAppend(d.scratch[:0])
+			d.unknownField(p, key, d.scratch)
+		} else {
+			d.Skip()
+		}
+	}
+}
+
+// ReadRawAppend reads the next value from the decoder, appends its raw JSON bytes to dst, and returns the extended
+// buffer. Passing a buffer that is reused between calls avoids an allocation per captured value. A panic with a
+// catch.Error is raised if an error occurred or if the next token isn't the start of a value.
+//
+// A decoder that uses a json.Decoder must first decode the value into a json.RawMessage so the call will allocate
+// regardless of the given buffer.
+func (d *decoder) ReadRawAppend(dst []byte) []byte {
+	var err error
+	if s, ok := d.TokenSource.(*scanner); ok {
+		var raw []byte
+		if raw, err = s.rawValue(); err == nil {
+			d.null = string(raw) == "null"
+			return append(dst, raw...)
+		}
+	} else if jd, ok := d.TokenSource.(*json.Decoder); ok && !d.buffering() {
+		var raw json.RawMessage
+		if err = jd.Decode(&raw); err == nil {
+			d.null = string(raw) == "null"
+			d.valueStarted()
+			d.atKey = d.container() == '{'
+			return append(dst, raw...)
+		}
+	} else {
+		start := len(dst)
+		if dst, err = d.appendTokens(dst); err == nil {
+			d.null = string(dst[start:]) == "null"
+			return dst
+		}
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadRawAppendOrEnd reads the next value from the decoder, appends its raw JSON bytes to dst, and returns the
+// extended buffer and true, or, if the next token is a delimiter that matches the given end, reads that delimiter
+// and returns dst and false. A panic with a catch.Error is raised if neither of those cases are true.
+func (d *decoder) ReadRawAppendOrEnd(dst []byte, end byte) ([]byte, bool) {
+	d.assertEnd(end)
+	if d.atEnd() {
+		t, err := d.Token()
+		if err == nil {
+			if isDelim(t, end) {
+				return dst, false
+			}
+			err = fmt.Errorf("expected a value or the delimiter '%c' got %T %v", end, t, t)
+		}
+		panic(d.unexpectedError(err))
+	}
+	return d.ReadRawAppend(dst), true
 }
 
 // ReadString reads next token from the decoder and asserts that it is a string or null. The function returns the
@@ -311,9 +1811,95 @@ func (d *decoder) ReadString() string {
 		if s, ok := t.(string); ok {
 			return s
 		}
+		if n, ok := t.(json.Number); ok && d.coerceScalars {
+			return string(n)
+		}
+		err = fmt.Errorf("expected a string, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadStringBytes reads next token from the decoder and asserts that it is a string or null. The function returns
+// the unquoted bytes of the string (or nil in case of null) or raises a panic with a catch.Error if an error
+// occurred or if the token didn't match a string. The returned bytes are only valid until the next call to the
+// decoder and must not be modified.
+//
+// The function avoids allocating a string when the decoder scans its input directly (see NewBytesDecoder).
+func (d *decoder) ReadStringBytes() []byte {
+	var t json.Token
+	var err error
+	if s, ok := d.TokenSource.(*scanner); ok {
+		var b []byte
+		b, t, err = s.stringBytesOrToken()
+		d.null = b == nil && t == nil && err == nil
+		if b != nil {
+			return b
+		}
+	} else {
+		t, err = d.Token()
+		if s, ok := t.(string); ok {
+			return []byte(s)
+		}
+	}
+	if err == nil {
+		if t == nil {
+			return nil
+		}
 		err = fmt.Errorf("expected a string, got %T %v", t, t)
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
+}
+
+// ReadStringBytesOrEnd reads next token from the decoder and asserts that it is a string, null, or a delimiter that
+// matches the given end. The function returns the unquoted bytes of the string (or nil in case of null) and true if
+// a string or null is found or nil and false if the delimiter was found. A panic with a catch.Error is raised if
+// neither of those cases are true. The returned bytes are only valid until the next call to the decoder and must not
+// be modified.
+func (d *decoder) ReadStringBytesOrEnd(end byte) ([]byte, bool) {
+	d.assertEnd(end)
+	var t json.Token
+	var err error
+	if s, ok := d.TokenSource.(*scanner); ok {
+		var b []byte
+		b, t, err = s.stringBytesOrToken()
+		d.null = b == nil && t == nil && err == nil
+		if b != nil {
+			return b, true
+		}
+	} else {
+		t, err = d.Token()
+		if s, ok := t.(string); ok {
+			return []byte(s), true
+		}
+	}
+	if err == nil {
+		if t == nil {
+			return nil, true
+		}
+		if isDelim(t, end) {
+			return nil, false
+		}
+		err = fmt.Errorf("expected a string or the delimiter '%c' got %T %v", end, t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadStringEnum reads next token from the decoder and asserts that it is a string that is equal to one of the given
+// allowed values, or null. The function returns the matching allowed value, so that no string is allocated for it, or
+// an empty string in case of null. A typed string constant, e.g. of a type Status, is validated by converting it to a
+// string and converting the result back. The function raises a panic with a catch.Error if an error occurred or if the
+// token didn't match any of the allowed values.
+func (d *decoder) ReadStringEnum(allowed ...string) string {
+	b := d.ReadStringBytes()
+	if b == nil && d.null {
+		return ""
+	}
+	for _, a := range allowed {
+		if a == string(b) {
+			return a
+		}
+	}
+	panic(d.unexpectedError(fmt.Errorf("%q is not one of the allowed values %q", b, allowed)))
 }
 
 // ReadStringOrEnd reads next token from the decoder and asserts that it is a string, null, or a delimiter that
@@ -321,6 +1907,7 @@ func (d *decoder) ReadString() string {
 // string in case of null) and true if a string or null is found or an empty string and false if the delimiter was
 // found. A panic with a catch.Error is raised if neither of those cases are true.
 func (d *decoder) ReadStringOrEnd(end byte) (string, bool) {
+	d.assertEnd(end)
 	t, err := d.Token()
 	if err == nil {
 		switch t := t.(type) {
@@ -328,6 +1915,10 @@ func (d *decoder) ReadStringOrEnd(end byte) (string, bool) {
 			return "", true
 		case string:
 			return t, true
+		case json.Number:
+			if d.coerceScalars {
+				return string(t), true
+			}
 		case json.Delim:
 			s := t.String()
 			if len(s) == 1 && s[0] == end {
@@ -336,5 +1927,251 @@ func (d *decoder) ReadStringOrEnd(end byte) (string, bool) {
 		}
 		err = fmt.Errorf("expected a string or the delimiter '%c' got %T %v", end, t, t)
 	}
-	panic(unexpectedError(err))
+	panic(d.unexpectedError(err))
+}
+
+// ReadStringReader reads next token from the decoder and asserts that it is a string or null. The function returns a
+// reader of the unquoted string (or of nothing in case of null) or raises a panic with a catch.Error if an error
+// occurred or if the token didn't match a string. The reader remains valid after subsequent calls to the decoder.
+//
+// The reader is meant for strings that contain embedded documents or logs that should not be copied into a Go string.
+// When the decoder scans its input (see NewBytesDecoder), the escapes are decoded while the reader is read, so neither
+// a string nor a copy of the unquoted bytes is allocated. The quoted string is still held in memory as a whole, either
+// as the input of NewBytesDecoder or in the buffer of a decoder that scans an io.Reader. Other decoders hold the
+// unquoted string in memory since the json.Decoder reads the whole token.
+func (d *decoder) ReadStringReader() io.Reader {
+	var t json.Token
+	var err error
+	if s, ok := d.TokenSource.(*scanner); ok {
+		var b []byte
+		b, t, err = s.rawStringOrToken()
+		d.null = b == nil && t == nil && err == nil
+		if b != nil {
+			if s.escapes == EscapesPreserve {
+				return bytes.NewReader(b)
+			}
+			return &unquoteReader{raw: b}
+		}
+	} else {
+		t, err = d.Token()
+		if s, ok := t.(string); ok {
+			return strings.NewReader(s)
+		}
+	}
+	if err == nil {
+		if t == nil {
+			return strings.NewReader("")
+		}
+		err = fmt.Errorf("expected a string, got %T %v", t, t)
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadText reads next token from the decoder and asserts that it is a string or null. The unquoted string is passed to
+// the UnmarshalText method of the given value. A null leaves the value unchanged. The function raises a panic with a
+// catch.Error if an error occurred, if the token didn't match a string, or if UnmarshalText returned an error.
+//
+// The method makes types like time.Time, net.IP, and big.Int, and other types that implement encoding.TextUnmarshaler,
+// readable by a Consumer.
+func (d *decoder) ReadText(v encoding.TextUnmarshaler) {
+	b := d.ReadStringBytes()
+	if b == nil {
+		return
+	}
+	if err := v.UnmarshalText(b); err != nil {
+		panic(d.unexpectedError(err))
+	}
+}
+
+// ReadTimeLayouts reads next token from the decoder and asserts that it is a string, a number, or null. A string is
+// parsed using the first of the given layouts that matches it, or the layouts returned by DefaultTimeLayouts when no
+// layouts are given. A number is the number of seconds, possibly with a fraction, since the Unix epoch. The function
+// returns the time (or the zero time in case of null) or raises a panic with a catch.Error if an error occurred or if
+// the token didn't match any of the layouts. A time without a zone is in UTC unless the TimeLocation or
+// RejectNaiveTimes option is given.
+func (d *decoder) ReadTimeLayouts(layouts ...string) time.Time {
+	n, t, err := d.numberOrToken()
+	if err == nil {
+		if s, ok := t.(json.Number); ok {
+			n = []byte(s)
+		}
+		var tm time.Time
+		switch {
+		case n != nil:
+			tm, err = epochTime(n, d.integral, time.Second)
+		case t == nil:
+		default:
+			if s, ok := t.(string); ok {
+				tm, err = parseTimeLayouts(s, layouts, &d.decoderOptions)
+			} else {
+				err = fmt.Errorf("expected a time, got %T %v", t, t)
+			}
+		}
+		if err == nil {
+			return tm
+		}
+	}
+	panic(d.unexpectedError(err))
+}
+
+// ReadTimeUnix reads next token from the decoder and asserts that it is a number or null. The number is the number of
+// seconds, possibly with a fraction, since the Unix epoch. The function returns the time (or the zero time in case of
+// null) or raises a panic with a catch.Error if an error occurred or if the token didn't match a number.
+func (d *decoder) ReadTimeUnix() time.Time {
+	return d.readEpoch(time.Second)
+}
+
+// ReadTimeUnixMillis reads next token from the decoder and asserts that it is a number or null. The number is the
+// number of milliseconds, possibly with a fraction, since the Unix epoch, as used by e.g. JavaScript. The function
+// returns the time (or the zero time in case of null) or raises a panic with a catch.Error if an error occurred or if
+// the token didn't match a number.
+func (d *decoder) ReadTimeUnixMillis() time.Time {
+	return d.readEpoch(time.Millisecond)
+}
+
+// ReadUint16 is like ReadInt but also asserts that the integer is within the range of a uint16. A panic with a
+// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is negative or out of
+// range.
+func (d *decoder) ReadUint16() uint16 {
+	return uint16(d.readUintRange(math.MaxUint16, "uint16"))
+}
+
+// ReadUint32 is like ReadInt but also asserts that the integer is within the range of a uint32. A panic with a
+// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is negative or out of
+// range.
+func (d *decoder) ReadUint32() uint32 {
+	return uint32(d.readUintRange(math.MaxUint32, "uint32"))
+}
+
+// ReadUint64 reads next token from the decoder and asserts that it is a non-negative integer or null. Unlike ReadInt,
+// the full range of a uint64 is accepted. The function returns the integer (or 0 in case of null) or raises a panic
+// with a catch.Error if an error occurred, if the token didn't match an integer or null, or if the integer is negative
+// or out of range, in which case the error contains the integer and the path to it (see CurrentPath).
+func (d *decoder) ReadUint64() uint64 {
+	return d.readUintRange(math.MaxUint64, "uint64")
+}
+
+// ReadUint8 is like ReadInt but also asserts that the integer is within the range of a uint8. A panic with a
+// catch.Error that contains the integer and the path to it (see CurrentPath) is raised if it is negative or out of
+// range.
+func (d *decoder) ReadUint8() uint8 {
+	return uint8(d.readUintRange(math.MaxUint8, "uint8"))
+}
+
+// Reset returns the decoder to the position that was saved by the last call to Mark and removes the mark, so that the
+// tokens read since then will be read again. Mark can be called directly after Reset to make another attempt. A panic
+// with a catch.Error is raised if there is no mark.
+func (d *decoder) Reset() {
+	m := d.mark
+	if m == nil {
+		panic(d.unexpectedError(errors.New("reset without a mark")))
+	}
+	d.mark = nil
+	d.stack, d.path, d.lastKey, d.atKey = m.stack, m.path, m.lastKey, m.atKey
+	d.integral, d.null = m.integral, m.null
+	if s, ok := d.TokenSource.(*scanner); ok {
+		s.restore(m.scanner)
+		s.pinned = false
+	} else {
+		d.next = 0
+	}
+}
+
+// Skip reads and discards the next value, including all nested values when it is an array or an object. A panic
+// with a catch.Error is raised if an error occurred or if the next token isn't the start of a value.
+func (d *decoder) Skip() {
+	if s, ok := d.TokenSource.(*scanner); ok {
+		raw, err := s.rawValue()
+		if err != nil {
+			panic(d.unexpectedError(err))
+		}
+		d.null = string(raw) == "null"
+		return
+	}
+	depth := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			panic(d.unexpectedError(err))
+		}
+		if dl, ok := t.(json.Delim); ok {
+			if dl == '[' || dl == '{' {
+				depth++
+			} else if depth--; depth < 0 {
+				panic(d.unexpectedError(fmt.Errorf("expected a value, got delimiter '%c'", dl)))
+			}
+		}
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// SkipRemaining reads and discards the remaining values of the current array or object, up to and including the
+// delimiter that closes it. It is typically used by a Consumer that has found what it needs. A panic with a catch.Error
+// is raised if an error occurred or if no array or object is open.
+func (d *decoder) SkipRemaining() {
+	depth := len(d.containers())
+	if depth == 0 {
+		panic(d.unexpectedError(errors.New("no array or object is open")))
+	}
+	d.null = false
+	if s, ok := d.TokenSource.(*scanner); ok {
+		for len(s.stack) >= depth {
+			if _, err := s.next(); err != nil {
+				panic(d.unexpectedError(err))
+			}
+		}
+		return
+	}
+	for len(d.stack) >= depth {
+		if _, err := d.Token(); err != nil {
+			panic(d.unexpectedError(err))
+		}
+	}
+}
+
+// TokenSpan returns the byte offsets in the input of the start and the end of the last token that was read, so that
+// tools such as linters and editors can map what they find back to the source. The span is only known for decoders that
+// scan their input directly (see NewBytesDecoder). Other decoders, and decoders that haven't read a token yet, return
+// -1, -1.
+func (d *decoder) TokenSpan() (start, end int64) {
+	if s, ok := d.TokenSource.(*scanner); ok && s.base+s.end > 0 {
+		return int64(s.base + s.start), int64(s.base + s.end)
+	}
+	return -1, -1
+}
+
+// Unmark removes the mark that was saved by the last call to Mark without returning to it, e.g. when a speculative read
+// succeeded, so that the decoder stops keeping the tokens that it reads. A panic with a catch.Error is raised if there
+// is no mark.
+func (d *decoder) Unmark() {
+	if d.mark == nil {
+		panic(d.unexpectedError(errors.New("unmark without a mark")))
+	}
+	d.mark = nil
+	if s, ok := d.TokenSource.(*scanner); ok {
+		s.pinned = false
+	} else {
+		// tokens that remain after a Reset are still to be read
+		d.buffered = append([]json.Token(nil), d.buffered[d.next:]...)
+		d.next = 0
+	}
+}
+
+// ValueSpan returns the byte offsets in the input of the start and the end of the last complete value that was read,
+// i.e. the last string, number, literal, or the array or object that was closed by the last end delimiter. Object keys
+// are not values. The span is only known for decoders that scan their input directly (see NewBytesDecoder). Other
+// decoders, and decoders that haven't read a complete value yet, return -1, -1.
+func (d *decoder) ValueSpan() (start, end int64) {
+	if s, ok := d.TokenSource.(*scanner); ok && s.base+s.valueEnd > 0 {
+		return int64(s.base + s.valueStart), int64(s.base + s.valueEnd)
+	}
+	return -1, -1
+}
+
+// WasNull returns true if the value that was read by the last call to a Read method was null. It is typically used
+// to tell a null apart from a zero value after a call to ReadBool, ReadFloat, ReadInt, or ReadString.
+func (d *decoder) WasNull() bool {
+	return d.null
 }
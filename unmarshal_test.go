@@ -2,11 +2,17 @@ package jsonstream
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"math/big"
+	"net"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/tada/catch"
@@ -62,7 +68,7 @@ func ExampleUnmarshal() {
 
 func TestJSONDecoder(t *testing.T) {
 	jd := json.NewDecoder(bytes.NewReader([]byte("{}")))
-	js := &decoder{jd}
+	js := &decoder{TokenSource: jd}
 	if js.JSONDecoder() != jd {
 		t.Fatal("JSONDecoder() returned different instance")
 	}
@@ -178,6 +184,25 @@ func TestReadBool(t *testing.T) {
 	}
 }
 
+func TestReadBoolLenient(t *testing.T) {
+	input := `[true, "true", "1", 1, false, "false", "0", 0, null, "yes"]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var bs []bool
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 10; i++ {
+				bs = append(bs, js.ReadBoolLenient())
+			}
+		})
+		if err == nil || !strings.Contains(err.Error(), `expected a boolean, got string yes`) {
+			t.Errorf("expected boolean error, got %v", err)
+		}
+		if a := fmt.Sprint(bs); a != `[true true true true false false false false false]` {
+			t.Errorf("unexpected booleans %s", a)
+		}
+	}
+}
+
 func TestReadBoolOrEnd(t *testing.T) {
 	js := decoderOn(`[true, false, null]`)
 	err := catch.Do(func() {
@@ -241,6 +266,110 @@ func TestReadInt(t *testing.T) {
 	}
 }
 
+func TestReadInteger(t *testing.T) {
+	input := `[9223372036854775807, -9223372036854775809, 123456789012345678901234567890, null, 1.5]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var a []string
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 4; i++ {
+				n, b := js.ReadInteger()
+				a = append(a, fmt.Sprint(n, " ", b))
+			}
+			js.ReadInteger()
+		})
+		if err == nil || err.Error() != "expected an integer, got json.Number 1.5" {
+			t.Errorf("expected integer error, got %v", err)
+		}
+		ex := `[9223372036854775807 <nil> 0 -9223372036854775809 0 123456789012345678901234567890 0 <nil>]`
+		if s := fmt.Sprint(a); s != ex {
+			t.Errorf("expected %s, got %s", ex, s)
+		}
+	}
+	err := catch.Do(func() {
+		if _, b := NewBytesDecoder([]byte(`"18446744073709551616"`), CoerceScalars()).ReadInteger(); b == nil {
+			t.Error("expected big.Int from string")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadNarrowNumbers(t *testing.T) {
+	input := `{"i8": -128, "i16": 32767, "i32": -2147483648, "u8": 255, "u16": 65535, "u32": 4294967295, "f": 1.5}`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var a []interface{}
+		err := catch.Do(func() {
+			js.ReadDelim('{')
+			js.ReadObjectFields(map[string]func(Decoder){
+				"i8":  func(js Decoder) { a = append(a, js.ReadInt8()) },
+				"i16": func(js Decoder) { a = append(a, js.ReadInt16()) },
+				"i32": func(js Decoder) { a = append(a, js.ReadInt32()) },
+				"u8":  func(js Decoder) { a = append(a, js.ReadUint8()) },
+				"u16": func(js Decoder) { a = append(a, js.ReadUint16()) },
+				"u32": func(js Decoder) { a = append(a, js.ReadUint32()) },
+				"f":   func(js Decoder) { a = append(a, js.ReadFloat32()) },
+			}, nil)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := fmt.Sprint(a); s != `[-128 32767 -2147483648 255 65535 4294967295 1.5]` {
+			t.Errorf("unexpected values %s", s)
+		}
+	}
+	for input, ex := range map[string]string{
+		`[128]`:    `number 128 at "/0" overflows int8`,
+		`[-32769]`: `number -32769 at "/0" overflows int16`,
+		`[-1]`:     `number -1 at "/0" overflows uint8`,
+		`[65536]`:  `number 65536 at "/0" overflows uint16`,
+		`[1e39]`:   `number 1e+39 at "/0" overflows float32`,
+	} {
+		err := catch.Do(func() {
+			js := NewBytesDecoder([]byte(input))
+			js.ReadDelim('[')
+			switch input {
+			case `[128]`:
+				js.ReadInt8()
+			case `[-32769]`:
+				js.ReadInt16()
+			case `[-1]`:
+				js.ReadUint8()
+			case `[65536]`:
+				js.ReadUint16()
+			default:
+				js.ReadFloat32()
+			}
+		})
+		if err == nil || err.Error() != ex {
+			t.Errorf("%s: expected error %q, got %v", input, ex, err)
+		}
+	}
+}
+
+func TestReadUint64(t *testing.T) {
+	input := `[18446744073709551615, 9223372036854775808, -0, null, "42"]`
+	for _, js := range []Decoder{NewDecoder(strings.NewReader(input), CoerceScalars()), NewBytesDecoder([]byte(input), CoerceScalars())} {
+		var a []uint64
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 4; i++ {
+				a = append(a, js.ReadUint64())
+			}
+			var u uint64
+			js.ReadInto(&u)
+			a = append(a, u)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := fmt.Sprint(a); s != `[18446744073709551615 9223372036854775808 0 0 42]` {
+			t.Errorf("unexpected values %s", s)
+		}
+	}
+}
+
 func TestReadIntOrEnd(t *testing.T) {
 	js := decoderOn(`[42, null]`)
 	err := catch.Do(func() {
@@ -420,3 +549,860 @@ func TestReadConsumerOrEnd(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestReadConsumers(t *testing.T) {
+	input := `[{"m":"message","i":42}, null, 7]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		tc := &testConsumer{t: t}
+		var audit Value
+		var paths []string
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			if !js.ReadConsumers(tc, &audit, consumerFunc(func(js Decoder, firstToken json.Token) {
+				js.ReadStringOrEnd('}')
+				paths = append(paths, js.CurrentPath())
+			})) {
+				t.Fatal("expected value, got null")
+			}
+			if js.ReadConsumers(tc) {
+				t.Fatal("expected null")
+			}
+			if i := js.ReadInt(); i != 7 || js.CurrentPath() != "/2" {
+				t.Fatalf("unexpected value %d at %q after the consumers", i, js.CurrentPath())
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.m != "message" || tc.i != 42 || audit.String() != `{"m":"message","i":42}` {
+			t.Errorf("unexpected consumer values %q, %d, %s", tc.m, tc.i, audit)
+		}
+		if fmt.Sprint(paths) != `[/m]` {
+			t.Errorf("unexpected paths %s", paths)
+		}
+		err = catch.Do(func() {
+			js.ReadConsumers(tc)
+		})
+		if err == nil || err.Error() != `expected a value, got delimiter ']'` {
+			t.Errorf("expected delimiter error, got %v", err)
+		}
+	}
+}
+
+func TestReadRawAppend(t *testing.T) {
+	input := `{"a":[1, {"b":"]}"}], "c":null, "d":"x"}`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var raws []string
+		err := catch.Do(func() {
+			js.ReadDelim('{')
+			buf := make([]byte, 0, 32)
+			for {
+				_, ok := js.ReadStringOrEnd('}')
+				if !ok {
+					break
+				}
+				buf = js.ReadRawAppend(buf[:0])
+				raws = append(raws, string(buf))
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(raws) != `[[1, {"b":"]}"}] null "x"]` {
+			t.Fatalf("unexpected raw values %q", raws)
+		}
+		err = catch.Do(func() {
+			js.ReadRawAppend(nil)
+		})
+		if err != io.ErrUnexpectedEOF {
+			t.Fatalf("expected ErrUnexpectedEOF, got %v", err)
+		}
+	}
+	err := catch.Do(func() {
+		js := NewBytesDecoder([]byte(`[]`))
+		js.ReadDelim('[')
+		js.ReadRawAppend(nil)
+	})
+	if err == nil || err.Error() != "expected a value, got delimiter ']' at offset 1" {
+		t.Fatalf("expected delimiter error, got %v", err)
+	}
+}
+
+func TestReadStringBytes(t *testing.T) {
+	input := `["plain", "esc\"aped", "", null, 1]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			if b := js.ReadStringBytes(); string(b) != "plain" {
+				t.Fatalf(`expected "plain", got %q`, b)
+			}
+			if b := js.ReadStringBytes(); string(b) != `esc"aped` {
+				t.Fatalf(`expected "esc\"aped", got %q`, b)
+			}
+			if b := js.ReadStringBytes(); !(b != nil && len(b) == 0) {
+				t.Fatalf(`expected empty string, got %q`, b)
+			}
+			if b := js.ReadStringBytes(); b != nil {
+				t.Fatalf(`expected nil, got %q`, b)
+			}
+			js.ReadStringBytes()
+		})
+		if err == nil || err.Error() != "expected a string, got json.Number 1" {
+			t.Fatalf("expected string error, got %v", err)
+		}
+	}
+}
+
+func TestReadIntExact(t *testing.T) {
+	input := `[1, 1.0, 1e0, null]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			if i := js.ReadIntExact(); !(i == 1 && js.LastNumberIntegral()) {
+				t.Fatalf("expected integral 1, got %d", i)
+			}
+			if f := js.ReadFloat(); !(f == 1 && !js.LastNumberIntegral()) {
+				t.Fatalf("expected non integral 1.0, got %g", f)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ex := range []string{
+			"expected an integer, got json.Number 1e0",
+			"expected an integer, got <nil> <nil>",
+		} {
+			err = catch.Do(func() {
+				js.ReadIntExact()
+			})
+			if err == nil || err.Error() != ex {
+				t.Fatalf("expected error %q, got %v", ex, err)
+			}
+		}
+	}
+}
+
+func TestOrEnd_mismatchedContainer(t *testing.T) {
+	input := `{"a":[1]}`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		err := catch.Do(func() {
+			js.ReadDelim('{')
+			if s, ok := js.ReadStringOrEnd('}'); !(ok && s == "a") {
+				t.Fatalf(`expected "a", got %q`, s)
+			}
+			js.ReadDelim('[')
+			js.ReadIntOrEnd('}')
+		})
+		if err == nil || err.Error() != "end delimiter '}' does not match the current container '['" {
+			t.Fatalf("expected mismatch error, got %v", err)
+		}
+	}
+}
+
+func TestReadObjectFields(t *testing.T) {
+	input := `{"m":"message","x":[1,{"y":[]}],"i":42,"z":{}}`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var m string
+		var i int64
+		var unknown []string
+		err := catch.Do(func() {
+			js.ReadDelim('{')
+			js.ReadObjectFields(map[string]func(Decoder){
+				"m": func(js Decoder) { m = js.ReadString() },
+				"i": func(js Decoder) { i = js.ReadInt() },
+			}, func(key string, js Decoder) {
+				unknown = append(unknown, key)
+				js.Skip()
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(m == "message" && i == 42 && fmt.Sprint(unknown) == "[x z]") {
+			t.Fatalf("unexpected values %q, %d, %v", m, i, unknown)
+		}
+	}
+}
+
+func TestSkip(t *testing.T) {
+	input := `[[1,{"a":[2]}], 3]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			js.Skip()
+			if i := js.ReadInt(); i != 3 {
+				t.Fatalf("expected 3, got %d", i)
+			}
+			js.Skip()
+		})
+		if err == nil || !strings.HasPrefix(err.Error(), "expected a value, got delimiter ']'") {
+			t.Fatalf("expected delimiter error, got %v", err)
+		}
+	}
+}
+
+func TestWasNull(t *testing.T) {
+	input := `[null, 0, null, "", null, 1.0, null]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 2; i++ {
+				js.ReadInt()
+				if js.WasNull() != (i == 0) {
+					t.Fatalf("unexpected WasNull() %t after ReadInt", js.WasNull())
+				}
+			}
+			for i := 0; i < 2; i++ {
+				js.ReadStringBytes()
+				if js.WasNull() != (i == 0) {
+					t.Fatalf("unexpected WasNull() %t after ReadStringBytes", js.WasNull())
+				}
+			}
+			for i := 0; i < 2; i++ {
+				js.ReadFloatOrEnd(']')
+				if js.WasNull() != (i == 0) {
+					t.Fatalf("unexpected WasNull() %t after ReadFloatOrEnd", js.WasNull())
+				}
+			}
+			js.Skip()
+			if !js.WasNull() {
+				t.Fatal("expected WasNull() to be true after Skip")
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUnmarshalReader(t *testing.T) {
+	tv := ts{}
+	if err := UnmarshalReader(&tv, strings.NewReader(`{"v":38}`)); err != nil {
+		t.Fatal(err)
+	}
+	if tv.v != 38*time.Millisecond {
+		t.Fatalf("expected 38ms, got %s", tv.v)
+	}
+	err := UnmarshalReader(&tv, strings.NewReader(`{"v":38,"v":39}`), Strict())
+	if err == nil || err.Error() != `duplicate key "v" in object at offset 8` {
+		t.Fatalf("expected duplicate key error, got %v", err)
+	}
+}
+
+func TestMustUnmarshal(t *testing.T) {
+	err := catch.Do(func() {
+		MustUnmarshal(&ts{}, []byte(`{"v":"x"}`))
+	})
+	if err == nil || err.Error() != "expected an integer, got string x" {
+		t.Fatalf("expected integer error, got %v", err)
+	}
+}
+
+func TestDebugState(t *testing.T) {
+	input := `{"a":1,"b!":[{"c":2}, "x"]}`
+	for _, js := range []Decoder{
+		NewDecoder(strings.NewReader(input), Debug()),
+		NewBytesDecoder([]byte(input), Debug()),
+	} {
+		var state string
+		err := catch.Do(func() {
+			js.ReadDelim('{')
+			js.ReadString()
+			js.ReadInt()
+			js.ReadString()
+			js.ReadDelim('[')
+			js.Skip()
+			state = js.DebugState()
+			js.ReadInt()
+		})
+		if ex := `depth 2, containers "{[", last key "c", offset 20`; state != ex {
+			t.Errorf("expected state %q, got %q", ex, state)
+		}
+		ex := `expected an integer, got string x (depth 2, containers "{[", last key "c", offset 25)`
+		if err == nil || err.Error() != ex {
+			t.Errorf("expected error %q, got %v", ex, err)
+		}
+	}
+}
+
+func TestOrEnd_newerReaders(t *testing.T) {
+	input := `[[1, 2], ["a", null], [{"x":[]}, 3]]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var ints []int64
+		var strs []string
+		var raws []string
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			js.ReadDelim('[')
+			for {
+				i, ok := js.ReadIntExactOrEnd(']')
+				if !ok {
+					break
+				}
+				ints = append(ints, i)
+			}
+			js.ReadDelim('[')
+			for {
+				b, ok := js.ReadStringBytesOrEnd(']')
+				if !ok {
+					break
+				}
+				strs = append(strs, string(b))
+			}
+			js.ReadDelim('[')
+			var buf []byte
+			for {
+				var ok bool
+				if buf, ok = js.ReadRawAppendOrEnd(buf[:0], ']'); !ok {
+					break
+				}
+				raws = append(raws, string(buf))
+			}
+			js.ReadDelim(']')
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(ints, strs, raws); a != `[1 2] [a ] [{"x":[]} 3]` {
+			t.Fatalf("unexpected values %s", a)
+		}
+	}
+}
+
+func TestReadInt64String(t *testing.T) {
+	input := `["9007199254740993", "-42", null, "4.2", 42]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var ints []int64
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 3; i++ {
+				ints = append(ints, js.ReadInt64String())
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(ints); a != `[9007199254740993 -42 0]` {
+			t.Fatalf("unexpected values %s", a)
+		}
+		for _, ex := range []string{
+			"expected a string that contains an integer, got string 4.2",
+			"expected a string that contains an integer, got json.Number 42",
+		} {
+			err = catch.Do(func() {
+				js.ReadInt64String()
+			})
+			if err == nil || err.Error() != ex {
+				t.Fatalf("expected error %q, got %v", ex, err)
+			}
+		}
+	}
+}
+
+func TestDepth(t *testing.T) {
+	input := `[{"a":[1]}, 2]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var depths []int
+		err := catch.Do(func() {
+			depths = append(depths, js.Depth())
+			js.ReadDelim('[')
+			depths = append(depths, js.Depth())
+			js.ReadDelim('{')
+			js.ReadString()
+			depths = append(depths, js.Depth())
+			js.ReadDelim('[')
+			js.ReadInt()
+			depths = append(depths, js.Depth())
+			js.ReadDelim(']')
+			js.ReadDelim('}')
+			depths = append(depths, js.Depth())
+			js.ReadInt()
+			js.ReadDelim(']')
+			depths = append(depths, js.Depth())
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(depths); a != `[0 1 2 3 1 0]` {
+			t.Fatalf("unexpected depths %s", a)
+		}
+	}
+}
+
+func TestCurrentPath(t *testing.T) {
+	input := `{"a":[1, {"b/c":true, "d~":[]}], "e":{}, "fé":[[null], [1, 2]]}`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var paths []string
+		err := catch.Do(func() {
+			paths = append(paths, js.CurrentPath())
+			js.ReadDelim('{')
+			paths = append(paths, js.CurrentPath())
+			js.ReadString()
+			js.ReadDelim('[')
+			paths = append(paths, js.CurrentPath())
+			js.ReadInt()
+			paths = append(paths, js.CurrentPath())
+			js.ReadDelim('{')
+			paths = append(paths, js.CurrentPath())
+			js.ReadString()
+			paths = append(paths, js.CurrentPath())
+			js.ReadBool()
+			js.ReadString()
+			js.Skip()
+			paths = append(paths, js.CurrentPath())
+			js.ReadDelim('}')
+			paths = append(paths, js.CurrentPath())
+			js.ReadDelim(']')
+			js.ReadString()
+			js.ReadRawAppend(nil)
+			js.ReadString()
+			js.ReadDelim('[')
+			js.ReadRawAppend(nil)
+			js.ReadDelim('[')
+			js.ReadInt()
+			js.ReadInt()
+			paths = append(paths, js.CurrentPath())
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex := `["" "" "/a" "/a/0" "/a/1" "/a/1/b~1c" "/a/1/d~0" "/a/1" "/fé/1/1"]`
+		if a := fmt.Sprintf("%q", paths); a != ex {
+			t.Fatalf("expected %s, got %s", ex, a)
+		}
+	}
+}
+
+func TestSkipRemaining(t *testing.T) {
+	input := `[{"a":1, "b":{"c":[2, 3]}, "d":"}"}, 4]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var i int64
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			js.ReadDelim('{')
+			js.ReadString()
+			js.ReadInt()
+			js.SkipRemaining()
+			i = js.ReadInt()
+			js.SkipRemaining()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != 4 || js.Depth() != 0 {
+			t.Fatalf("expected 4 at depth 0, got %d at depth %d", i, js.Depth())
+		}
+		err = catch.Do(func() {
+			js.SkipRemaining()
+		})
+		if err == nil || err.Error() != "no array or object is open" {
+			t.Fatalf("expected no open container error, got %v", err)
+		}
+	}
+}
+
+func TestMarkReset(t *testing.T) {
+	input := `{"a":[1, "x", {"b":[true, null]}], "c":2}`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var raws []string
+		var c int64
+		err := catch.Do(func() {
+			js.ReadDelim('{')
+			js.ReadString()
+			js.Mark()
+			err := catch.Do(func() {
+				js.ReadDelim('[')
+				for {
+					if _, ok := js.ReadIntOrEnd(']'); !ok {
+						break
+					}
+				}
+			})
+			if err == nil {
+				t.Fatal("expected an error when reading a string as an integer")
+			}
+			js.Reset()
+			if p := js.CurrentPath(); p != "/a" {
+				t.Fatalf("expected path /a after reset, got %s", p)
+			}
+			js.Mark()
+			raws = append(raws, string(js.ReadRawAppend(nil)))
+			js.Reset()
+			js.ReadDelim('[')
+			for {
+				raw, ok := js.ReadRawAppendOrEnd(nil, ']')
+				if !ok {
+					break
+				}
+				raws = append(raws, string(raw))
+			}
+			js.ReadString()
+			c = js.ReadInt()
+			js.ReadDelim('}')
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(raws); !(a == `[[1,"x",{"b":[true,null]}] 1 "x" {"b":[true,null]}]` ||
+			a == `[[1, "x", {"b":[true, null]}] 1 "x" {"b":[true, null]}]`) {
+			t.Fatalf("unexpected raw values %s", a)
+		}
+		if c != 2 {
+			t.Fatalf("expected 2, got %d", c)
+		}
+		err = catch.Do(func() {
+			js.Reset()
+		})
+		if err == nil || err.Error() != "reset without a mark" {
+			t.Fatalf("expected reset without a mark error, got %v", err)
+		}
+	}
+}
+
+func TestUnmark(t *testing.T) {
+	js := decoderOn(`[1, 2, 3] [4]`)
+	js.Mark()
+	js.ReadDelim('[')
+	js.ReadInt()
+	js.Unmark()
+	if n := len(js.(*decoder).buffered); n != 0 {
+		t.Fatalf("expected no buffered tokens after unmark, got %d", n)
+	}
+	js.Mark()
+	js.ReadInt()
+	js.Reset()
+	js.Mark()
+	js.Unmark()
+	if a := fmt.Sprint(js.ReadInt(), js.ReadInt()); a != "2 3" {
+		t.Fatalf("expected the tokens read after the mark to be kept, got %s", a)
+	}
+	js.ReadDelim(']')
+	js.ReadDelim('[')
+	if n := len(js.(*decoder).buffered); n != 0 {
+		t.Fatalf("expected the buffer to be released, got %d tokens", n)
+	}
+	err := catch.Do(func() {
+		js.Unmark()
+	})
+	if err == nil || err.Error() != "unmark without a mark" {
+		t.Fatalf("expected unmark without a mark error, got %v", err)
+	}
+}
+
+func TestUnmark_scanner(t *testing.T) {
+	input := strings.Repeat(`{"a":[1,2,3]}`+"\n", 1000)
+	js := NewDecoder(strings.NewReader(input), Strict())
+	js.Mark()
+	js.ReadAny()
+	js.Unmark()
+	for i := 1; i < 1000; i++ {
+		js.ReadAny()
+	}
+	s := js.(*decoder).TokenSource.(*scanner)
+	if s.base == 0 || cap(s.buf) >= len(input) {
+		t.Fatalf("expected the buffer to be compacted after unmark, got base %d and capacity %d", s.base, cap(s.buf))
+	}
+}
+
+func TestPeekN(t *testing.T) {
+	input := `{"type":"point", "x":1} [2]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var peeked []string
+		err := catch.Do(func() {
+			peeked = append(peeked, fmt.Sprint(js.PeekN(3)))
+			js.ReadDelim('{')
+			peeked = append(peeked, fmt.Sprint(js.PeekN(1)), fmt.Sprint(js.PeekN(4)))
+			if k := js.ReadString(); k != "type" {
+				t.Fatalf("expected key type, got %s", k)
+			}
+			js.ReadString()
+			js.ReadString()
+			if i := js.ReadInt(); i != 1 {
+				t.Fatalf("expected 1, got %d", i)
+			}
+			js.ReadDelim('}')
+			peeked = append(peeked, fmt.Sprint(js.PeekN(5)))
+			js.ReadDelim('[')
+			if js.Depth() != 1 {
+				t.Fatalf("expected depth 1, got %d", js.Depth())
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(peeked); a != `[[{ type point] [type] [type point x 1] [[ 2 ]]]` {
+			t.Fatalf("unexpected peeked tokens %s", a)
+		}
+	}
+}
+
+func TestSpans(t *testing.T) {
+	input := ` {"a": [1, "xy"], "b":{}}`
+	js := NewBytesDecoder([]byte(input))
+	var spans []string
+	span := func(start, end int64) {
+		spans = append(spans, input[start:end])
+	}
+	err := catch.Do(func() {
+		if s, e := js.ValueSpan(); s != -1 || e != -1 {
+			t.Fatalf("expected no value span, got %d, %d", s, e)
+		}
+		js.ReadDelim('{')
+		span(js.TokenSpan())
+		js.ReadString()
+		span(js.TokenSpan())
+		js.ReadDelim('[')
+		js.ReadInt()
+		span(js.ValueSpan())
+		js.ReadString()
+		span(js.TokenSpan())
+		js.ReadDelim(']')
+		span(js.TokenSpan())
+		span(js.ValueSpan())
+		js.ReadString()
+		js.Skip()
+		span(js.ValueSpan())
+		js.ReadDelim('}')
+		span(js.ValueSpan())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := `["{" "\"a\"" "1" "\"xy\"" "]" "[1, \"xy\"]" "{}" "{\"a\": [1, \"xy\"], \"b\":{}}"]`
+	if a := fmt.Sprintf("%q", spans); a != ex {
+		t.Fatalf("expected %s, got %s", ex, a)
+	}
+	if s, e := decoderOn(input).TokenSpan(); s != -1 || e != -1 {
+		t.Fatalf("expected no token span, got %d, %d", s, e)
+	}
+}
+
+func TestNewTokenSourceDecoder(t *testing.T) {
+	ts := &sliceSource{json.Delim('{'), "a", json.Number("1"), "b", json.Delim('['), true, nil, json.Delim(']'),
+		json.Delim('}')}
+	js := NewTokenSourceDecoder(ts)
+	var raw []byte
+	err := catch.Do(func() {
+		js.ReadDelim('{')
+		if k := js.ReadString(); k != "a" {
+			t.Fatalf("expected key a, got %s", k)
+		}
+		if i := js.ReadInt(); i != 1 {
+			t.Fatalf("expected 1, got %d", i)
+		}
+		if p := js.CurrentPath(); p != "/a" {
+			t.Fatalf("expected path /a, got %s", p)
+		}
+		js.ReadString()
+		raw = js.ReadRawAppend(raw)
+		js.ReadDelim('}')
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `[true,null]` {
+		t.Fatalf("expected [true,null], got %s", raw)
+	}
+	if js.JSONDecoder() != nil {
+		t.Fatal("expected no json.Decoder")
+	}
+}
+
+func TestReadBytesTo(t *testing.T) {
+	blob := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	input := `["` + base64.StdEncoding.EncodeToString(blob) + `", null, "", "!!!!", "AAAA"]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		b := bytes.Buffer{}
+		var ns []int64
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 3; i++ {
+				ns = append(ns, js.ReadBytesTo(&b))
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b.Bytes(), blob) {
+			t.Fatal("unexpected decoded bytes")
+		}
+		if a := fmt.Sprint(ns); a != `[65536 0 0]` {
+			t.Fatalf("unexpected counts %s", a)
+		}
+		for _, ex := range []string{`illegal base64 data at input byte 0`, `write failed`} {
+			err = catch.Do(func() {
+				js.ReadBytesTo(failingWriter{})
+			})
+			if err == nil || err.Error() != ex {
+				t.Fatalf("expected error %q, got %v", ex, err)
+			}
+		}
+	}
+}
+
+func TestReadStringReader(t *testing.T) {
+	long := strings.Repeat(`line\n\u00e5\ud83d\ude00 \"quoted\" ä\t`, 500)
+	input := `["` + long + `", null, "", 1]`
+	expected := strings.Repeat("line\nå😀 \"quoted\" ä\t", 500)
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var rs []io.Reader
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for i := 0; i < 3; i++ {
+				rs = append(rs, js.ReadStringReader())
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, ex := range []string{expected, ``, ``} {
+			b, err := ioutil.ReadAll(iotest.OneByteReader(rs[i]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != ex {
+				t.Fatalf("unexpected string %d: %.40q", i, b)
+			}
+		}
+		err = catch.Do(func() {
+			js.ReadStringReader()
+		})
+		if err == nil || err.Error() != `expected a string, got json.Number 1` {
+			t.Fatalf("expected type error, got %v", err)
+		}
+	}
+}
+
+func TestReadText(t *testing.T) {
+	input := `["10.0.0.1", "123456789012345678901234567890", null, "x", 1]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var ip net.IP
+		n := big.NewInt(7)
+		var m big.Int
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			js.ReadText(&ip)
+			js.ReadText(n)
+			js.ReadText(&m)
+			if !js.WasNull() {
+				t.Fatal("expected WasNull to be true")
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ip.Equal(net.IPv4(10, 0, 0, 1)) {
+			t.Errorf("unexpected ip %v", ip)
+		}
+		if n.String() != "123456789012345678901234567890" {
+			t.Errorf("unexpected number %v", n)
+		}
+		if m.Sign() != 0 {
+			t.Errorf("expected null to leave the value unchanged, got %v", &m)
+		}
+		for _, ex := range []string{`invalid IP address: x`, `expected a string, got json.Number 1`} {
+			err = catch.Do(func() {
+				js.ReadText(&ip)
+			})
+			if err == nil || err.Error() != ex {
+				t.Errorf("expected error %q, got %v", ex, err)
+			}
+		}
+	}
+}
+
+func TestReadInto(t *testing.T) {
+	input := `[true, "s", 1, -8, 16, 32, 64, 2, 8, 16, 32, 64, 1.5, 2.5, "1s", "2021-03-04", {"v":1000}, "10.0.0.1"]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var (
+			b   bool
+			s   string
+			i   int
+			i8  int8
+			i16 int16
+			i32 int32
+			i64 int64
+			u   uint
+			u8  uint8
+			u16 uint16
+			u32 uint32
+			u64 uint64
+			f32 float32
+			f64 float64
+			dr  time.Duration
+			tm  time.Time
+			c   ts
+			ip  net.IP
+		)
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			for _, p := range []interface{}{
+				&b, &s, &i, &i8, &i16, &i32, &i64, &u, &u8, &u16, &u32, &u64, &f32, &f64, &dr, &tm, &c, &ip,
+			} {
+				js.ReadInto(p)
+			}
+			js.ReadDelim(']')
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := fmt.Sprintln(b, s, i, i8, i16, i32, i64, u, u8, u16, u32, u64, f32, f64, dr, tm.Format("2006-01-02"), c.v,
+			ip)
+		if ex := "true s 1 -8 16 32 64 2 8 16 32 64 1.5 2.5 1s 2021-03-04 1s 10.0.0.1\n"; a != ex {
+			t.Fatalf("expected %s, got %s", ex, a)
+		}
+	}
+
+	var i8 int8
+	var i16 int16
+	var u uint
+	var u64 uint64
+	var f32 float32
+	for _, tc := range []struct {
+		input string
+		ptr   interface{}
+		ex    string
+	}{
+		{`128`, &i8, `number 128 at "" overflows int8`},
+		{`-1`, &u, `number -1 at "" overflows uint`},
+		{`-32769`, &i16, `number -32769 at "" overflows int16`},
+		{`1e39`, &f32, `number 1e+39 at "" overflows float32`},
+		{`18446744073709551616`, &u64, `number 18446744073709551616 at "" overflows uint64`},
+		{`-2`, &u64, `number -2 at "" overflows uint64`},
+		{`1.5`, &u64, `expected an integer, got json.Number 1.5`},
+		{`1`, &[]int{}, `ReadInto: unsupported type *[]int`},
+	} {
+		err := catch.Do(func() {
+			NewBytesDecoder([]byte(tc.input)).ReadInto(tc.ptr)
+		})
+		if err == nil || err.Error() != tc.ex {
+			t.Errorf("expected error %q, got %v", tc.ex, err)
+		}
+	}
+}
+
+func TestPeekKind(t *testing.T) {
+	input := `[{"a": 1.5}, [], "s", true, null]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var kinds []Kind
+		err := catch.Do(func() {
+			kinds = append(kinds, js.PeekKind())
+			js.ReadDelim('[')
+			for k := js.PeekKind(); k != KindInvalid; k = js.PeekKind() {
+				kinds = append(kinds, k)
+				js.Skip()
+			}
+			js.ReadDelim(']')
+			kinds = append(kinds, js.PeekKind())
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := fmt.Sprint(kinds); a != `[array object array string bool null invalid]` {
+			t.Errorf("unexpected kinds %s", a)
+		}
+	}
+	if kindOfToken(json.Number("1")) != KindNumber || kindOfToken(json.Delim('}')) != KindInvalid {
+		t.Error("unexpected kind of token")
+	}
+}
@@ -0,0 +1,212 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A ValidOption sets a limit that is checked by Valid.
+type ValidOption func(*validOptions)
+
+type validOptions struct {
+	maxDepth        int
+	maxString       int
+	maxElements     int
+	noDuplicateKeys bool
+	syntax          []DecoderOption
+}
+
+// ValidMaxDepth makes Valid reject input where more than n arrays and objects are nested.
+func ValidMaxDepth(n int) ValidOption {
+	return func(o *validOptions) {
+		o.maxDepth = n
+	}
+}
+
+// ValidMaxString makes Valid reject input with a string or an object key that is longer than n bytes when unquoted.
+func ValidMaxString(n int) ValidOption {
+	return func(o *validOptions) {
+		o.maxString = n
+	}
+}
+
+// ValidMaxElements makes Valid reject input with an array that has more than n elements or an object that has more
+// than n members.
+func ValidMaxElements(n int) ValidOption {
+	return func(o *validOptions) {
+		o.maxElements = n
+	}
+}
+
+// ValidNoDuplicateKeys makes Valid reject input with an object that has the same key more than once.
+func ValidNoDuplicateKeys() ValidOption {
+	return func(o *validOptions) {
+		o.noDuplicateKeys = true
+	}
+}
+
+// ValidSyntax makes Valid check the syntax in the same way as a decoder that is given the given options, e.g. so that
+// Strict, ObjectsOnly, or AllowComments applies (see NewDecoder). Options that don't check or relax the syntax are
+// ignored.
+func ValidSyntax(options ...DecoderOption) ValidOption {
+	return func(o *validOptions) {
+		o.syntax = options
+	}
+}
+
+// A ValidationError is an error returned by Valid.
+type ValidationError struct {
+	// Offset is the byte offset in the input where the error was found, i.e. the offset of the end of the token that
+	// broke a limit, or the number of bytes read when a syntax error was found.
+	Offset int64
+
+	// Err is the error
+	Err error
+
+	// located is true when the message of Err already contains the offset
+	located bool
+}
+
+func (e *ValidationError) Error() string {
+	if e.located {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + " at offset " + strconv.FormatInt(e.Offset, 10)
+}
+
+// Unwrap returns the Err of the error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validFrame is an open array or object.
+type validFrame struct {
+	object bool
+
+	// n is the number of elements or keys
+	n int
+
+	// key is true when the last token read in an object was a key
+	key bool
+
+	// keys holds the keys read in an object when duplicate keys are rejected
+	keys map[string]bool
+}
+
+// Valid reads all JSON values from the given reader and returns nil if the values are syntactically valid and within
+// the limits that are set by the given options. No value is kept in memory and no Consumer is called, so Valid is a
+// cheap pre-flight check of untrusted input. The input must contain at least one value and may contain any number of
+// values that are separated by whitespace, e.g. NDJSON.
+//
+// The error returned for invalid input is a *ValidationError. Errors from the reader are returned as is.
+func Valid(r io.Reader, options ...ValidOption) error {
+	o := validOptions{}
+	for _, option := range options {
+		option(&o)
+	}
+	var ts TokenSource
+	var s *scanner
+	if do := newDecoderOptions(o.syntax); do.checksSyntax() {
+		s = newReaderScanner(r, do)
+		ts = s
+	} else {
+		jd := json.NewDecoder(r)
+		jd.UseNumber()
+		ts = jd
+	}
+	var stack []validFrame
+	for values := 0; ; values++ {
+		for {
+			t, err := ts.Token()
+			if err != nil {
+				if err == io.EOF {
+					if values > 0 && len(stack) == 0 {
+						return nil
+					}
+					err = io.ErrUnexpectedEOF
+				}
+				if se, ok := err.(*json.SyntaxError); ok {
+					return &ValidationError{Offset: se.Offset, Err: err}
+				}
+				if err == io.ErrUnexpectedEOF {
+					return &ValidationError{Offset: validOffset(ts), Err: err}
+				}
+				if s != nil && err != s.rerr {
+					// the scanner includes the offset in its errors
+					return &ValidationError{Offset: validOffset(ts), Err: err, located: true}
+				}
+				return err
+			}
+			if stack, err = o.push(stack, t); err != nil {
+				return &ValidationError{Offset: validOffset(ts), Err: err}
+			}
+			if len(stack) == 0 {
+				break
+			}
+		}
+	}
+}
+
+// push checks the given token, which has been read in the given open containers, against the limits and returns the
+// containers that are open after the token.
+func (o *validOptions) push(stack []validFrame, t json.Token) ([]validFrame, error) {
+	n := len(stack)
+	if d, ok := t.(json.Delim); ok && (d == ']' || d == '}') {
+		return stack[:n-1], nil
+	}
+	s, isString := t.(string)
+	if o.maxString > 0 && isString && len(s) > o.maxString {
+		return nil, fmt.Errorf("string of %d bytes exceeds the maximum of %d", len(s), o.maxString)
+	}
+	if n > 0 {
+		f := &stack[n-1]
+		if f.object && f.key {
+			f.key = false
+		} else {
+			f.n++
+			if o.maxElements > 0 && f.n > o.maxElements {
+				return nil, fmt.Errorf("more than %d elements", o.maxElements)
+			}
+			if f.object {
+				f.key = true
+				if f.keys != nil {
+					if f.keys[s] {
+						return nil, fmt.Errorf("duplicate key %q", s)
+					}
+					f.keys[s] = true
+				}
+				return stack, nil
+			}
+		}
+	}
+	if d, ok := t.(json.Delim); ok {
+		if o.maxDepth > 0 && n >= o.maxDepth {
+			return nil, fmt.Errorf("maximum depth %d exceeded", o.maxDepth)
+		}
+		f := validFrame{object: d == '{'}
+		if f.object && o.noDuplicateKeys {
+			f.keys = make(map[string]bool)
+		}
+		stack = append(stack, f)
+	}
+	return stack, nil
+}
+
+// validOffset returns the offset in the input of the given token source of Valid, or -1 when it isn't known.
+func validOffset(ts TokenSource) int64 {
+	if s, ok := ts.(*scanner); ok {
+		return int64(s.base + s.pos)
+	}
+	return inputOffset(ts)
+}
+
+// inputOffset returns the input offset of the given json.Decoder, or -1 when it isn't known.
+func inputOffset(jd interface{}) int64 {
+	// json.Decoder has InputOffset since Go 1.14
+	if od, ok := jd.(interface{ InputOffset() int64 }); ok {
+		return od.InputOffset()
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package jsonstream
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestValid(t *testing.T) {
+	for _, input := range []string{`{"a": [1, "x", null, {"b": true}]}`, "1\n\"a\"\n[]\n{}", ` [[[]]] `} {
+		if err := Valid(strings.NewReader(input)); err != nil {
+			t.Errorf("%q: unexpected error %v", input, err)
+		}
+	}
+	limits := []ValidOption{ValidMaxDepth(3), ValidMaxString(3), ValidMaxElements(2), ValidNoDuplicateKeys()}
+	if err := Valid(strings.NewReader(`{"a": ["abc", {}], "b": {"a": 1, "b": 2}}`), limits...); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	tests := map[string]string{
+		``:                         "unexpected EOF at offset 0",
+		`[1, 2`:                    "unexpected EOF at offset 5",
+		`{"a": 1,}`:                "invalid character ',' looking for beginning of value at offset 8",
+		`[1] x`:                    "invalid character 'x' looking for beginning of value at offset 5",
+		`[[[[1]]]]`:                "maximum depth 3 exceeded at offset 4",
+		`["abcd"]`:                 "string of 4 bytes exceeds the maximum of 3 at offset 7",
+		`{"abcd": 1}`:              "string of 4 bytes exceeds the maximum of 3 at offset 7",
+		`[1, 2, 3]`:                "more than 2 elements at offset 8",
+		`{"a": 1, "b": 2, "c": 3}`: "more than 2 elements at offset 20",
+		`{"a": {"a": 1}, "a": 2}`:  `duplicate key "a" at offset 19`,
+	}
+	for input, expected := range tests {
+		err := Valid(strings.NewReader(input), limits...)
+		if err == nil || err.Error() != expected {
+			t.Errorf("%q: expected error %q, got %v", input, expected, err)
+		}
+		if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("%q: expected a *ValidationError, got %T", input, err)
+		}
+	}
+	for input, expected := range map[string]string{
+		`{"a": 1, "a": 2}`: `duplicate key "a" in object at offset 9`,
+		`{"a": 1`:          "unexpected EOF at offset 7",
+		`{} [1]`:           `invalid character '[' looking for beginning of top level object at offset 3`,
+	} {
+		err := Valid(strings.NewReader(input), ValidSyntax(Strict(), ObjectsOnly()))
+		if _, ok := err.(*ValidationError); !ok || err.Error() != expected {
+			t.Errorf("%q: expected validation error %q, got %v", input, expected, err)
+		}
+	}
+	err := Valid(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader(`[1, 2]`))))
+	if !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,345 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tada/catch"
+	"github.com/tada/catch/pio"
+)
+
+// Kind is the kind of a JSON value.
+type Kind int
+
+const (
+	// KindInvalid is the kind of a Value that doesn't exist, e.g. the result of a Get of a missing key.
+	KindInvalid = Kind(iota)
+
+	// KindNull is the kind of the literal null.
+	KindNull
+
+	// KindBool is the kind of the literals true and false.
+	KindBool
+
+	// KindNumber is the kind of a number.
+	KindNumber
+
+	// KindString is the kind of a string.
+	KindString
+
+	// KindArray is the kind of an array.
+	KindArray
+
+	// KindObject is the kind of an object.
+	KindObject
+)
+
+// String returns the name of the kind, e.g. "object".
+func (k Kind) String() string {
+	switch k {
+	case KindInvalid:
+		return "invalid"
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindArray:
+		return "array"
+	case KindObject:
+		return "object"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// kindOfRaw returns the kind of the value that starts with the first byte of the given raw JSON.
+func kindOfRaw(raw []byte) Kind {
+	if len(raw) == 0 {
+		return KindInvalid
+	}
+	switch raw[0] {
+	case 'n':
+		return KindNull
+	case 't', 'f':
+		return KindBool
+	case '"':
+		return KindString
+	case '[':
+		return KindArray
+	case '{':
+		return KindObject
+	default:
+		return KindNumber
+	}
+}
+
+// kindOfToken returns the kind of the value that starts with the given token, or KindInvalid if the token is the end
+// of an array or an object.
+func kindOfToken(t json.Token) Kind {
+	switch t := t.(type) {
+	case nil:
+		return KindNull
+	case bool:
+		return KindBool
+	case json.Number, float64:
+		return KindNumber
+	case string:
+		return KindString
+	case json.Delim:
+		switch t {
+		case '[':
+			return KindArray
+		case '{':
+			return KindObject
+		}
+	}
+	return KindInvalid
+}
+
+// A Value is a JSON value that is kept as its raw bytes and parsed lazily when its accessor methods are called. It is
+// a middle ground between a Consumer, which must know the structure of what it reads, and a map[string]interface{},
+// which parses and allocates everything, and is typically used in exploratory code or to pass on parts of a document
+// that are rarely looked at. The accessors can be chained, e.g. v.Get("items").Index(0).Get("id").Int(), since a
+// missing key or index results in a Value of KindInvalid.
+//
+// A Value is a Consumer and a Streamer, so it can be a field of a Consumer. The zero Value is of KindInvalid.
+type Value struct {
+	raw  []byte
+	kind Kind
+}
+
+// ParseValue returns a Value that holds the given JSON, which must be a single value. The syntax of the value is
+// validated but nothing is parsed. The Value retains the given bytes.
+func ParseValue(bs []byte) (v Value, err error) {
+	err = catch.Do(func() {
+		d := NewBytesDecoder(bs)
+		v = valueOf(d.ReadRawAppend(nil))
+		if ts := d.PeekN(1); len(ts) > 0 {
+			panic(catch.Error("unexpected %v after the value", ts[0]))
+		}
+	})
+	return
+}
+
+func valueOf(raw []byte) Value {
+	return Value{raw: raw, kind: kindOfRaw(raw)}
+}
+
+// Kind returns the kind of the value.
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// Exists returns true if the value isn't of KindInvalid.
+func (v Value) Exists() bool {
+	return v.kind != KindInvalid
+}
+
+// Raw returns the raw JSON of the value, or nil if the value is of KindInvalid. Whitespace inside the value is kept as
+// it was read. The returned bytes must not be modified.
+func (v Value) Raw() []byte {
+	return v.raw
+}
+
+// Get returns the value of the given key of an object, or a Value of KindInvalid if the value isn't an object or if it
+// doesn't have the key.
+func (v Value) Get(key string) (found Value) {
+	v.each(KindObject, func(d Decoder, k string, _ int) bool {
+		if k == key {
+			found = valueOf(d.ReadRawAppend(nil))
+			return false
+		}
+		d.Skip()
+		return true
+	})
+	return
+}
+
+// Index returns the element at the given index of an array, or a Value of KindInvalid if the value isn't an array or
+// if the index is out of range.
+func (v Value) Index(i int) (found Value) {
+	v.each(KindArray, func(d Decoder, _ string, n int) bool {
+		if n == i {
+			found = valueOf(d.ReadRawAppend(nil))
+			return false
+		}
+		d.Skip()
+		return true
+	})
+	return
+}
+
+// Keys returns the keys of an object in the order that they appear, or nil if the value isn't an object.
+func (v Value) Keys() (keys []string) {
+	v.each(KindObject, func(d Decoder, k string, _ int) bool {
+		keys = append(keys, k)
+		d.Skip()
+		return true
+	})
+	return
+}
+
+// Len returns the number of elements of an array or the number of members of an object, or 0 if the value is neither.
+func (v Value) Len() (n int) {
+	v.each(v.kind, func(d Decoder, _ string, _ int) bool {
+		n++
+		d.Skip()
+		return true
+	})
+	return
+}
+
+// each calls f for each element of an array or member of an object when the value is of the given kind, which must be
+// KindArray or KindObject, until f returns false. The function f is called with the decoder positioned at the value
+// of the element or member, which f must read.
+func (v Value) each(kind Kind, f func(d Decoder, key string, i int) bool) {
+	if v.kind != kind || !(kind == KindArray || kind == KindObject) {
+		return
+	}
+	d := NewBytesDecoder(v.raw)
+	if kind == KindArray {
+		d.ReadDelim('[')
+		for i := 0; !isDelim(d.PeekN(1)[0], ']'); i++ {
+			if !f(d, "", i) {
+				return
+			}
+		}
+		return
+	}
+	d.ReadDelim('{')
+	for i := 0; ; i++ {
+		key, ok := d.ReadStringOrEnd('}')
+		if !(ok && f(d, key, i)) {
+			return
+		}
+	}
+}
+
+// Bool returns the value as a boolean. An error is returned if the value isn't a boolean or null.
+func (v Value) Bool() (b bool, err error) {
+	err = v.read(func(d Decoder) { b = d.ReadBool() })
+	return
+}
+
+// Float returns the value as a float. An error is returned if the value isn't a number or null.
+func (v Value) Float() (f float64, err error) {
+	err = v.read(func(d Decoder) { f = d.ReadFloat() })
+	return
+}
+
+// Int returns the value as an integer. An error is returned if the value isn't an integer or null.
+func (v Value) Int() (i int64, err error) {
+	err = v.read(func(d Decoder) { i = d.ReadInt() })
+	return
+}
+
+// Str returns the value as a string. An error is returned if the value isn't a string or null.
+func (v Value) Str() (s string, err error) {
+	err = v.read(func(d Decoder) { s = d.ReadString() })
+	return
+}
+
+// Decode reads the value into the given Consumer.
+func (v Value) Decode(c Consumer) error {
+	return v.read(func(d Decoder) { d.ReadConsumer(c) })
+}
+
+func (v Value) read(f func(d Decoder)) error {
+	if v.kind == KindInvalid {
+		return catch.Error("value does not exist")
+	}
+	return catch.Do(func() { f(NewBytesDecoder(v.raw)) })
+}
+
+// String returns the raw JSON of the value.
+func (v Value) String() string {
+	return string(v.raw)
+}
+
+// MarshalToJSON writes the raw JSON of the value, or null if the value is of KindInvalid.
+func (v Value) MarshalToJSON(w io.Writer) {
+	if v.kind == KindInvalid {
+		WriteNull(w)
+		return
+	}
+	pio.Write(w, v.raw)
+}
+
+// UnmarshalFromJSON captures the value that starts with the given firstToken.
+func (v *Value) UnmarshalFromJSON(js Decoder, firstToken json.Token) {
+	*v = valueOf(appendValue(nil, js, firstToken))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v Value) MarshalJSON() ([]byte, error) {
+	return Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Value) UnmarshalJSON(bs []byte) error {
+	return Unmarshal(v, bs)
+}
+
+// appendValue appends the compact JSON of the value that starts with the given first token, which has already been
+// read from the decoder, to dst.
+func appendValue(dst []byte, js Decoder, first json.Token) []byte {
+	switch {
+	case isDelim(first, '['):
+		dst = append(dst, '[')
+		for n := 0; ; n++ {
+			if n > 0 {
+				dst = append(dst, ',')
+			}
+			var ok bool
+			if dst, ok = js.ReadRawAppendOrEnd(dst, ']'); !ok {
+				if n > 0 {
+					dst = dst[:len(dst)-1]
+				}
+				return append(dst, ']')
+			}
+		}
+	case isDelim(first, '{'):
+		dst = append(dst, '{')
+		for n := 0; ; n++ {
+			key, ok := js.ReadStringOrEnd('}')
+			if !ok {
+				return append(dst, '}')
+			}
+			if n > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendToken(dst, 0, key, true)
+			dst = js.ReadRawAppend(dst)
+		}
+	default:
+		return appendToken(dst, 0, first, false)
+	}
+}
+
+// LazyObject reads the next value from the decoder, which must be an object or null, and returns its members as
+// Values. Only the keys are parsed, so the fields that are needed can be decoded right away and the rest later, or
+// never. A repeated key keeps its last value. The map is nil when the value is null.
+//
+// The function will recover a catch.Error panic and return its cause.
+func LazyObject(d Decoder) (m map[string]Value, err error) {
+	err = catch.Do(func() {
+		if peekToken(d) == nil {
+			d.Skip()
+			return
+		}
+		d.ReadDelim('{')
+		m = make(map[string]Value)
+		for {
+			key, ok := d.ReadStringOrEnd('}')
+			if !ok {
+				return
+			}
+			m[key] = valueOf(d.ReadRawAppend(nil))
+		}
+	})
+	return
+}
@@ -0,0 +1,155 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+func TestParseValue(t *testing.T) {
+	v, err := ParseValue([]byte(` {"items": [{"id": 7, "name": "a"}, {"id": 8}], "ok": true, "n": null} `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Kind() != KindObject {
+		t.Fatalf("expected object, got %s", v.Kind())
+	}
+	if a := fmt.Sprint(v.Keys(), v.Len()); a != `[items ok n] 3` {
+		t.Errorf("unexpected keys %s", a)
+	}
+	items := v.Get("items")
+	if a := fmt.Sprint(items.Kind(), items.Len()); a != `array 2` {
+		t.Errorf("unexpected items %s", a)
+	}
+	if id, err := items.Index(1).Get("id").Int(); err != nil || id != 8 {
+		t.Errorf("expected id 8, got %d, %v", id, err)
+	}
+	if name, err := items.Index(0).Get("name").Str(); err != nil || name != "a" {
+		t.Errorf("expected name a, got %q, %v", name, err)
+	}
+	if ok, err := v.Get("ok").Bool(); err != nil || !ok {
+		t.Errorf("expected ok, got %t, %v", ok, err)
+	}
+	if k := v.Get("n").Kind(); k != KindNull {
+		t.Errorf("expected null, got %s", k)
+	}
+	for _, m := range []Value{v.Get("x"), items.Index(2), items.Get("id"), v.Get("ok").Index(0)} {
+		if m.Exists() {
+			t.Errorf("expected missing value, got %s", m)
+		}
+	}
+	if _, err := v.Get("x").Int(); err == nil || err.Error() != "value does not exist" {
+		t.Errorf("expected missing value error, got %v", err)
+	}
+	if _, err := v.Get("ok").Int(); err == nil {
+		t.Error("expected type error")
+	}
+	l := intList{}
+	if err := items.Index(1).Get("id").Decode(&l); err == nil {
+		t.Error("expected decode error")
+	}
+	if lv, _ := ParseValue([]byte(`[1,2]`)); lv.Decode(&l) != nil || len(l) != 2 {
+		t.Errorf("unexpected decoded list %v", l)
+	}
+
+	for _, bad := range []string{`{"a":}`, `1 2`, ``} {
+		if _, err := ParseValue([]byte(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestValue_consumer(t *testing.T) {
+	var s struct {
+		A Value
+		B Value
+		C Value
+	}
+	input := `{"A": {"x": [1,"y",{}], "z": []}, "B": "s", "C": 1.5}`
+	if err := json.Unmarshal([]byte(input), &s); err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprint(s.A, " ", s.B, " ", s.C); a != `{"x":[1,"y",{}],"z":[]} "s" 1.5` {
+		t.Errorf("unexpected values %s", a)
+	}
+	var vs []Value
+	err := Unmarshal(consumerFunc(func(js Decoder, firstToken json.Token) {
+		AssertDelim(firstToken, '[')
+		for {
+			var v Value
+			if found, ok := js.ReadConsumerOrEnd(&v, ']'); !ok {
+				break
+			} else if found {
+				vs = append(vs, v)
+			}
+		}
+	}), []byte(`[{"a": [true,null]}, [], "x"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := fmt.Sprint(vs); a != `[{"a":[true,null]} [] "x"]` {
+		t.Errorf("unexpected values %s", a)
+	}
+	bs, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := string(bs); a != `{"A":{"x":[1,"y",{}],"z":[]},"B":"s","C":1.5}` {
+		t.Errorf("unexpected output %s", a)
+	}
+	if bs, _ := Marshal(Value{}); string(bs) != "null" {
+		t.Errorf("expected null, got %s", bs)
+	}
+}
+
+func TestValue_controlCharacters(t *testing.T) {
+	input := `["a\nb", {"k\n": "v\u0001"}]`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		var a, b Value
+		err := catch.Do(func() {
+			js.ReadDelim('[')
+			js.ReadConsumer(&a)
+			js.ReadConsumer(&b)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := Marshal(b)
+		if err != nil || string(bs) != `{"k\n":"v\u0001"}` {
+			t.Fatalf("unexpected output %q, %v", bs, err)
+		}
+		var s string
+		if err = json.Unmarshal([]byte(a.String()), &s); err != nil || s != "a\nb" {
+			t.Fatalf("expected %q, got %q, %v", "a\nb", s, err)
+		}
+		if v, err := ParseValue(bs); err != nil || v.String() != string(bs) {
+			t.Fatalf("expected round trip of %s, got %s, %v", bs, v, err)
+		}
+	}
+}
+
+func TestLazyObject(t *testing.T) {
+	input := `{"id": 7, "payload": {"big": [1,2,3]}, "id": 8} null`
+	for _, js := range []Decoder{decoderOn(input), NewBytesDecoder([]byte(input))} {
+		m, err := LazyObject(js)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, err := m["id"].Int(); err != nil || id != 8 {
+			t.Errorf("expected id 8, got %d, %v", id, err)
+		}
+		if a := m["payload"].Get("big").String(); a != `[1,2,3]` {
+			t.Errorf("unexpected payload %s", a)
+		}
+		if m, err = LazyObject(js); err != nil || m != nil {
+			t.Errorf("expected nil map for null, got %v, %v", m, err)
+		}
+	}
+	for _, bad := range []string{`[]`, `{"a":`, ``} {
+		if _, err := LazyObject(NewBytesDecoder([]byte(bad))); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package jsonstream
+
+import (
+	"io"
+	"reflect"
+	"sort"
+)
+
+// An ObjectWriter writes the fields of a JSON object and keeps track of whether a comma is needed before the next
+// field. All methods panic with a catch.Error if an error occurs.
+type ObjectWriter struct {
+	w io.Writer
+	n int
+}
+
+// An ArrayWriter writes the elements of a JSON array and keeps track of whether a comma is needed before the next
+// element. All methods panic with a catch.Error if an error occurs.
+type ArrayWriter struct {
+	w io.Writer
+	n int
+}
+
+// NewObjectWriter writes the opening '{' of an object on the given writer and returns an ObjectWriter for the
+// fields of that object.
+func NewObjectWriter(w io.Writer) *ObjectWriter {
+	WriteByte(w, '{')
+	return &ObjectWriter{w: w}
+}
+
+// NewArrayWriter writes the opening '[' of an array on the given writer and returns an ArrayWriter for the elements
+// of that array.
+func NewArrayWriter(w io.Writer) *ArrayWriter {
+	WriteByte(w, '[')
+	return &ArrayWriter{w: w}
+}
+
+// Field writes a comma if needed followed by the given key and a colon. The value of the field must be written on
+// the writer before the next call to the ObjectWriter.
+func (o *ObjectWriter) Field(key string) {
+	if o.n > 0 {
+		valueWritten(o.w)
+		WriteByte(o.w, ',')
+	}
+	o.n++
+	WriteString(o.w, key)
+	WriteByte(o.w, ':')
+}
+
+// Bool writes a field with a boolean value.
+func (o *ObjectWriter) Bool(key string, v bool) {
+	o.Field(key)
+	WriteBool(o.w, v)
+}
+
+// Float writes a field with a float value.
+func (o *ObjectWriter) Float(key string, v float64) {
+	o.Field(key)
+	WriteFloat(o.w, v)
+}
+
+// Int writes a field with an integer value.
+func (o *ObjectWriter) Int(key string, v int64) {
+	o.Field(key)
+	WriteInt(o.w, v)
+}
+
+// String writes a field with a string value.
+func (o *ObjectWriter) String(key string, v string) {
+	o.Field(key)
+	WriteString(o.w, v)
+}
+
+// Null writes a field with a null value. The field is omitted when the OmitNull option is in effect.
+func (o *ObjectWriter) Null(key string) {
+	if !optionsOf(o.w).omitNull {
+		o.Field(key)
+		WriteNull(o.w)
+	}
+}
+
+// Streamer writes a field with a value that is streamed by the given Streamer, or null if the Streamer is nil.
+func (o *ObjectWriter) Streamer(key string, v Streamer) {
+	o.Field(key)
+	WriteStreamer(o.w, v)
+}
+
+// StreamerSlice writes a field with an array of the values that are streamed by the given Streamers.
+func (o *ObjectWriter) StreamerSlice(key string, vs []Streamer) {
+	o.Field(key)
+	WriteStreamerSlice(o.w, vs)
+}
+
+// StreamerMap writes a field with an object of the values that are streamed by the Streamers of the given map.
+func (o *ObjectWriter) StreamerMap(key string, vs map[string]Streamer) {
+	o.Field(key)
+	WriteStreamerMap(o.w, vs)
+}
+
+// Close writes the closing '}' of the object.
+func (o *ObjectWriter) Close() {
+	if o.n > 0 {
+		valueWritten(o.w)
+	}
+	WriteByte(o.w, '}')
+}
+
+// Element writes a comma if needed. The element must be written on the writer before the next call to the
+// ArrayWriter.
+func (a *ArrayWriter) Element() {
+	if a.n > 0 {
+		valueWritten(a.w)
+		WriteByte(a.w, ',')
+	}
+	a.n++
+}
+
+// Bool writes a boolean element.
+func (a *ArrayWriter) Bool(v bool) {
+	a.Element()
+	WriteBool(a.w, v)
+}
+
+// Float writes a float element.
+func (a *ArrayWriter) Float(v float64) {
+	a.Element()
+	WriteFloat(a.w, v)
+}
+
+// Int writes an integer element.
+func (a *ArrayWriter) Int(v int64) {
+	a.Element()
+	WriteInt(a.w, v)
+}
+
+// String writes a string element.
+func (a *ArrayWriter) String(v string) {
+	a.Element()
+	WriteString(a.w, v)
+}
+
+// Streamer writes an element that is streamed by the given Streamer, or null if the Streamer is nil.
+func (a *ArrayWriter) Streamer(v Streamer) {
+	a.Element()
+	WriteStreamer(a.w, v)
+}
+
+// Close writes the closing ']' of the array.
+func (a *ArrayWriter) Close() {
+	if a.n > 0 {
+		valueWritten(a.w)
+	}
+	WriteByte(a.w, ']')
+}
+
+// WriteStreamer writes the value that is streamed by the given Streamer, or null if the Streamer is nil or holds a nil
+// pointer, map, or slice. It lets a Streamer that is composed of other Streamers write them without knowing whether
+// they are set.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteStreamer(w io.Writer, s Streamer) {
+	if isNilStreamer(s) {
+		WriteNull(w)
+		return
+	}
+	s.MarshalToJSON(w)
+}
+
+// isNilStreamer returns true if the given Streamer is nil or is a nil value of a type that can be nil.
+func isNilStreamer(s Streamer) bool {
+	if s == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(s); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+// WriteStreamerSlice writes an array of the values that are streamed by the given Streamers. A nil Streamer is
+// written as null and a nil slice as an empty array.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteStreamerSlice(w io.Writer, ss []Streamer) {
+	a := NewArrayWriter(w)
+	for _, s := range ss {
+		a.Streamer(s)
+	}
+	a.Close()
+}
+
+// WriteStreamerMap writes an object with a field for each entry of the given map, with the value that is streamed by
+// the Streamer of the entry. A nil Streamer is written as null and a nil map as an empty object. The fields are written
+// in the order of their keys when the SortMapKeys option is in effect.
+//
+// If an error occurs the method panics with a catch.Error with the Cause set to that error
+func WriteStreamerMap(w io.Writer, ss map[string]Streamer) {
+	keys := make([]string, 0, len(ss))
+	for k := range ss {
+		keys = append(keys, k)
+	}
+	if optionsOf(w).sortKeys {
+		sort.Strings(keys)
+	}
+	o := NewObjectWriter(w)
+	for _, k := range keys {
+		o.Streamer(k, ss[k])
+	}
+	o.Close()
+}
@@ -0,0 +1,73 @@
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestObjectWriter(t *testing.T) {
+	b := bytes.Buffer{}
+	o := NewObjectWriter(&b)
+	o.String("s", "x")
+	o.Int("i", 1)
+	o.Float("f", 1.5)
+	o.Bool("b", true)
+	o.Streamer("t", &ts{v: time.Millisecond})
+	o.Field("a")
+	a := NewArrayWriter(&b)
+	a.String("x")
+	a.Int(1)
+	a.Float(1.5)
+	a.Bool(false)
+	a.Streamer(&ts{v: 2 * time.Millisecond})
+	a.Element()
+	NewArrayWriter(&b).Close()
+	a.Close()
+	o.Field("o")
+	NewObjectWriter(&b).Close()
+	o.Close()
+	ex := `{"s":"x","i":1,"f":1.5,"b":true,"t":{"v":1},"a":["x",1,1.5,false,{"v":2},[]],"o":{}}`
+	if ac := b.String(); ac != ex {
+		t.Fatalf("expected %s, got %s", ex, ac)
+	}
+}
+
+func TestWriteStreamerSlice(t *testing.T) {
+	b := bytes.Buffer{}
+	o := NewObjectWriter(&b)
+	var np *ts
+	o.StreamerSlice("a", []Streamer{&ts{v: time.Millisecond}, nil, &intList{1, 2}, np, valueOf([]byte(`true`))})
+	o.StreamerSlice("e", nil)
+	o.Streamer("n", nil)
+	o.Streamer("p", np)
+	o.Close()
+	ex := `{"a":[{"v":1},null,[1,2],null,true],"e":[],"n":null,"p":null}`
+	if ac := b.String(); ac != ex {
+		t.Fatalf("expected %s, got %s", ex, ac)
+	}
+}
+
+func TestWriteStreamerMap(t *testing.T) {
+	m := map[string]Streamer{"c": &intList{1, 2}, "a": &ts{v: time.Millisecond}, "b": nil, "d": valueOf([]byte(`true`))}
+	s := AsStreamer(errorStreamerFunc(func(w io.Writer) error {
+		o := NewObjectWriter(w)
+		o.StreamerMap("m", m)
+		o.StreamerMap("e", nil)
+		o.Close()
+		return nil
+	}))
+	ex := `{"m":{"a":{"v":1},"b":null,"c":[1,2],"d":true},"e":{}}`
+	for i := 0; i < 10; i++ {
+		if ac := string(MustMarshal(s, SortMapKeys())); ac != ex {
+			t.Fatalf("expected %s, got %s", ex, ac)
+		}
+	}
+	ac := MustMarshal(s)
+	var v map[string]map[string]interface{}
+	if err := json.Unmarshal(ac, &v); err != nil || len(v["m"]) != 4 {
+		t.Fatalf("expected an object with 4 fields, got %s", ac)
+	}
+}
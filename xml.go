@@ -0,0 +1,193 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// An XMLMapping is the convention that maps XML elements to the JSON tokens read by a decoder created with
+// NewXMLDecoder:
+//
+// The root element becomes an object with the name of the element as its only key. An element without attributes and
+// child elements becomes a string with its text. Any other element becomes an object where each attribute is a member
+// with the name of the attribute, prefixed with AttrPrefix, and a string value, and each child element is a member with
+// the name of the child. Text that is mixed with child elements is trimmed and, unless empty, becomes a member with the
+// key TextKey. Namespaces are ignored.
+//
+// Since the elements are read as they are streamed, a repeated child element becomes a repeated key. A child element
+// whose name is listed in Arrays instead becomes an array that holds the consecutive elements of that name, e.g.
+// <items><item>a</item><item>b</item></items> becomes {"items":{"item":["a","b"]}}.
+type XMLMapping struct {
+	AttrPrefix string
+	TextKey    string
+	Arrays     []string
+}
+
+// NewXMLMapping creates a new XMLMapping with the AttrPrefix "@" and the TextKey "#text" that maps the child elements
+// with the given names to arrays. The mapping without arrays is used when none is given to NewXMLDecoder.
+func NewXMLMapping(arrays ...string) *XMLMapping {
+	return &XMLMapping{AttrPrefix: "@", TextKey: "#text", Arrays: arrays}
+}
+
+// xmlFrame is an element that has become an object.
+type xmlFrame struct {
+	// openArray is the name of the child elements whose array is open, or the empty string
+	openArray string
+}
+
+// xmlSource is a TokenSource that converts the tokens of an xml.Decoder to JSON tokens.
+type xmlSource struct {
+	xd      *xml.Decoder
+	m       *XMLMapping
+	arrays  map[string]bool
+	queue   []json.Token
+	stack   []xmlFrame
+	peeked  xml.Token
+	started bool
+	done    bool
+}
+
+// NewXMLDecoder creates a new Decoder that reads the XML document of the given xml.Decoder as the JSON tokens given by
+// the mapping, or by NewXMLMapping() if the mapping is nil, so that legacy XML feeds can be read by existing
+// Consumers. All text becomes strings, so numbers and booleans are typically read using the CoerceScalars option.
+// Options that are only effective for decoders created with NewDecoder or NewBytesDecoder are ignored.
+func NewXMLDecoder(xd *xml.Decoder, m *XMLMapping, options ...DecoderOption) Decoder {
+	if m == nil {
+		m = NewXMLMapping()
+	}
+	arrays := make(map[string]bool, len(m.Arrays))
+	for _, name := range m.Arrays {
+		arrays[name] = true
+	}
+	return NewTokenSourceDecoder(&xmlSource{xd: xd, m: m, arrays: arrays}, options...)
+}
+
+// Token returns the next JSON token.
+func (s *xmlSource) Token() (json.Token, error) {
+	for len(s.queue) == 0 {
+		if s.done {
+			return nil, io.EOF
+		}
+		if err := s.fill(); err != nil {
+			return nil, err
+		}
+	}
+	t := s.queue[0]
+	s.queue = s.queue[1:]
+	return t, nil
+}
+
+// next returns the next XML token that isn't a comment, a processing instruction, or a directive. The returned token
+// is only valid until the next call.
+func (s *xmlSource) next() (xml.Token, error) {
+	if t := s.peeked; t != nil {
+		s.peeked = nil
+		return t, nil
+	}
+	for {
+		t, err := s.xd.Token()
+		if err != nil {
+			if err == io.EOF && s.started {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		switch t.(type) {
+		case xml.Comment, xml.ProcInst, xml.Directive:
+		default:
+			return t, nil
+		}
+	}
+}
+
+func (s *xmlSource) emit(ts ...json.Token) {
+	s.queue = append(s.queue, ts...)
+}
+
+// closeArray closes the open array of the given frame, if any.
+func (s *xmlSource) closeArray(f *xmlFrame) {
+	if f.openArray != "" {
+		s.emit(json.Delim(']'))
+		f.openArray = ""
+	}
+}
+
+// fill converts the next XML token to JSON tokens.
+func (s *xmlSource) fill() error {
+	t, err := s.next()
+	if err != nil {
+		return err
+	}
+	n := len(s.stack)
+	switch t := t.(type) {
+	case xml.StartElement:
+		name := t.Name.Local
+		if n == 0 {
+			s.started = true
+			s.emit(json.Delim('{'), name)
+		} else {
+			f := &s.stack[n-1]
+			if f.openArray != name {
+				s.closeArray(f)
+				s.emit(name)
+				if s.arrays[name] {
+					s.emit(json.Delim('['))
+					f.openArray = name
+				}
+			}
+		}
+		return s.element(t)
+	case xml.EndElement:
+		s.closeArray(&s.stack[n-1])
+		s.emit(json.Delim('}'))
+		s.stack = s.stack[:n-1]
+		if n == 1 {
+			s.emit(json.Delim('}'))
+			s.done = true
+		}
+	case xml.CharData:
+		if text := strings.TrimSpace(string(t)); text != "" && n > 0 {
+			s.closeArray(&s.stack[n-1])
+			s.emit(s.m.TextKey, text)
+		}
+	}
+	return nil
+}
+
+// element converts the start of the given element, which has been read, and its leading text.
+func (s *xmlSource) element(se xml.StartElement) error {
+	attrs := make([]json.Token, 0, 2*len(se.Attr))
+	for _, a := range se.Attr {
+		attrs = append(attrs, s.m.AttrPrefix+a.Name.Local, a.Value)
+	}
+	var text []byte
+	for {
+		t, err := s.next()
+		if err != nil {
+			return err
+		}
+		if cd, ok := t.(xml.CharData); ok {
+			text = append(text, cd...)
+			continue
+		}
+		if _, ok := t.(xml.EndElement); ok && len(attrs) == 0 {
+			s.emit(string(text))
+			if len(s.stack) == 0 {
+				s.emit(json.Delim('}'))
+				s.done = true
+			}
+			return nil
+		}
+		s.peeked = xml.CopyToken(t)
+		break
+	}
+	s.emit(json.Delim('{'))
+	s.emit(attrs...)
+	if trimmed := strings.TrimSpace(string(text)); trimmed != "" {
+		s.emit(s.m.TextKey, trimmed)
+	}
+	s.stack = append(s.stack, xmlFrame{})
+	return nil
+}
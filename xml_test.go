@@ -0,0 +1,81 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/tada/catch"
+)
+
+const xmlFeed = `<?xml version="1.0"?>
+<!-- a feed -->
+<feed version="2">
+	<title>News</title>
+	<entry id="1"><title>First</title><tag>a</tag><tag>b</tag></entry>
+	<entry id="2">Second <b>bold</b> tail</entry>
+	<empty/>
+</feed>`
+
+func xmlToJSON(t *testing.T, input string, m *XMLMapping) string {
+	t.Helper()
+	var raw []byte
+	err := catch.Do(func() {
+		raw = NewXMLDecoder(xml.NewDecoder(strings.NewReader(input)), m).ReadRawAppend(nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
+
+func TestNewXMLDecoder(t *testing.T) {
+	ex := `{"feed":{"@version":"2","title":"News",` +
+		`"entry":{"@id":"1","title":"First","tag":"a","tag":"b"},` +
+		`"entry":{"@id":"2","#text":"Second","b":"bold","#text":"tail"},"empty":""}}`
+	if a := xmlToJSON(t, xmlFeed, nil); a != ex {
+		t.Errorf("expected %s, got %s", ex, a)
+	}
+	ex = `{"feed":{"_version":"2","title":"News",` +
+		`"entry":[{"_id":"1","title":"First","tag":["a","b"]},{"_id":"2","text":"Second","b":"bold","text":"tail"}],` +
+		`"empty":""}}`
+	m := &XMLMapping{AttrPrefix: "_", TextKey: "text", Arrays: []string{"entry", "tag"}}
+	if a := xmlToJSON(t, xmlFeed, m); a != ex {
+		t.Errorf("expected %s, got %s", ex, a)
+	}
+	if a := xmlToJSON(t, `<n>42</n>`, nil); a != `{"n":"42"}` {
+		t.Errorf("unexpected leaf root %s", a)
+	}
+}
+
+func TestNewXMLDecoder_consumer(t *testing.T) {
+	js := NewXMLDecoder(xml.NewDecoder(strings.NewReader(`<r><v>1</v><v>2</v><v>3</v></r>`)),
+		NewXMLMapping("v"), CoerceScalars())
+	var l intList
+	err := catch.Do(func() {
+		js.ReadDelim('{')
+		js.ReadObjectFields(map[string]func(Decoder){
+			"r": func(d Decoder) {
+				d.ReadDelim('{')
+				d.ReadObjectFields(map[string]func(Decoder){"v": func(d Decoder) { d.ReadConsumer(&l) }}, nil)
+			},
+		}, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 3 || l[2] != 3 {
+		t.Errorf("unexpected list %v", l)
+	}
+	err = catch.Do(func() {
+		NewXMLDecoder(xml.NewDecoder(strings.NewReader(`<r><v>1</r>`)), nil).Skip()
+	})
+	if err == nil {
+		t.Error("expected XML syntax error")
+	}
+	var m json.RawMessage
+	if err = json.Unmarshal([]byte(xmlToJSON(t, xmlFeed, nil)), &m); err != nil {
+		t.Errorf("expected valid JSON, got %v", err)
+	}
+}